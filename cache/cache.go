@@ -0,0 +1,322 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	appDir     = "k8s-outdated"
+	metaSuffix = ".meta.json"
+
+	//DirEnv overrides the default cache directory
+	DirEnv = "K8S_OUTDATED_CACHE_DIR"
+)
+
+//Entry metadata of a cached download
+type Entry struct {
+	Key          string `json:"key"`
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	// Link is the pagination header of the answer
+	Link string `json:"link,omitempty"`
+	// Immutable entries, e.g. the swagger spec of a released tag, are stored forever and never revalidated
+	Immutable bool      `json:"immutable"`
+	Fetched   time.Time `json:"fetched"`
+	Size      int64     `json:"size"`
+}
+
+//StaleError is returned with the stored content of an entry which could not be revalidated, the content is the one
+//fetched at Entry.Fetched and may be out of date
+type StaleError struct {
+	Entry Entry
+	Err   error
+}
+
+func (e *StaleError) Error() string {
+	return fmt.Sprintf("using %s cached %s, revalidation failed: %s", e.Entry.Key, e.Entry.Fetched.Format(time.RFC3339), e.Err)
+}
+
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+//ReportStale write a *StaleError to w as a warning and return nil so the stale content is used, other errors are
+//returned unchanged
+func ReportStale(w io.Writer, err error) error {
+	var stale *StaleError
+	if !errors.As(err, &stale) {
+		return err
+	}
+	if w != nil {
+		fmt.Fprintf(w, "warning: %s\n", stale)
+	}
+	return nil
+}
+
+//Doer send http requests, e.g. *http.Client or the collector client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+//Cache persistent download cache, every entry is stored in a file named after its key
+type Cache struct {
+	dir     string
+	refresh bool
+	client  Doer
+}
+
+//DefaultDir return the cache directory, $K8S_OUTDATED_CACHE_DIR or k8s-outdated under the XDG cache directory
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(DirEnv); len(dir) > 0 {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, appDir), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDir), nil
+}
+
+//NewCache instansiate new Cache stored in dir, refresh downloads every entry again ignoring the stored data
+func NewCache(dir string, refresh bool) *Cache {
+	return &Cache{dir: dir, refresh: refresh, client: http.DefaultClient}
+}
+
+//WithClient return a copy of the cache downloading through client
+func (c Cache) WithClient(client Doer) *Cache {
+	c.client = client
+	return &c
+}
+
+//Dir return the cache directory
+func (c Cache) Dir() string {
+	return c.dir
+}
+
+//Fetch return the content of url stored under key. Immutable entries are downloaded once, other entries are
+//revalidated with If-None-Match and If-Modified-Since. When revalidation fails the stored content is returned with
+//a *StaleError, see ReportStale
+func (c Cache) Fetch(ctx context.Context, url string, key string, immutable bool) ([]byte, error) {
+	data, _, err := c.FetchEntry(ctx, url, key, immutable)
+	return data, err
+}
+
+//FetchEntry fetch like Fetch and also return the entry metadata, e.g. the pagination Link header
+func (c Cache) FetchEntry(ctx context.Context, url string, key string, immutable bool) ([]byte, Entry, error) {
+	path, err := c.path(key)
+	if err != nil {
+		return nil, Entry{}, err
+	}
+	entry, cached := c.read(path)
+	if c.refresh {
+		cached = false
+	}
+	if cached && entry.Immutable && immutable {
+		data, err := os.ReadFile(path)
+		return data, entry, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, entry, err
+	}
+	if cached {
+		if len(entry.ETag) > 0 {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if len(entry.LastModified) > 0 {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	data, entry, err := c.download(req, entry)
+	if err != nil {
+		// a cancelled run must not continue with stale data
+		if cached && ctx.Err() == nil {
+			data, readErr := os.ReadFile(path)
+			if readErr != nil {
+				return nil, entry, readErr
+			}
+			return data, entry, &StaleError{Entry: entry, Err: err}
+		}
+		return nil, entry, err
+	}
+	if data == nil {
+		// 304 Not Modified
+		entry.Fetched = time.Now().UTC()
+		if err := writeMeta(path, entry); err != nil {
+			return nil, entry, err
+		}
+		data, err := os.ReadFile(path)
+		return data, entry, err
+	}
+	entry.Key, entry.URL, entry.Immutable = key, url, immutable
+	entry.Fetched, entry.Size = time.Now().UTC(), int64(len(data))
+	if err := c.write(path, data, entry); err != nil {
+		return nil, entry, err
+	}
+	return data, entry, nil
+}
+
+//download send req, data is nil when the server answers 304 Not Modified
+func (c Cache) download(req *http.Request, entry Entry) ([]byte, Entry, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, entry, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		return nil, entry, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, entry, fmt.Errorf("GET %s: unexpected status %s", req.URL, res.Status)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, entry, err
+	}
+	entry.ETag = res.Header.Get("ETag")
+	entry.LastModified = res.Header.Get("Last-Modified")
+	entry.Link = res.Header.Get("Link")
+	return data, entry, nil
+}
+
+//Entries return the metadata of every cached download sorted by key
+func (c Cache) Entries() ([]Entry, error) {
+	entries := make([]Entry, 0)
+	err := filepath.WalkDir(c.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == c.dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, metaSuffix) {
+			return nil
+		}
+		entry, ok := c.read(strings.TrimSuffix(path, metaSuffix))
+		if ok {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries, nil
+}
+
+//Clear remove every cached download: the data files with a metadata file naming them and the metadata files. Other
+//files are kept, e.g. when the cache directory was set to a directory holding more than the cache, and so are the
+//directories they are in
+func (c Cache) Clear() error {
+	dirs := make([]string, 0)
+	err := filepath.WalkDir(c.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == c.dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		if !strings.HasSuffix(path, metaSuffix) {
+			return nil
+		}
+		dataPath := strings.TrimSuffix(path, metaSuffix)
+		entry, _ := c.read(dataPath)
+		if keyPath, err := c.path(entry.Key); err != nil || keyPath != dataPath {
+			// not written by the cache
+			return nil
+		}
+		for _, file := range []string{dataPath, path} {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// the walk lists parents first, removing in reverse only removes the directories left empty
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+	return nil
+}
+
+//path return the data file of key, keys are slash separated relative paths
+func (c Cache) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if len(key) == 0 || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid cache key %q", key)
+	}
+	return filepath.Join(c.dir, clean), nil
+}
+
+//read return the metadata of the data file at path, false when the entry is missing or incomplete
+func (c Cache) read(path string) (Entry, bool) {
+	var entry Entry
+	meta, err := os.ReadFile(path + metaSuffix)
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		return entry, false
+	}
+	if _, err := os.Stat(path); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+//write store data and its metadata, the data file is renamed into place so readers never see a partial download
+func (c Cache) write(path string, data []byte, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return writeMeta(path, entry)
+}
+
+func writeMeta(path string, entry Entry) error {
+	meta, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+metaSuffix, meta, 0600)
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//fakeServer serve body with an ETag, counting requests and answering 304 to a matching If-None-Match
+type fakeServer struct {
+	body        string
+	etag        string
+	requests    int
+	notModified int
+	fail        bool
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests++
+	if f.fail {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if r.Header.Get("If-None-Match") == f.etag {
+		f.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", f.etag)
+	w.Header().Set("Last-Modified", "Wed, 21 Oct 2020 07:28:00 GMT")
+	_, _ = w.Write([]byte(f.body))
+}
+
+func TestFetchImmutable(t *testing.T) {
+	f := &fakeServer{body: `{"swagger": "2.0"}`, etag: `"v1"`}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	c := NewCache(t.TempDir(), false)
+	for i := 0; i < 3; i++ {
+		data, err := c.Fetch(context.Background(), srv.URL, "swagger/v1.20.1.json", true)
+		assert.NoError(t, err)
+		assert.Equal(t, f.body, string(data))
+	}
+	assert.Equal(t, 1, f.requests)
+	refreshed := NewCache(c.Dir(), true)
+	_, err := refreshed.Fetch(context.Background(), srv.URL, "swagger/v1.20.1.json", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, f.requests)
+}
+
+func TestFetchRevalidate(t *testing.T) {
+	f := &fakeServer{body: "# guide", etag: `"v1"`}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	c := NewCache(t.TempDir(), false)
+	data, err := c.Fetch(context.Background(), srv.URL, "deprecation-guide.md", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "# guide", string(data))
+	data, err = c.Fetch(context.Background(), srv.URL, "deprecation-guide.md", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "# guide", string(data))
+	assert.Equal(t, 2, f.requests)
+	assert.Equal(t, 1, f.notModified)
+
+	f.body, f.etag = "# guide v2", `"v2"`
+	data, err = c.Fetch(context.Background(), srv.URL, "deprecation-guide.md", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "# guide v2", string(data))
+
+	f.fail = true
+	data, err = c.Fetch(context.Background(), srv.URL, "deprecation-guide.md", false)
+	var stale *StaleError
+	assert.ErrorAs(t, err, &stale)
+	assert.Equal(t, "deprecation-guide.md", stale.Entry.Key)
+	assert.Equal(t, "# guide v2", string(data))
+	var warnings bytes.Buffer
+	assert.NoError(t, ReportStale(&warnings, err))
+	assert.Contains(t, warnings.String(), "warning: using deprecation-guide.md cached ")
+	_, err = c.Fetch(context.Background(), srv.URL, "tags.json", false)
+	assert.Error(t, err)
+	assert.Error(t, ReportStale(&warnings, err))
+}
+
+func TestEntriesAndClear(t *testing.T) {
+	f := &fakeServer{body: "data", etag: `"v1"`}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	c := NewCache(filepath.Join(t.TempDir(), "cache"), false)
+	entries, err := c.Entries()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	_, err = c.Fetch(context.Background(), srv.URL+"/tags", "github/tags.json", false)
+	assert.NoError(t, err)
+	_, err = c.Fetch(context.Background(), srv.URL+"/swagger", "swagger/v1.21.0.json", true)
+	assert.NoError(t, err)
+	entries, err = c.Entries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "github/tags.json", entries[0].Key)
+	assert.Equal(t, `"v1"`, entries[0].ETag)
+	assert.False(t, entries[0].Immutable)
+	assert.Equal(t, "swagger/v1.21.0.json", entries[1].Key)
+	assert.Equal(t, int64(4), entries[1].Size)
+	assert.True(t, entries[1].Immutable)
+	assert.NoError(t, c.Clear())
+	entries, err = c.Entries()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	_, err = os.Stat(c.Dir())
+	assert.True(t, os.IsNotExist(err))
+	// clearing a missing cache is not an error
+	assert.NoError(t, c.Clear())
+}
+
+func TestClearKeepsForeignFiles(t *testing.T) {
+	f := &fakeServer{body: "data", etag: `"v1"`}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	dir := t.TempDir()
+	c := NewCache(dir, false)
+	_, err := c.Fetch(context.Background(), srv.URL+"/tags", "github/tags.json", false)
+	assert.NoError(t, err)
+	_, err = c.Fetch(context.Background(), srv.URL+"/swagger", "swagger/v1.21.0.json", true)
+	assert.NoError(t, err)
+	foreign := []string{filepath.Join(dir, "notes.txt"), filepath.Join(dir, "swagger", "local.json"),
+		// a metadata file naming another data file was not written by the cache
+		filepath.Join(dir, "swagger", "copy.json.meta.json")}
+	assert.NoError(t, os.WriteFile(foreign[0], []byte("keep"), 0600))
+	assert.NoError(t, os.WriteFile(foreign[1], []byte("keep"), 0600))
+	assert.NoError(t, os.WriteFile(foreign[2], []byte(`{"key": "swagger/v1.21.0.json"}`), 0600))
+
+	assert.NoError(t, c.Clear())
+	for _, path := range foreign {
+		_, err := os.Stat(path)
+		assert.NoError(t, err, path)
+	}
+	for _, path := range []string{"github", "swagger/v1.21.0.json", "swagger/v1.21.0.json.meta.json"} {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path)))
+		assert.True(t, os.IsNotExist(err), path)
+	}
+}
+
+func TestFetchCancelled(t *testing.T) {
+	f := &fakeServer{body: "# guide", etag: `"v1"`}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	c := NewCache(t.TempDir(), false)
+	_, err := c.Fetch(context.Background(), srv.URL, "deprecation-guide.md", false)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Fetch(ctx, srv.URL, "deprecation-guide.md", false)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestInvalidKey(t *testing.T) {
+	c := NewCache(t.TempDir(), false)
+	for _, key := range []string{"", "../outside", "/etc/passwd"} {
+		_, err := c.Fetch(context.Background(), "http://127.0.0.1:1", key, true)
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"k8s-outdated/cache"
+	"k8s-outdated/output"
+	"strconv"
+	"time"
+)
+
+//cacheRow table row of a cached download
+type cacheRow struct {
+	Key       string `header:"key"`
+	Size      string `header:"size"`
+	Fetched   string `header:"fetched"`
+	Immutable bool   `header:"immutable"`
+	ETag      string `header:"etag"`
+}
+
+func newCacheCommand(c *collectors) *cobra.Command {
+	var format string
+	var clearCache bool
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect or clear the cache of downloaded swagger specs, tag lists and deprecation guide",
+		Long: "Print the cache directory and every cached download. Swagger specs of released tags are stored forever,\n" +
+			"the tag list and the deprecation guide are revalidated with ETag and If-Modified-Since on every run.",
+		Example: "  k8s-outdated cache\n  k8s-outdated cache --clear\n  k8s-outdated list -k v1.20.0 --refresh-cache",
+		Args:    usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			downloads, err := c.opts.cache()
+			if err != nil {
+				return err
+			}
+			if downloads == nil {
+				return usageErrorf("--%s has no cache to inspect", noCacheFlag)
+			}
+			if clearCache {
+				if err := downloads.Clear(); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "cleared %s\n", downloads.Dir())
+				return nil
+			}
+			return printCache(cmd, downloads, format)
+		},
+	}
+	cmd.Flags().BoolVar(&clearCache, "clear", false, "remove every cached download")
+	cmd.Flags().StringVarP(&format, outputFlag, "o", string(output.Table), "output format, one of: table, json")
+	return cmd
+}
+
+func printCache(cmd *cobra.Command, downloads *cache.Cache, format string) error {
+	entries, err := downloads.Entries()
+	if err != nil {
+		return err
+	}
+	switch output.Format(format) {
+	case output.JSON:
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Dir     string        `json:"dir"`
+			Entries []cache.Entry `json:"entries"`
+		}{Dir: downloads.Dir(), Entries: entries})
+	case output.Table:
+		fmt.Fprintf(cmd.OutOrStdout(), "cache directory: %s\n", downloads.Dir())
+		rows := make([]cacheRow, 0, len(entries))
+		for _, e := range entries {
+			rows = append(rows, cacheRow{Key: e.Key, Size: strconv.FormatInt(e.Size, 10), Fetched: e.Fetched.Format(time.RFC3339),
+				Immutable: e.Immutable, ETag: e.ETag})
+		}
+		if len(rows) > 0 {
+			printTable(cmd.OutOrStdout(), rows)
+		}
+		return nil
+	}
+	return usageErrorf("unsupported cache output format %q, supported formats: table, json", format)
+}
@@ -0,0 +1,461 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/lensesio/tableprinter"
+	"github.com/spf13/cobra"
+	"io"
+	"k8s-outdated/cache"
+	"k8s-outdated/collector"
+	"k8s-outdated/collector/crd"
+	"k8s-outdated/collector/lifecycle"
+	"k8s-outdated/collector/markdown"
+	"k8s-outdated/collector/swagger"
+	"k8s-outdated/dataset"
+	"os"
+	"strings"
+	"time"
+)
+
+//sourceOptions select where the collectors read their data from
+type sourceOptions struct {
+	swaggerPath   string
+	guidePath     string
+	guideRef      string
+	offline       bool
+	cacheDir      string
+	noCache       bool
+	refreshCache  bool
+	concurrency   int
+	timeout       time.Duration
+	quiet         bool
+	httpTimeout   time.Duration
+	retries       int
+	proxy         string
+	caBundle      string
+	tagPolicy     string
+	openAPIV3     bool
+	groupVersions []string
+	lifecyclePath string
+	lifecycleRef  string
+	crdPaths      []string
+	dataset       string
+}
+
+//collectors hold the data sources used by the commands
+type collectors struct {
+	opts     sourceOptions
+	swagger  func(ctx context.Context, k8sVer string) (map[string]*collector.OutdatedAPI, error)
+	markdown func(ctx context.Context) ([]*collector.OutdatedAPI, error)
+	// lifecycle is optional, nil skips the prerelease lifecycle data
+	lifecycle func(ctx context.Context) ([]*collector.OutdatedAPI, error)
+	// crds is optional, nil skips the custom resource versions deprecated by CustomResourceDefinitions
+	crds func(ctx context.Context) ([]*collector.OutdatedAPI, error)
+	// fields is optional, nil scans without the deprecated fields of the swagger api
+	fields func(ctx context.Context, k8sVer string) ([]*collector.DeprecatedField, error)
+	// specs is optional, nil generates a dataset without the swagger specs read release by release
+	specs func(ctx context.Context, k8sVer string) (*swagger.Specs, error)
+	// progress receives the download progress, stderr unless --quiet
+	progress io.Writer
+	// warnings receives the data the collectors skip as malformed, stderr even with --quiet
+	warnings io.Writer
+	// cancel release the --timeout deadline of the running command
+	cancel context.CancelFunc
+	// server serve the openapi v3 documents read instead of github, set by scan --cluster with --openapi-v3
+	server swagger.OpenAPIV3Server
+	// crdLister list the CustomResourceDefinitions of the cluster, set by scan --cluster unless --crds=false
+	crdLister crd.Lister
+	// collected is the last swagger collect, shared by the outdated apis and the deprecated fields
+	collected *swaggerData
+	// loaded is the --dataset read by the running command
+	loaded *dataset.Dataset
+}
+
+//swaggerData outdated apis and deprecated fields collected from the swagger api of a k8s version
+type swaggerData struct {
+	k8sVer string
+	apis   map[string]*collector.OutdatedAPI
+	fields []*collector.DeprecatedField
+	// specs are the documents read
+	specs *swagger.Specs
+}
+
+func defaultCollectors() *collectors {
+	c := &collectors{}
+	c.swagger = func(ctx context.Context, k8sVer string) (map[string]*collector.OutdatedAPI, error) {
+		data, err := c.collectSwaggerData(ctx, k8sVer)
+		if err != nil {
+			return nil, err
+		}
+		return data.apis, nil
+	}
+	c.fields = func(ctx context.Context, k8sVer string) ([]*collector.DeprecatedField, error) {
+		data, err := c.collectSwaggerData(ctx, k8sVer)
+		if err != nil {
+			return nil, err
+		}
+		return data.fields, nil
+	}
+	c.specs = func(ctx context.Context, k8sVer string) (*swagger.Specs, error) {
+		data, err := c.collectSwaggerData(ctx, k8sVer)
+		if err != nil {
+			return nil, err
+		}
+		return data.specs, nil
+	}
+	c.markdown = func(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+		if len(c.opts.guidePath) > 0 {
+			return markdown.NewLocalDeprecationGuide(c.opts.guidePath).WithWarnings(c.warn).CollectOutdatedAPI(ctx)
+		}
+		client, downloads, err := c.opts.downloads()
+		if err != nil {
+			return nil, err
+		}
+		guide := markdown.NewDeprecationGuide()
+		if downloads != nil {
+			guide = markdown.NewCachedDeprecationGuide(downloads)
+		}
+		return guide.WithClient(client).WithRef(c.opts.guideRef).WithWarnings(c.warn).WithProgress(c.progress).CollectOutdatedAPI(ctx)
+	}
+	c.lifecycle = func(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+		if len(c.opts.lifecyclePath) > 0 {
+			return lifecycle.NewLocalPrereleaseLifecycle(c.opts.lifecyclePath).CollectOutdatedAPI(ctx)
+		}
+		// the lifecycle data only refines the other collectors, offline it is read from a local path or skipped
+		if c.opts.offline {
+			return nil, nil
+		}
+		client, downloads, err := c.opts.downloads()
+		if err != nil {
+			return nil, err
+		}
+		generated := lifecycle.NewPrereleaseLifecycle()
+		if downloads != nil {
+			generated = lifecycle.NewCachedPrereleaseLifecycle(downloads)
+		}
+		return generated.WithClient(client).WithRef(c.opts.lifecycleRef).WithProgress(c.progress).CollectOutdatedAPI(ctx)
+	}
+	c.crds = func(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+		apis := make([]*collector.OutdatedAPI, 0)
+		if len(c.opts.crdPaths) > 0 {
+			local, err := crd.NewLocalCustomResources(c.opts.crdPaths...).CollectOutdatedAPI(ctx)
+			if err != nil {
+				return nil, err
+			}
+			apis = append(apis, local...)
+		}
+		if c.crdLister != nil {
+			served, err := crd.NewClusterCustomResources(c.crdLister).CollectOutdatedAPI(ctx)
+			if err != nil {
+				return nil, err
+			}
+			apis = append(apis, served...)
+		}
+		return apis, nil
+	}
+	return c
+}
+
+//swaggerSpec build the swagger collector of the source options
+func (c *collectors) swaggerSpec() (*swagger.OpenAPISpec, error) {
+	if c.server != nil {
+		return swagger.NewServerOpenAPISpec(c.server).WithOpenAPIV3(c.opts.groupVersions...).WithConcurrency(c.opts.concurrency).
+			WithProgress(c.progress).WithWarnings(c.warn), nil
+	}
+	if len(c.opts.swaggerPath) > 0 {
+		return c.opts.openAPIV3Spec(swagger.NewLocalOpenAPISpec(c.opts.swaggerPath)).WithTagPolicy(swagger.TagPolicy(c.opts.tagPolicy)).
+			WithWarnings(c.warn), nil
+	}
+	client, downloads, err := c.opts.downloads()
+	if err != nil {
+		return nil, err
+	}
+	spec := swagger.NewOpenAPISpec()
+	if downloads != nil {
+		spec = swagger.NewCachedOpenAPISpec(downloads)
+	}
+	return c.opts.openAPIV3Spec(spec).WithClient(client).WithTagPolicy(swagger.TagPolicy(c.opts.tagPolicy)).WithConcurrency(c.opts.concurrency).
+		WithProgress(c.progress).WithWarnings(c.warn), nil
+}
+
+//warn report a malformed collector value skipped instead of turned into a row
+func (c *collectors) warn(w collector.Warning) {
+	if c.warnings != nil {
+		fmt.Fprintf(c.warnings, "warning: %s\n", w)
+	}
+}
+
+//collectSwaggerData collect the swagger api of k8sVer once per command, the outdated apis and the deprecated fields
+//are read from the same documents
+func (c *collectors) collectSwaggerData(ctx context.Context, k8sVer string) (*swaggerData, error) {
+	if c.collected != nil && c.collected.k8sVer == k8sVer {
+		return c.collected, nil
+	}
+	spec, err := c.swaggerSpec()
+	if err != nil {
+		return nil, err
+	}
+	specs, err := spec.CollectSpecs(ctx, k8sVer)
+	if err != nil {
+		return nil, err
+	}
+	c.collected = &swaggerData{k8sVer: k8sVer, apis: specs.APIs, fields: specs.Fields, specs: specs}
+	return c.collected, nil
+}
+
+//validate check the offline mode has a local path for every collector and the cache flags do not conflict
+func (o sourceOptions) validate() error {
+	if o.concurrency < 1 {
+		return usageErrorf("--%s must be at least 1", concurrencyFlag)
+	}
+	if _, err := swagger.ParseTagPolicy(o.tagPolicy); err != nil {
+		return &UsageError{Err: err}
+	}
+	if o.retries < 0 {
+		return usageErrorf("--%s must not be negative", retriesFlag)
+	}
+	if len(o.groupVersions) > 0 && !o.openAPIV3 {
+		return usageErrorf("--%s requires --%s", groupVersionsFlag, openAPIV3Flag)
+	}
+	if o.noCache && o.refreshCache {
+		return usageErrorf("--%s and --%s cannot be used together", noCacheFlag, refreshFlag)
+	}
+	if len(o.dataset) > 0 && (len(o.swaggerPath) > 0 || len(o.guidePath) > 0 || len(o.lifecyclePath) > 0) {
+		return usageErrorf("--%s cannot be used with --%s, --%s or --%s", datasetFlag, swaggerPathFlag, guidePathFlag, lifecyclePathFlag)
+	}
+	if !o.offline || len(o.datasetName()) > 0 {
+		return nil
+	}
+	if len(o.swaggerPath) == 0 || len(o.guidePath) == 0 {
+		return usageErrorf("--%s requires --%s and --%s", offlineFlag, swaggerPathFlag, guidePathFlag)
+	}
+	return nil
+}
+
+//datasetName return the dataset the apis are read from instead of running the collectors, the --dataset or, offline
+//without any local source, the embedded dataset. Empty when the collectors run
+func (o sourceOptions) datasetName() string {
+	if len(o.dataset) == 0 && o.offline && len(o.swaggerPath) == 0 && len(o.guidePath) == 0 && len(o.lifecyclePath) == 0 {
+		return embeddedDataset
+	}
+	return o.dataset
+}
+
+//validateCollectors check the collectors run for a command comparing or writing their results, which cannot read a
+//dataset for the reason given
+func (o sourceOptions) validateCollectors(reason string) error {
+	if len(o.dataset) > 0 {
+		return usageErrorf("%s, it cannot read --%s", reason, datasetFlag)
+	}
+	if len(o.datasetName()) > 0 {
+		return usageErrorf("%s, --%s requires --%s and --%s", reason, offlineFlag, swaggerPathFlag, guidePathFlag)
+	}
+	return nil
+}
+
+//swaggerSource return the source of the swagger apis, the openapi v3 documents with --openapi-v3
+func (o sourceOptions) swaggerSource() string {
+	if o.openAPIV3 {
+		return collector.SourceOpenAPIV3
+	}
+	return collector.SourceSwagger
+}
+
+//openAPIV3Spec return spec reading the openapi v3 documents of the --group-versions with --openapi-v3, spec otherwise
+func (o sourceOptions) openAPIV3Spec(spec *swagger.OpenAPISpec) *swagger.OpenAPISpec {
+	if !o.openAPIV3 {
+		return spec
+	}
+	return spec.WithOpenAPIV3(o.groupVersions...)
+}
+
+//start apply the --timeout deadline to the command context and direct the download progress and the warnings to stderr
+func (c *collectors) start(cmd *cobra.Command) {
+	c.progress = nil
+	c.warnings = cmd.ErrOrStderr()
+	c.server = nil
+	c.crdLister = nil
+	c.collected = nil
+	c.loaded = nil
+	if !c.opts.quiet {
+		c.progress = cmd.ErrOrStderr()
+	}
+	if c.opts.timeout > 0 {
+		ctx, cancel := context.WithTimeout(cmd.Context(), c.opts.timeout)
+		c.cancel = cancel
+		cmd.SetContext(ctx)
+	}
+}
+
+//stop release the resources of the command context
+func (c *collectors) stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+//downloads return the http client and the download cache using it, the cache is nil with --no-cache
+func (o sourceOptions) downloads() (*collector.Client, *cache.Cache, error) {
+	client, err := collector.NewClient(collector.ClientOptions{
+		Timeout:  o.httpTimeout,
+		Retries:  o.retries,
+		Token:    os.Getenv(collector.TokenEnv),
+		Proxy:    o.proxy,
+		CABundle: o.caBundle,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	downloads, err := o.cache()
+	if err != nil || downloads == nil {
+		return client, nil, err
+	}
+	return client, downloads.WithClient(client), nil
+}
+
+//cache return the download cache, nil with --no-cache
+func (o sourceOptions) cache() (*cache.Cache, error) {
+	if o.noCache {
+		return nil, nil
+	}
+	dir := o.cacheDir
+	if len(dir) == 0 {
+		var err error
+		if dir, err = cache.DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	return cache.NewCache(dir, o.refreshCache), nil
+}
+
+//collectSwagger parse deprecate and removed versions from k8s swagger api
+func (c *collectors) collectSwagger(ctx context.Context, k8sVer string) (map[string]*collector.OutdatedAPI, error) {
+	return c.swagger(ctx, k8sVer)
+}
+
+//collectFields parse the fields marked deprecated in the k8s swagger api, nil when they are skipped
+func (c *collectors) collectFields(ctx context.Context, k8sVer string) ([]*collector.DeprecatedField, error) {
+	if len(c.opts.datasetName()) > 0 {
+		d, err := c.loadDataset(k8sVer)
+		if err != nil {
+			return nil, err
+		}
+		return d.DeprecatedFields(k8sVer)
+	}
+	if c.fields == nil {
+		return nil, nil
+	}
+	return c.fields(ctx, k8sVer)
+}
+
+//collectSpecs return the swagger specs of k8sVer onward, nil when they are skipped
+func (c *collectors) collectSpecs(ctx context.Context, k8sVer string) (*swagger.Specs, error) {
+	if c.specs == nil {
+		return nil, nil
+	}
+	return c.specs(ctx, k8sVer)
+}
+
+//collectMarkdown parse removed version from k8s deprecation mark down docs
+func (c *collectors) collectMarkdown(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+	return c.markdown(ctx)
+}
+
+//collectLifecycle parse the api lifecycle from the k8s.io/api prerelease lifecycle generated code, nil when it is skipped
+func (c *collectors) collectLifecycle(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+	if c.lifecycle == nil {
+		return nil, nil
+	}
+	return c.lifecycle(ctx)
+}
+
+//collectCRDs parse the custom resource versions deprecated or unserved by CustomResourceDefinitions, nil when they are skipped
+func (c *collectors) collectCRDs(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+	if c.crds == nil {
+		return nil, nil
+	}
+	return c.crds(ctx)
+}
+
+//coreRegistry return the collectors of the k8s apis, the prerelease lifecycle is registered unless it is skipped
+func (c *collectors) coreRegistry() *collector.Registry {
+	r := collector.NewRegistry(
+		collector.NewCollector(c.opts.swaggerSource(), collector.PrioritySwagger,
+			func(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+				apis, err := c.collectSwagger(ctx, opts.K8sVersion)
+				if err != nil {
+					return nil, err
+				}
+				return collector.SortedAPIs(apis), nil
+			}),
+		collector.NewCollector(collector.SourceMarkdown, collector.PriorityMarkdown,
+			func(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+				return c.collectMarkdown(ctx)
+			}))
+	if c.lifecycle != nil {
+		r = r.WithCollector(collector.NewCollector(collector.SourceLifecycle, collector.PriorityLifecycle,
+			func(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+				return c.collectLifecycle(ctx)
+			}))
+	}
+	return r
+}
+
+//collectMerged run the registered collectors and merge their results by priority, a dataset replaces the collectors of
+//the k8s apis, see datasetName
+func (c *collectors) collectMerged(ctx context.Context, k8sVer string) ([]*collector.OutdatedAPI, error) {
+	r := c.coreRegistry()
+	if len(c.opts.datasetName()) > 0 {
+		d, err := c.loadDataset(k8sVer)
+		if err != nil {
+			return nil, err
+		}
+		r = collector.NewRegistry(d)
+	}
+	if c.crds != nil {
+		r = r.WithCollector(collector.NewCollector(collector.SourceCRD, collector.PriorityCRD,
+			func(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+				return c.collectCRDs(ctx)
+			}))
+	}
+	return r.Collect(ctx, collector.Options{K8sVersion: k8sVer})
+}
+
+//loadDataset read the dataset of datasetName once per command, a dataset without apis is an error, one missing a core
+//source or generated from a later release than k8sVer is reported as a warning
+func (c *collectors) loadDataset(k8sVer string) (*dataset.Dataset, error) {
+	if c.loaded == nil {
+		name := c.opts.datasetName()
+		d, err := readDataset(name)
+		if err != nil {
+			return nil, err
+		}
+		if d.IsEmpty() {
+			return nil, fmt.Errorf("dataset %s has no apis, write one with k8s-outdated dataset generate", name)
+		}
+		c.loaded = d
+		if missing := d.Missing(); len(missing) > 0 && c.warnings != nil {
+			fmt.Fprintf(c.warnings, "warning: dataset %s is incomplete, it has no %s, regenerate it with k8s-outdated dataset generate\n",
+				name, strings.Join(missing, ", "))
+		}
+		if v, err := collector.ParseKubeVersion(k8sVer); err == nil && len(d.K8sVersions) > 0 && !d.Covers(v) && c.warnings != nil {
+			fmt.Fprintf(c.warnings, "warning: dataset %s does not cover %s, the apis removed before %s may be missing\n",
+				name, v, d.K8sVersions[0])
+		}
+	}
+	return c.loaded, nil
+}
+
+//readDataset read the dataset of a --dataset value
+func readDataset(name string) (*dataset.Dataset, error) {
+	if len(name) == 0 || name == embeddedDataset {
+		return dataset.Embedded()
+	}
+	return dataset.ReadFile(name)
+}
+
+//printTable print a slice of header tagged rows in a table
+func printTable(w io.Writer, rows interface{}) {
+	tableprinter.Print(w, rows)
+}
@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"k8s-outdated/collector"
+	"k8s-outdated/collector/swagger"
+	"k8s-outdated/dataset"
+	"k8s-outdated/output"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	commitFlag = "commit"
+
+	//sourceDateEpochEnv reproducible builds variable, the generation time of the dataset when set
+	sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+	kubernetesRepository = "https://github.com/kubernetes/kubernetes"
+	websiteRepository    = "https://github.com/kubernetes/website"
+	apiRepository        = "https://github.com/kubernetes/api"
+)
+
+//commitRe match a full commit hash
+var commitRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+type datasetGenerateOptions struct {
+	k8sVersion string
+	commits    []string
+}
+
+//datasetSourceRow table row of a dataset source
+type datasetSourceRow struct {
+	Name     string `header:"source"`
+	Location string `header:"location"`
+	Ref      string `header:"ref"`
+	Commit   string `header:"commit"`
+	APIs     int    `header:"apis"`
+}
+
+func newDatasetCommand(c *collectors) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dataset",
+		Short: "Generate or inspect the offline dataset of outdated APIs",
+		Long: "A dataset is the merged result of every collector written to a json file. A dataset is built into the\n" +
+			"binary, --" + datasetFlag + " " + embeddedDataset + " reads it and --" + datasetFlag + " <file> reads a newer one instead of running the collectors.",
+		Args: usageArgs(cobra.NoArgs),
+	}
+	cmd.AddCommand(newDatasetGenerateCommand(c), newDatasetInfoCommand(c))
+	return cmd
+}
+
+func newDatasetGenerateCommand(c *collectors) *cobra.Command {
+	opts := &datasetGenerateOptions{}
+	cmd := &cobra.Command{
+		Use:   "generate [file]",
+		Short: "Run every collector and write the dataset to a file, stdout when no file is given",
+		Long: "Run the swagger api, deprecation guide and prerelease lifecycle collectors and write the apis of each, merged\n" +
+			"when the dataset is read. The dataset records the release tags the swagger specs were read from and the commit\n" +
+			"of the --" + guideRefFlag + " and --" + lifecycleRefFlag + " when they are commits. A dataset missing the apis of one\n" +
+			"of the collectors is not written. The file is canonical: the same collector results always give the same bytes,\n" +
+			"and $" + sourceDateEpochEnv + " sets the generation time.",
+		Example: "  k8s-outdated dataset generate dataset/dataset.json -k v1.16.0\n" +
+			"  k8s-outdated dataset generate dataset.json -k v1.16.0 --" + guideRefFlag + " $(git ls-remote https://github.com/kubernetes/website main | cut -f1)\n" +
+			"  k8s-outdated dataset generate dataset.json -k v1.16.0 --commit markdown=$(git -C website rev-parse HEAD)",
+		Args: usageArgs(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDatasetGenerate(cmd, c, opts, args)
+		},
+	}
+	addK8sVersionFlag(cmd, &opts.k8sVersion)
+	cmd.Flags().StringArrayVar(&opts.commits, commitFlag, nil, "commit a source was read at, e.g. markdown=3f2a1c9 (repeatable)")
+	return cmd
+}
+
+func runDatasetGenerate(cmd *cobra.Command, c *collectors, opts *datasetGenerateOptions, args []string) error {
+	if err := validateK8sVersion(opts.k8sVersion); err != nil {
+		return err
+	}
+	if err := c.opts.validateCollectors("dataset generate runs the collectors"); err != nil {
+		return err
+	}
+	sources, err := c.datasetSources(opts.commits)
+	if err != nil {
+		return err
+	}
+	generated, err := generationTime()
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	collected := make([]collector.Collected, 0)
+	for _, source := range c.coreRegistry().Collectors() {
+		apis, err := source.Collect(ctx, collector.Options{K8sVersion: opts.k8sVersion})
+		if err != nil {
+			return err
+		}
+		collected = append(collected, collector.Collected{Name: source.Name(), Priority: source.Priority(), APIs: apis})
+	}
+	specs, err := c.collectSpecs(ctx, opts.k8sVersion)
+	if err != nil {
+		return err
+	}
+	var releases []swagger.ReleaseSpecs
+	if specs != nil {
+		if releases, err = specs.ByRelease(); err != nil {
+			return err
+		}
+		for i := range sources {
+			if sources[i].Name == c.opts.swaggerSource() {
+				sources[i].Tags = specs.Tags
+			}
+		}
+	}
+	d := dataset.New(generated, sources, collected, releases)
+	if missing := d.Missing(); len(missing) > 0 {
+		return fmt.Errorf("the dataset has no %s, it is not written", strings.Join(missing, ", "))
+	}
+	if len(args) == 0 {
+		return d.Encode(cmd.OutOrStdout())
+	}
+	if err := writeDataset(args[0], d); err != nil {
+		return err
+	}
+	if c.progress != nil {
+		apis, fields, err := datasetCounts(d)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.progress, "wrote %d apis and %d deprecated fields to %s\n", apis, fields, args[0])
+	}
+	return nil
+}
+
+//datasetCounts return the number of merged apis and of deprecated fields of a dataset read from every release
+func datasetCounts(d *dataset.Dataset) (int, int, error) {
+	apis, err := d.OutdatedAPIs("")
+	if err != nil {
+		return 0, 0, err
+	}
+	fields, err := d.DeprecatedFields("")
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(apis), len(fields), nil
+}
+
+//datasetSources return the collectors a generated dataset is read from, with the commits given as name=commit
+func (c *collectors) datasetSources(commits []string) ([]dataset.Source, error) {
+	swaggerSource := dataset.Source{Name: c.opts.swaggerSource(), Location: kubernetesRepository}
+	if len(c.opts.swaggerPath) > 0 {
+		swaggerSource.Location = c.opts.swaggerPath
+	}
+	guideSource := dataset.Source{Name: collector.SourceMarkdown, Location: websiteRepository, Ref: c.opts.guideRef}
+	if len(c.opts.guidePath) > 0 {
+		guideSource = dataset.Source{Name: collector.SourceMarkdown, Location: c.opts.guidePath}
+	}
+	sources := []dataset.Source{swaggerSource, guideSource}
+	switch {
+	case len(c.opts.lifecyclePath) > 0:
+		sources = append(sources, dataset.Source{Name: collector.SourceLifecycle, Location: c.opts.lifecyclePath})
+	case !c.opts.offline:
+		sources = append(sources, dataset.Source{Name: collector.SourceLifecycle, Location: apiRepository, Ref: c.opts.lifecycleRef})
+	}
+	// a source downloaded at a commit records it
+	for i := range sources {
+		if commitRe.MatchString(sources[i].Ref) {
+			sources[i].Commit = sources[i].Ref
+		}
+	}
+	for _, commit := range commits {
+		name, sha, ok := strings.Cut(commit, "=")
+		if !ok || len(sha) == 0 {
+			return nil, usageErrorf("invalid --%s %q, expected source=commit", commitFlag, commit)
+		}
+		found := false
+		for i := range sources {
+			if sources[i].Name == name {
+				sources[i].Commit = sha
+				found = true
+			}
+		}
+		if !found {
+			return nil, usageErrorf("invalid --%s %q, unknown source %q", commitFlag, commit, name)
+		}
+	}
+	return sources, nil
+}
+
+//generationTime return the time of $SOURCE_DATE_EPOCH, now when it is unset
+func generationTime() (time.Time, error) {
+	epoch := os.Getenv(sourceDateEpochEnv)
+	if len(epoch) == 0 {
+		return time.Now(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid $%s %q: %w", sourceDateEpochEnv, epoch, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+//writeDataset write the dataset to a temporary file renamed over path, a failed generation keeps the previous file
+func writeDataset(path string, d *dataset.Dataset) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := d.Encode(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func newDatasetInfoCommand(c *collectors) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:     "info",
+		Short:   "Print the generation date, sources and k8s versions of the --" + datasetFlag + ", the embedded dataset by default",
+		Example: "  k8s-outdated dataset info\n  k8s-outdated dataset info --" + datasetFlag + " dataset.json -o json",
+		Args:    usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := c.opts.dataset
+			if len(name) == 0 {
+				name = embeddedDataset
+			}
+			d, err := readDataset(name)
+			if err != nil {
+				return err
+			}
+			return printDatasetInfo(cmd.OutOrStdout(), name, d, format)
+		},
+	}
+	cmd.Flags().StringVarP(&format, outputFlag, "o", string(output.Table), "output format, one of: table, json")
+	return cmd
+}
+
+func printDatasetInfo(w io.Writer, name string, d *dataset.Dataset, format string) error {
+	apis, fields, err := datasetCounts(d)
+	if err != nil {
+		return err
+	}
+	sources := make([]dataset.Source, 0, len(d.Sources))
+	for _, s := range d.Sources {
+		s.APIs = nil
+		sources = append(sources, s)
+	}
+	switch output.Format(format) {
+	case output.JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Dataset       string                  `json:"dataset"`
+			SchemaVersion int                     `json:"schemaVersion"`
+			Generated     time.Time               `json:"generated"`
+			K8sVersions   []collector.KubeVersion `json:"k8sVersions"`
+			Sources       []dataset.Source        `json:"sources"`
+			APIs          int                     `json:"apis"`
+			Fields        int                     `json:"fields"`
+		}{Dataset: name, SchemaVersion: d.SchemaVersion, Generated: d.Generated, K8sVersions: d.K8sVersions, Sources: sources,
+			APIs: apis, Fields: fields})
+	case output.Table:
+		generated := "unknown"
+		if !d.Generated.IsZero() {
+			generated = d.Generated.Format(time.RFC3339)
+		}
+		versions := "none"
+		if n := len(d.K8sVersions); n > 0 {
+			versions = fmt.Sprintf("%s to %s (%d releases)", d.K8sVersions[0], d.K8sVersions[n-1], n)
+		}
+		fmt.Fprintf(w, "dataset: %s\nschema version: %d\ngenerated: %s\nk8s versions: %s\napis: %d\ndeprecated fields: %d\n",
+			name, d.SchemaVersion, generated, versions, apis, fields)
+		rows := make([]datasetSourceRow, 0, len(d.Sources))
+		for _, s := range d.Sources {
+			ref := s.Ref
+			if n := len(s.Tags); len(ref) == 0 && n > 0 {
+				// the swagger specs are read from one tag per minor release
+				ref = fmt.Sprintf("%s to %s (%d tags)", s.Tags[0], s.Tags[n-1], n)
+			}
+			rows = append(rows, datasetSourceRow{Name: s.Name, Location: s.Location, Ref: ref, Commit: s.Commit, APIs: len(s.APIs)})
+		}
+		if len(rows) > 0 {
+			printTable(w, rows)
+		}
+		return nil
+	}
+	return usageErrorf("unsupported dataset output format %q, supported formats: table, json", format)
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"k8s-outdated/collector"
+	"sort"
+)
+
+//sourceDiff row describing an api which the swagger and markdown collectors report differently
+type sourceDiff struct {
+	API               string `header:"k8s api"`
+	SwaggerDeprecated string `header:"swagger deprecated"`
+	SwaggerRemoved    string `header:"swagger removed"`
+	MarkdownRemoved   string `header:"markdown removed"`
+	Status            string `header:"status"`
+}
+
+//replacementDiff row describing an api which the swagger and markdown collectors name different replacements for
+type replacementDiff struct {
+	API                 string `header:"k8s api"`
+	SwaggerReplacement  string `header:"swagger replacement"`
+	MarkdownReplacement string `header:"markdown replacement"`
+}
+
+const (
+	swaggerOnly     = "swagger only"
+	markdownOnly    = "markdown only"
+	removedMismatch = "removed version mismatch"
+)
+
+func newDiffCommand(c *collectors) *cobra.Command {
+	var k8sVersion string
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   "Show APIs which the swagger api and the deprecation guide report differently",
+		Example: "  k8s-outdated diff --k8s-version v1.20.0",
+		Args:    usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateK8sVersion(k8sVersion); err != nil {
+				return err
+			}
+			if err := c.opts.validateCollectors("diff compares the collectors"); err != nil {
+				return err
+			}
+			mDetails, err := c.collectSwagger(cmd.Context(), k8sVersion)
+			if err != nil {
+				return err
+			}
+			objs, err := c.collectMarkdown(cmd.Context())
+			if err != nil {
+				return err
+			}
+			printTable(cmd.OutOrStdout(), diffSources(objs, mDetails))
+			if replacements := diffReplacements(objs, mDetails); len(replacements) > 0 {
+				printTable(cmd.OutOrStdout(), replacements)
+			}
+			return nil
+		},
+	}
+	addK8sVersionFlag(cmd, &k8sVersion)
+	return cmd
+}
+
+func diffSources(objs []*collector.OutdatedAPI, mDetails map[string]*collector.OutdatedAPI) []sourceDiff {
+	swaggerByGvk := make(map[string]*collector.OutdatedAPI)
+	for _, sw := range mDetails {
+		swaggerByGvk[gvkName(sw.Gav)] = sw
+	}
+	markdownByGvk := make(map[string]*collector.OutdatedAPI)
+	for _, md := range objs {
+		markdownByGvk[gvkName(md.Gav)] = md
+	}
+	diffs := make([]sourceDiff, 0)
+	for name, md := range markdownByGvk {
+		sw, ok := swaggerByGvk[name]
+		if !ok {
+			diffs = append(diffs, sourceDiff{API: name, MarkdownRemoved: md.Removed.String(), Status: markdownOnly})
+			continue
+		}
+		if !sw.Removed.IsZero() && sw.Removed != md.Removed {
+			diffs = append(diffs, sourceDiff{API: name, SwaggerDeprecated: sw.Deprecated.String(), SwaggerRemoved: sw.Removed.String(), MarkdownRemoved: md.Removed.String(), Status: removedMismatch})
+		}
+	}
+	for name, sw := range swaggerByGvk {
+		if _, ok := markdownByGvk[name]; !ok {
+			diffs = append(diffs, sourceDiff{API: name, SwaggerDeprecated: sw.Deprecated.String(), SwaggerRemoved: sw.Removed.String(), Status: swaggerOnly})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].API < diffs[j].API
+	})
+	return diffs
+}
+
+func diffReplacements(objs []*collector.OutdatedAPI, mDetails map[string]*collector.OutdatedAPI) []replacementDiff {
+	swaggerByGvk := make(map[collector.Gvk]*collector.OutdatedAPI)
+	for _, sw := range mDetails {
+		swaggerByGvk[sw.Gav] = sw
+	}
+	diffs := make([]replacementDiff, 0)
+	for _, md := range objs {
+		sw, ok := swaggerByGvk[md.Gav]
+		if !ok || sw.Replacement.IsZero() || md.Replacement.IsZero() || sw.Replacement == md.Replacement {
+			continue
+		}
+		diffs = append(diffs, replacementDiff{API: gvkName(md.Gav), SwaggerReplacement: sw.Replacement.String(), MarkdownReplacement: md.Replacement.String()})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].API < diffs[j].API
+	})
+	return diffs
+}
+
+func gvkName(gvk collector.Gvk) string {
+	return fmt.Sprintf("%s.%s.%s", gvk.Group, gvk.Version, gvk.Kind)
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"k8s-outdated/collector"
+	"sort"
+)
+
+func newExplainCommand(c *collectors) *cobra.Command {
+	var k8sVersion string
+	cmd := &cobra.Command{
+		Use:     "explain <apiVersion> <kind>",
+		Short:   "Explain the deprecation and removal details of a single k8s API",
+		Example: "  k8s-outdated explain batch/v1beta1 CronJob --k8s-version v1.20.0",
+		Args:    usageArgs(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateK8sVersion(k8sVersion); err != nil {
+				return err
+			}
+			if err := c.opts.validateCollectors("explain shows the value of every collector"); err != nil {
+				return err
+			}
+			gvk, err := parseAPIVersionKind(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			mDetails, err := c.collectSwagger(cmd.Context(), k8sVersion)
+			if err != nil {
+				return err
+			}
+			objs, err := c.collectMarkdown(cmd.Context())
+			if err != nil {
+				return err
+			}
+			generated, err := c.collectLifecycle(cmd.Context())
+			if err != nil {
+				return err
+			}
+			crds, err := c.collectCRDs(cmd.Context())
+			if err != nil {
+				return err
+			}
+			found := explainAPI(cmd.OutOrStdout(), gvk, objs, mDetails, generated, crds)
+			if !found {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s is not deprecated or removed from k8s %s onward\n", gvkName(gvk), k8sVersion)
+			}
+			return nil
+		},
+	}
+	addK8sVersionFlag(cmd, &k8sVersion)
+	return cmd
+}
+
+//parseAPIVersionKind parse manifest style apiVersion and kind command arguments
+func parseAPIVersionKind(apiVersion string, kind string) (collector.Gvk, error) {
+	gvk, err := collector.ParseGvk(apiVersion, kind)
+	if err != nil {
+		return collector.Gvk{}, &UsageError{Err: err}
+	}
+	return gvk, nil
+}
+
+//explainAPI print the value of every collector for gvk, the swagger definitions in name order
+func explainAPI(w io.Writer, gvk collector.Gvk, objs []*collector.OutdatedAPI, mDetails map[string]*collector.OutdatedAPI, generated []*collector.OutdatedAPI, crds []*collector.OutdatedAPI) bool {
+	found := false
+	names := make([]string, 0, len(mDetails))
+	for name := range mDetails {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if sw := mDetails[name]; sw.Gav == gvk {
+			printExplain(w, "swagger api", sw)
+			found = true
+		}
+	}
+	for _, md := range objs {
+		if md.Gav == gvk {
+			printExplain(w, "deprecation guide", md)
+			found = true
+		}
+	}
+	for _, lc := range generated {
+		if lc.Gav == gvk {
+			printExplain(w, "prerelease lifecycle", lc)
+			found = true
+		}
+	}
+	for _, cr := range crds {
+		if cr.Gav == gvk {
+			printExplain(w, "custom resource definition", cr)
+			found = true
+		}
+	}
+	return found
+}
+
+func printExplain(w io.Writer, source string, api *collector.OutdatedAPI) {
+	fmt.Fprintf(w, "API:         %s\n", gvkName(api.Gav))
+	fmt.Fprintf(w, "Source:      %s\n", source)
+	if !api.Introduced.IsZero() {
+		fmt.Fprintf(w, "Introduced:  %s\n", api.Introduced)
+	}
+	fmt.Fprintf(w, "Deprecated:  %s\n", api.Deprecated)
+	fmt.Fprintf(w, "Removed:     %s\n", api.Removed)
+	fmt.Fprintf(w, "Replacement: %s\n", api.Replacement)
+	fmt.Fprintf(w, "Description: %s\n", api.Description)
+	if m := api.Migration; m != nil {
+		fmt.Fprintf(w, "Guide:       %s\n", m.URL)
+		if len(m.NotableChanges) > 0 {
+			fmt.Fprintln(w, "Notable changes:")
+		}
+		for _, change := range m.NotableChanges {
+			fmt.Fprintf(w, "  - %s\n", change)
+		}
+	}
+	fmt.Fprintln(w)
+}
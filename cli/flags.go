@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"k8s-outdated/cache"
+	"k8s-outdated/collector"
+	"k8s-outdated/collector/lifecycle"
+	"k8s-outdated/collector/markdown"
+	"k8s-outdated/collector/swagger"
+	"k8s-outdated/output"
+	"os"
+	"strings"
+)
+
+const (
+	k8sVersionFlag    = "k8s-version"
+	targetVersionFlag = "target-version"
+	outputFlag        = "output"
+	swaggerPathFlag   = "swagger-path"
+	guidePathFlag     = "deprecation-guide-path"
+	guideRefFlag      = "deprecation-guide-ref"
+	offlineFlag       = "offline"
+	cacheDirFlag      = "cache-dir"
+	noCacheFlag       = "no-cache"
+	refreshFlag       = "refresh-cache"
+	concurrencyFlag   = "concurrency"
+	timeoutFlag       = "timeout"
+	quietFlag         = "quiet"
+	httpTimeoutFlag   = "http-timeout"
+	retriesFlag       = "retries"
+	proxyFlag         = "proxy"
+	caBundleFlag      = "ca-bundle"
+	tagPolicyFlag     = "tag-policy"
+	openAPIV3Flag     = "openapi-v3"
+	groupVersionsFlag = "group-versions"
+	lifecyclePathFlag = "lifecycle-path"
+	lifecycleRefFlag  = "lifecycle-ref"
+	metadataPathFlag  = "metadata-path"
+	crdPathFlag       = "crd-path"
+	datasetFlag       = "dataset"
+
+	swaggerPathEnv   = "K8S_OUTDATED_SWAGGER_PATH"
+	guidePathEnv     = "K8S_OUTDATED_DEPRECATION_GUIDE_PATH"
+	lifecyclePathEnv = "K8S_OUTDATED_LIFECYCLE_PATH"
+	metadataPathEnv  = "K8S_OUTDATED_METADATA_PATH"
+	datasetEnv       = "K8S_OUTDATED_DATASET"
+
+	//embeddedDataset --dataset value of the dataset built into the binary
+	embeddedDataset = "embedded"
+)
+
+func addSourceFlags(cmd *cobra.Command, opts *sourceOptions) {
+	cmd.PersistentFlags().StringVar(&opts.swaggerPath, swaggerPathFlag, os.Getenv(swaggerPathEnv),
+		"read swagger specs from a swagger.json file, a directory of swagger-vX.Y.Z.json files or a kubernetes/kubernetes checkout")
+	cmd.PersistentFlags().StringVar(&opts.guidePath, guidePathFlag, os.Getenv(guidePathEnv),
+		"read the deprecation guide from a deprecation-guide.md file or a kubernetes/website checkout")
+	cmd.PersistentFlags().StringVar(&opts.guideRef, guideRefFlag, markdown.DefaultRef,
+		"kubernetes/website branch, tag or commit the deprecation guide is downloaded from")
+	cmd.PersistentFlags().StringVar(&opts.lifecyclePath, lifecyclePathFlag, os.Getenv(lifecyclePathEnv),
+		"read the prerelease lifecycle generated code from a k8s.io/api checkout, a kubernetes/kubernetes checkout or a k8s.io/api .tar.gz archive")
+	cmd.PersistentFlags().StringVar(&opts.lifecycleRef, lifecycleRefFlag, lifecycle.DefaultRef,
+		"k8s.io/api branch or tag, e.g. v0.28.4, the prerelease lifecycle generated code is downloaded from")
+	cmd.PersistentFlags().StringArrayVar(&opts.crdPaths, crdPathFlag, nil,
+		"CustomResourceDefinition manifest file or directory whose deprecated and unserved versions are outdated apis (repeatable)")
+	cmd.PersistentFlags().StringVar(&opts.dataset, datasetFlag, os.Getenv(datasetEnv),
+		"read the apis from a dataset file written by dataset generate instead of running the collectors, "+embeddedDataset+
+			" for the dataset built into the binary")
+	cmd.PersistentFlags().BoolVar(&opts.offline, offlineFlag, false,
+		"never access the network, reads the embedded dataset unless --"+datasetFlag+" or --"+swaggerPathFlag+" and --"+guidePathFlag+" are set")
+	cmd.PersistentFlags().StringVar(&opts.cacheDir, cacheDirFlag, "",
+		"directory of the download cache (default $"+cache.DirEnv+" or k8s-outdated under the XDG cache directory)")
+	cmd.PersistentFlags().BoolVar(&opts.noCache, noCacheFlag, false, "download everything without reading or writing the cache")
+	cmd.PersistentFlags().BoolVar(&opts.refreshCache, refreshFlag, false, "download every cached entry again, including released swagger specs")
+	cmd.PersistentFlags().IntVar(&opts.concurrency, concurrencyFlag, swagger.DefaultConcurrency, "number of swagger specs downloaded at once")
+	cmd.PersistentFlags().DurationVar(&opts.timeout, timeoutFlag, 0, "abort the command after this duration, e.g. 2m (default no timeout)")
+	cmd.PersistentFlags().BoolVarP(&opts.quiet, quietFlag, "q", false, "do not report download progress on stderr")
+	cmd.PersistentFlags().DurationVar(&opts.httpTimeout, httpTimeoutFlag, collector.DefaultTimeout, "timeout of a single download attempt")
+	cmd.PersistentFlags().IntVar(&opts.retries, retriesFlag, collector.DefaultRetries,
+		"retries of downloads failing with a network error, 5xx or rate limit answer")
+	cmd.PersistentFlags().StringVar(&opts.proxy, proxyFlag, "", "proxy url for downloads (default $HTTPS_PROXY, $HTTP_PROXY and $NO_PROXY)")
+	cmd.PersistentFlags().StringVar(&opts.tagPolicy, tagPolicyFlag, string(swagger.LatestPatch),
+		"release tags of every minor version to read swagger specs from, one of: "+strings.Join(swagger.TagPolicies(), ", "))
+	cmd.PersistentFlags().BoolVar(&opts.openAPIV3, openAPIV3Flag, false,
+		"read the per group version openapi v3 documents instead of swagger.json, from the cluster itself with scan --cluster")
+	cmd.PersistentFlags().StringSliceVar(&opts.groupVersions, groupVersionsFlag, nil,
+		"with --"+openAPIV3Flag+", only read the documents of these group versions, e.g. batch/v1beta1,v1")
+	cmd.PersistentFlags().StringVar(&opts.caBundle, caBundleFlag, "", "pem file of certificate authorities trusted in addition to the system ones")
+}
+
+func addK8sVersionFlag(cmd *cobra.Command, k8sVersion *string) {
+	cmd.Flags().StringVarP(k8sVersion, k8sVersionFlag, "k", "", "k8s version to search outdated APIs from, e.g. v1.20.0")
+}
+
+func validateK8sVersion(k8sVersion string) error {
+	return validateVersion(k8sVersionFlag, k8sVersion)
+}
+
+func validateVersion(flag string, v string) error {
+	if len(v) == 0 {
+		return usageErrorf("--%s is required", flag)
+	}
+	if _, err := collector.ParseKubeVersion(v); err != nil {
+		return usageErrorf("invalid --%s %q: %v", flag, v, err)
+	}
+	return nil
+}
+
+func addOutputFlag(cmd *cobra.Command, format *string) {
+	cmd.Flags().StringVarP(format, outputFlag, "o", string(output.Table), "output format, one of: "+strings.Join(output.Formats(), ", "))
+}
+
+func parseOutputFormat(format string) (output.Format, error) {
+	f, err := output.ParseFormat(format)
+	if err != nil {
+		return "", &UsageError{Err: err}
+	}
+	return f, nil
+}
+
+//usageArgs wrap a positional args validator so its failures exit with ExitUsage
+func usageArgs(fn cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := fn(cmd, args); err != nil {
+			return &UsageError{Err: err}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"k8s-outdated/collector"
+	"k8s-outdated/output"
+	"k8s-outdated/query"
+	"strings"
+)
+
+const (
+	statusFlag = "status"
+	withinFlag = "within"
+	groupFlag  = "group"
+	kindFlag   = "kind"
+	sourceFlag = "source"
+	sortFlag   = "sort"
+)
+
+type listOptions struct {
+	k8sVersion string
+	output     string
+	query      queryOptions
+}
+
+//queryOptions filter and sort flags of the listed apis, the zero value lists every api
+type queryOptions struct {
+	targetVersion string
+	statuses      []string
+	within        int
+	groups        []string
+	kinds         []string
+	sources       []string
+	sort          string
+}
+
+func newListCommand(c *collectors) *cobra.Command {
+	opts := &listOptions{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List deprecated and removed k8s APIs from the given k8s version onward",
+		Example: "  k8s-outdated list --k8s-version v1.20.0\n  k8s-outdated list -k v1.20.0 --output json\n" +
+			"  k8s-outdated list -k v1.20.0 --status removed --target-version v1.26\n" +
+			"  k8s-outdated list -k v1.20.0 --status removed-soon -t v1.24 --within 2 --sort removed",
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runList(cmd, c, opts)
+		},
+	}
+	addK8sVersionFlag(cmd, &opts.k8sVersion)
+	addOutputFlag(cmd, &opts.output)
+	addQueryFlags(cmd, &opts.query)
+	return cmd
+}
+
+func addQueryFlags(cmd *cobra.Command, opts *queryOptions) {
+	cmd.Flags().StringVarP(&opts.targetVersion, targetVersionFlag, "t", "", "k8s version --"+statusFlag+" is evaluated at (default --"+k8sVersionFlag+")")
+	cmd.Flags().StringSliceVar(&opts.statuses, statusFlag, nil,
+		"only list the apis with a status at the target version, any of: "+strings.Join(query.Statuses(), ", "))
+	cmd.Flags().IntVar(&opts.within, withinFlag, query.DefaultWithin, "minor releases after the target version "+string(query.RemovedSoon)+" looks ahead")
+	cmd.Flags().StringSliceVar(&opts.groups, groupFlag, nil, "only list the apis of these groups, "+query.CoreGroup+" for the core group")
+	cmd.Flags().StringSliceVar(&opts.kinds, kindFlag, nil, "only list the apis whose kind matches these globs, e.g. '*Ingress*'")
+	cmd.Flags().StringSliceVar(&opts.sources, sourceFlag, nil, "only list the apis reported by these sources, e.g. "+collector.SourceMarkdown)
+	cmd.Flags().StringVar(&opts.sort, sortFlag, string(query.ByGroup), "order of the apis, one of: "+strings.Join(query.SortKeys(), ", "))
+}
+
+//buildQuery parse the query flags, the statuses are evaluated at k8sVersion unless --target-version is set
+func (o queryOptions) buildQuery(k8sVersion string) (query.Query, error) {
+	target := o.targetVersion
+	if len(target) == 0 {
+		target = k8sVersion
+	}
+	if err := validateVersion(targetVersionFlag, target); err != nil {
+		return query.Query{}, err
+	}
+	q := query.Query{Target: collector.MustParseKubeVersion(target), Within: o.within, Groups: o.groups, Kinds: o.kinds, Sources: o.sources}
+	for _, name := range o.statuses {
+		status, err := query.ParseStatus(name)
+		if err != nil {
+			return query.Query{}, &UsageError{Err: err}
+		}
+		q.Statuses = append(q.Statuses, status)
+	}
+	if len(o.sort) > 0 {
+		sortKey, err := query.ParseSortKey(o.sort)
+		if err != nil {
+			return query.Query{}, &UsageError{Err: err}
+		}
+		q.SortBy = sortKey
+	}
+	if err := q.Validate(); err != nil {
+		return query.Query{}, &UsageError{Err: err}
+	}
+	return q, nil
+}
+
+func runList(cmd *cobra.Command, c *collectors, opts *listOptions) error {
+	if err := validateK8sVersion(opts.k8sVersion); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(opts.output)
+	if err != nil {
+		return err
+	}
+	q, err := opts.query.buildQuery(opts.k8sVersion)
+	if err != nil {
+		return err
+	}
+	apis, err := c.collectMerged(cmd.Context(), opts.k8sVersion)
+	if err != nil {
+		return err
+	}
+	selected, err := q.Apply(apis)
+	if err != nil {
+		return err
+	}
+	return output.WriteAPIs(cmd.OutOrStdout(), format, selected)
+}
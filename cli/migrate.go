@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"io"
+	"k8s-outdated/helm"
+	"k8s-outdated/migrate"
+	"k8s-outdated/scanner"
+	"os"
+)
+
+type migrateOptions struct {
+	k8sVersion    string
+	targetVersion string
+	inPlace       bool
+}
+
+func newMigrateCommand(c *collectors) *cobra.Command {
+	opts := &migrateOptions{}
+	cmd := &cobra.Command{
+		Use:   "migrate <file|dir|->...",
+		Short: "Rewrite k8s manifests using deprecated or removed APIs to their replacement APIs",
+		Long: "Rewrite the apiVersion of every object using an API which is deprecated or removed at the target k8s\n" +
+			"version to its replacement, following replacements which are outdated themselves. The schema changes of\n" +
+			"the big removals are applied as well: Ingress backends and pathType, HorizontalPodAutoscaler v2 metrics\n" +
+			"and CustomResourceDefinition v1 versions[].schema. Comments and key order are preserved.\n\n" +
+			"By default a unified diff is printed, --in-place writes the migrated manifests back to their files.",
+		Example: "  k8s-outdated migrate ./manifests -k v1.20.0 --target-version v1.25.0\n" +
+			"  k8s-outdated migrate ./manifests -k v1.20.0 -t v1.25.0 --in-place",
+		Args: usageArgs(cobra.MinimumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrate(cmd, c, opts, args)
+		},
+	}
+	addK8sVersionFlag(cmd, &opts.k8sVersion)
+	cmd.Flags().StringVarP(&opts.targetVersion, targetVersionFlag, "t", "",
+		"k8s version the objects are migrated for (default --k8s-version)")
+	cmd.Flags().BoolVarP(&opts.inPlace, "in-place", "w", false, "write the migrated manifests to their files instead of printing a diff")
+	return cmd
+}
+
+func runMigrate(cmd *cobra.Command, c *collectors, opts *migrateOptions, paths []string) error {
+	if err := validateK8sVersion(opts.k8sVersion); err != nil {
+		return err
+	}
+	target := opts.targetVersion
+	if len(target) == 0 {
+		target = opts.k8sVersion
+	}
+	if err := validateVersion(targetVersionFlag, target); err != nil {
+		return err
+	}
+	files, err := scanner.ManifestFiles(paths)
+	if err != nil {
+		return err
+	}
+	apis, err := c.collectMerged(cmd.Context(), opts.k8sVersion)
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewMigrator(apis, target)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file == scanner.StdinPath && opts.inPlace {
+			return usageErrorf("--in-place cannot be used with standard input")
+		}
+		if file != scanner.StdinPath && helm.IsChart(file) {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: skipping helm chart, templates are not migrated\n", file)
+			continue
+		}
+		if err := migrateFile(cmd, m, file, opts.inPlace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateFile(cmd *cobra.Command, m *migrate.Migrator, file string, inPlace bool) error {
+	data, err := readManifest(cmd.InOrStdin(), file)
+	if err != nil {
+		return err
+	}
+	migrated, changes, err := m.Migrate(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	report := cmd.ErrOrStderr()
+	if inPlace {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, migrated, info.Mode().Perm()); err != nil {
+			return err
+		}
+		report = cmd.OutOrStdout()
+	} else {
+		err := difflib.WriteUnifiedDiff(cmd.OutOrStdout(), difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(data)),
+			B:        difflib.SplitLines(string(migrated)),
+			FromFile: file,
+			ToFile:   file,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, c := range changes {
+		fmt.Fprintf(report, "%s:%d: %s %s migrated from %s to %s\n", file, c.Line, c.Kind, c.Name, c.From, c.To)
+	}
+	return nil
+}
+
+func readManifest(stdin io.Reader, file string) ([]byte, error) {
+	if file == scanner.StdinPath {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(file)
+}
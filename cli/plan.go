@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"k8s-outdated/output"
+	"k8s-outdated/plan"
+)
+
+type planOptions struct {
+	from   string
+	to     string
+	output string
+}
+
+func newPlanCommand(c *collectors) *cobra.Command {
+	opts := &planOptions{}
+	cmd := &cobra.Command{
+		Use:   "plan --from <version> --to <version>",
+		Short: "Plan an upgrade across multiple minor k8s releases",
+		Long: "Step through every minor release between --from and --to and list, for each hop, the APIs which are\n" +
+			"no longer served, the APIs which become deprecated and what replaces them.",
+		Example: "  k8s-outdated plan --from v1.21 --to v1.27\n  k8s-outdated plan --from v1.21.3 --to v1.25.0 -o yaml",
+		Args:    usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPlan(cmd, c, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.from, "from", "", "k8s version the cluster runs today, e.g. v1.21")
+	cmd.Flags().StringVar(&opts.to, "to", "", "k8s version to upgrade to, e.g. v1.27")
+	addOutputFlag(cmd, &opts.output)
+	return cmd
+}
+
+func runPlan(cmd *cobra.Command, c *collectors, opts *planOptions) error {
+	if err := validateVersion("from", opts.from); err != nil {
+		return err
+	}
+	if err := validateVersion("to", opts.to); err != nil {
+		return err
+	}
+	if _, err := plan.Hops(opts.from, opts.to); err != nil {
+		return &UsageError{Err: err}
+	}
+	format, err := parseOutputFormat(opts.output)
+	if err != nil {
+		return err
+	}
+	apis, err := c.collectMerged(cmd.Context(), opts.from)
+	if err != nil {
+		return err
+	}
+	hops, err := plan.NewPlan(apis, opts.from, opts.to)
+	if err != nil {
+		return err
+	}
+	return output.WritePlan(cmd.OutOrStdout(), format, opts.from, opts.to, hops)
+}
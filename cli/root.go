@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"k8s-outdated/output"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+//Exit codes returned by the k8s-outdated binary
+const (
+	ExitOK       = 0
+	ExitError    = 1
+	ExitUsage    = 2
+	ExitFindings = 3
+)
+
+//UsageError is returned when the command line is invalid
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+//FindingsError is returned when a command completed but found outdated api usage
+type FindingsError struct {
+	Count int
+}
+
+func (e *FindingsError) Error() string {
+	return fmt.Sprintf("found %d outdated api usages", e.Count)
+}
+
+func usageErrorf(format string, a ...interface{}) error {
+	return &UsageError{Err: fmt.Errorf(format, a...)}
+}
+
+//Execute run the k8s-outdated command tree and return the process exit code
+func Execute(args []string, stdout io.Writer, stderr io.Writer) int {
+	c := defaultCollectors()
+	defer c.stop()
+	root := NewRootCommand(c)
+	root.SetArgs(args)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+	// Ctrl-C cancels the running downloads instead of killing the process mid-write
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	err := root.ExecuteContext(ctx)
+	return exitCode(err, stderr)
+}
+
+func exitCode(err error, stderr io.Writer) int {
+	if err == nil {
+		return ExitOK
+	}
+	var findings *FindingsError
+	if errors.As(err, &findings) {
+		return ExitFindings
+	}
+	fmt.Fprintln(stderr, "Error:", err)
+	var usage *UsageError
+	if errors.As(err, &usage) {
+		return ExitUsage
+	}
+	return ExitError
+}
+
+//NewRootCommand build the k8s-outdated root command with all sub commands
+func NewRootCommand(c *collectors) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "k8s-outdated [k8s version]",
+		Short: "search for k8s deprecated and removed API from k8s docs",
+		Long: "k8s-outdated collects deprecated and removed k8s APIs from the k8s swagger api and\n" +
+			"the k8s deprecation guide, and checks manifests against them.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.opts.validate(); err != nil {
+				return err
+			}
+			c.start(cmd)
+			return nil
+		},
+		Args: usageArgs(cobra.MaximumNArgs(1)),
+		// running the root command with a version argument is kept for backward compatibility
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+			return runList(cmd, c, &listOptions{k8sVersion: args[0], output: string(output.Table)})
+		},
+	}
+	addSourceFlags(root, &c.opts)
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &UsageError{Err: err}
+	})
+	root.AddCommand(
+		newListCommand(c),
+		newScanCommand(c),
+		newMigrateCommand(c),
+		newPlanCommand(c),
+		newCacheCommand(c),
+		newDatasetCommand(c),
+		newDiffCommand(c),
+		newExplainCommand(c),
+		newVersionCommand(),
+	)
+	return root
+}
+
+//Main is the entry point used by cmd/main.go
+func Main() {
+	os.Exit(Execute(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,475 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"k8s-outdated/kube/kubetest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fakeCollectors() *collectors {
+	return &collectors{
+		swagger: func(ctx context.Context, k8sVer string) (map[string]*collector.OutdatedAPI, error) {
+			return map[string]*collector.OutdatedAPI{
+				"io.k8s.api.batch.v1beta1.CronJob": {Description: "CronJob represents the configuration of a single cron job.", Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Source: collector.SourceSwagger,
+					Gav: collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}, Replacement: collector.Gvk{Group: "batch", Version: "v1", Kind: "CronJob"}},
+			}, nil
+		},
+		markdown: func(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+			return []*collector.OutdatedAPI{
+				{Removed: collector.MustParseKubeVersion("v1.26"), Source: collector.SourceMarkdown, Gav: collector.Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema"}},
+				{Removed: collector.MustParseKubeVersion("v1.25"), Source: collector.SourceMarkdown, Gav: collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"},
+					Replacement: collector.Gvk{Group: "batch", Version: "v2", Kind: "CronJob"}},
+			}, nil
+		},
+		lifecycle: func(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+			return []*collector.OutdatedAPI{
+				{Introduced: collector.MustParseKubeVersion("v1.8"), Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Source: collector.SourceLifecycle, Gav: collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"},
+					Replacement: collector.Gvk{Group: "batch", Version: "v1", Kind: "CronJob"}},
+			}, nil
+		},
+	}
+}
+
+func runCommand(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	root := NewRootCommand(fakeCollectors())
+	root.SetArgs(args)
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	code := exitCode(root.Execute(), &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		contains []string
+	}{
+		{name: "list", args: []string{"list", "--k8s-version", "v1.20.0"}, wantCode: ExitOK, contains: []string{"batch.v1beta1.CronJob", "flowcontrol.apiserver.k8s.io.v1beta1.FlowSchema"}},
+		{name: "root with version argument", args: []string{"v1.20.0"}, wantCode: ExitOK, contains: []string{"batch.v1beta1.CronJob"}},
+		{name: "list json output", args: []string{"list", "-k", "v1.20.0", "-o", "json"}, wantCode: ExitOK, contains: []string{`"schemaVersion": "k8s-outdated/v1"`, `"source": "swagger,markdown,lifecycle"`, `"field": "replacement"`, `"introduced": "v1.8"`}},
+		{name: "list invalid output", args: []string{"list", "-k", "v1.20.0", "-o", "xml"}, wantCode: ExitUsage},
+		{name: "list unknown tag policy", args: []string{"list", "-k", "v1.20.0", "--tag-policy", "newest"}, wantCode: ExitUsage},
+		{name: "list negative retries", args: []string{"list", "-k", "v1.20.0", "--retries", "-1"}, wantCode: ExitUsage},
+		{name: "list missing version", args: []string{"list"}, wantCode: ExitUsage},
+		{name: "list invalid version", args: []string{"list", "-k", "latest"}, wantCode: ExitUsage},
+		{name: "unknown flag", args: []string{"list", "--foo"}, wantCode: ExitUsage},
+		{name: "diff", args: []string{"diff", "-k", "v1.20.0"}, wantCode: ExitOK, contains: []string{"FlowSchema", markdownOnly, "batch/v2 CronJob"}},
+		{name: "explain", args: []string{"explain", "batch/v1beta1", "CronJob", "-k", "v1.20.0"}, wantCode: ExitOK, contains: []string{"swagger api", "deprecation guide", "prerelease lifecycle", "Introduced:  v1.8", "v1.21", "Replacement: batch/v1 CronJob"}},
+		{name: "explain not found", args: []string{"explain", "apps/v1", "Deployment", "-k", "v1.20.0"}, wantCode: ExitOK, contains: []string{"is not deprecated or removed"}},
+		{name: "explain missing kind", args: []string{"explain", "apps/v1", "-k", "v1.20.0"}, wantCode: ExitUsage},
+		{name: "scan with findings", args: []string{"scan", "./testdata/fixture/manifests.yaml", "-k", "v1.20.0", "-t", "v1.25.0"}, wantCode: ExitFindings, contains: []string{"batch/v1beta1/CronJob", "removed"}},
+		{name: "scan json findings", args: []string{"scan", "./testdata/fixture", "-k", "v1.20.0", "-t", "v1.21.0", "-o", "json"}, wantCode: ExitFindings, contains: []string{`"status": "deprecated"`, `"name": "hello"`}},
+		{name: "scan helm chart", args: []string{"scan", "../helm/testdata/fixture/mychart", "-k", "v1.20.0", "-t", "v1.25.0", "-o", "json"}, wantCode: ExitFindings,
+			contains: []string{`"chart": "mychart-1.2.3"`, `"template": "templates/cronjob.yaml"`}},
+		{name: "scan without findings", args: []string{"scan", "./testdata/fixture/manifests.yaml", "-k", "v1.20.0"}, wantCode: ExitOK},
+		{name: "scan invalid target version", args: []string{"scan", "./testdata/fixture/manifests.yaml", "-k", "v1.20.0", "-t", "next"}, wantCode: ExitUsage},
+		{name: "scan deprecated annotations", args: []string{"scan", "../scanner/testdata/fixture/metadata", "-k", "v1.20.0", "-t", "v1.25.0", "-o", "json"}, wantCode: ExitFindings,
+			contains: []string{`"field": "metadata.annotations[kubernetes.io/ingress.class]"`, `"replacement": "spec.securityContext.seccompProfile"`}},
+		{name: "scan extra deprecated metadata", args: []string{"scan", "../scanner/testdata/fixture/metadata", "-k", "v1.20.0", "-t", "v1.40.0", "-o", "csv",
+			"--metadata-path", "../collector/testdata/fixture/metadata.yaml"}, wantCode: ExitFindings, contains: []string{"removed,v1.18,v1.40,,,,,spec.ingressClassName"}},
+		{name: "scan missing metadata file", args: []string{"scan", "./testdata/fixture/manifests.yaml", "-k", "v1.20.0", "--metadata-path", "./testdata/fixture/missing.yaml"}, wantCode: ExitError},
+		{name: "scan missing file", args: []string{"scan", "./testdata/fixture/missing.yaml", "-k", "v1.20.0"}, wantCode: ExitError},
+		{name: "migrate diff", args: []string{"migrate", "./testdata/fixture/manifests.yaml", "-k", "v1.20.0", "-t", "v1.25.0"}, wantCode: ExitOK,
+			contains: []string{"-apiVersion: batch/v1beta1", "+apiVersion: batch/v1"}},
+		{name: "migrate nothing outdated", args: []string{"migrate", "./testdata/fixture/manifests.yaml", "-k", "v1.20.0"}, wantCode: ExitOK},
+		{name: "migrate without files", args: []string{"migrate", "-k", "v1.20.0"}, wantCode: ExitUsage},
+		{name: "plan", args: []string{"plan", "--from", "v1.21", "--to", "v1.26"}, wantCode: ExitOK,
+			contains: []string{"v1.24 -> v1.25", "removed", "batch/v1beta1/CronJob", "v1.25 -> v1.26", "flowcontrol.apiserver.k8s.io/v1beta1/FlowSchema"}},
+		{name: "plan same minor", args: []string{"plan", "--from", "v1.21.0", "--to", "v1.21.4"}, wantCode: ExitUsage},
+		{name: "plan missing to", args: []string{"plan", "--from", "v1.21"}, wantCode: ExitUsage},
+		{name: "version", args: []string{"version"}, wantCode: ExitOK, contains: []string{"k8s-outdated dev"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, _ := runCommand(tt.args...)
+			assert.Equal(t, tt.wantCode, code)
+			for _, c := range tt.contains {
+				assert.Contains(t, stdout, c)
+			}
+		})
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		contains []string
+		excludes []string
+	}{
+		{name: "removed at target", args: []string{"list", "-k", "v1.20.0", "--status", "removed", "-t", "v1.25"}, wantCode: ExitOK,
+			contains: []string{"batch.v1beta1.CronJob"}, excludes: []string{"FlowSchema"}},
+		{name: "removed soon", args: []string{"list", "-k", "v1.20.0", "--status", "removed-soon", "-t", "v1.24", "--within", "2"}, wantCode: ExitOK,
+			contains: []string{"batch.v1beta1.CronJob"}, excludes: []string{"FlowSchema"}},
+		{name: "group and kind", args: []string{"list", "-k", "v1.20.0", "--group", "flowcontrol.apiserver.k8s.io", "--kind", "Flow*"}, wantCode: ExitOK,
+			contains: []string{"FlowSchema"}, excludes: []string{"CronJob"}},
+		{name: "source", args: []string{"list", "-k", "v1.20.0", "--source", "lifecycle", "-o", "csv"}, wantCode: ExitOK,
+			contains: []string{"batch,v1beta1,CronJob"}, excludes: []string{"FlowSchema"}},
+		{name: "sort by removed version", args: []string{"list", "-k", "v1.20.0", "--sort", "removed", "-o", "csv"}, wantCode: ExitOK,
+			contains: []string{"CronJob,v1.21,v1.25", "FlowSchema,,v1.26"}},
+		{name: "unknown status", args: []string{"list", "-k", "v1.20.0", "--status", "gone"}, wantCode: ExitUsage},
+		{name: "unknown sort key", args: []string{"list", "-k", "v1.20.0", "--sort", "kind"}, wantCode: ExitUsage},
+		{name: "invalid target version", args: []string{"list", "-k", "v1.20.0", "--status", "removed", "-t", "next"}, wantCode: ExitUsage},
+		{name: "invalid kind pattern", args: []string{"list", "-k", "v1.20.0", "--kind", "[Flow"}, wantCode: ExitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, _ := runCommand(tt.args...)
+			assert.Equal(t, tt.wantCode, code)
+			for _, c := range tt.contains {
+				assert.Contains(t, stdout, c)
+			}
+			for _, e := range tt.excludes {
+				assert.NotContains(t, stdout, e)
+			}
+		})
+	}
+}
+
+func TestMigrateInPlace(t *testing.T) {
+	data, err := os.ReadFile("./testdata/fixture/manifests.yaml")
+	assert.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "manifests.yaml")
+	assert.NoError(t, os.WriteFile(file, data, 0600))
+	code, stdout, _ := runCommand("migrate", file, "-k", "v1.20.0", "-t", "v1.25.0", "--in-place")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "CronJob hello migrated from batch/v1beta1 to batch/v1")
+	migrated, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(string(data), "batch/v1beta1", "batch/v1", 1), string(migrated))
+	code, _, _ = runCommand("scan", file, "-k", "v1.20.0", "-t", "v1.25.0")
+	assert.Equal(t, ExitOK, code)
+}
+
+func TestCacheCommand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "swagger"), 0750))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "swagger", "v1.21.0.json"), []byte("{}"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "swagger", "v1.21.0.json.meta.json"),
+		[]byte(`{"key": "swagger/v1.21.0.json", "immutable": true, "size": 2, "fetched": "2021-04-08T00:00:00Z"}`), 0600))
+
+	code, stdout, _ := runCommand("cache", "--cache-dir", dir)
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "cache directory: "+dir)
+	assert.Contains(t, stdout, "swagger/v1.21.0.json")
+	code, stdout, _ = runCommand("cache", "--cache-dir", dir, "-o", "json")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, `"key": "swagger/v1.21.0.json"`)
+	code, _, _ = runCommand("cache", "--cache-dir", dir, "-o", "csv")
+	assert.Equal(t, ExitUsage, code)
+	code, _, _ = runCommand("list", "-k", "v1.20.0", "--no-cache", "--refresh-cache")
+	assert.Equal(t, ExitUsage, code)
+
+	code, stdout, _ = runCommand("cache", "--cache-dir", dir, "--clear")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "cleared")
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTimeout(t *testing.T) {
+	c := fakeCollectors()
+	c.swagger = func(ctx context.Context, k8sVer string) (map[string]*collector.OutdatedAPI, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	var stdout, stderr bytes.Buffer
+	root := NewRootCommand(c)
+	root.SetArgs([]string{"list", "-k", "v1.20.0", "--timeout", "50ms"})
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	code := exitCode(root.Execute(), &stderr)
+	c.stop()
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr.String(), context.DeadlineExceeded.Error())
+
+	code, _, _ = runCommand("list", "-k", "v1.20.0", "--concurrency", "0")
+	assert.Equal(t, ExitUsage, code)
+}
+
+func TestParseAPIVersionKind(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiVersion string
+		kind       string
+		want       collector.Gvk
+		wantErr    bool
+	}{
+		{name: "group version", apiVersion: "batch/v1beta1", kind: "CronJob", want: collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}},
+		{name: "core group", apiVersion: "v1", kind: "Pod", want: collector.Gvk{Version: "v1", Kind: "Pod"}},
+		{name: "missing kind", apiVersion: "v1", wantErr: true},
+		{name: "invalid api version", apiVersion: "a/b/c", kind: "Pod", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAPIVersionKind(tt.apiVersion, tt.kind)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExplainAPIOrder(t *testing.T) {
+	gvk := collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+	mDetails := map[string]*collector.OutdatedAPI{}
+	for _, name := range []string{"d", "a", "c", "b"} {
+		mDetails[name] = &collector.OutdatedAPI{Gav: gvk, Description: "definition " + name}
+	}
+	crds := []*collector.OutdatedAPI{{Gav: gvk, Description: "custom resource"}}
+	for i := 0; i < 5; i++ {
+		var out bytes.Buffer
+		assert.True(t, explainAPI(&out, gvk, nil, mDetails, nil, crds))
+		descriptions := make([]string, 0)
+		for _, line := range strings.Split(out.String(), "\n") {
+			if strings.HasPrefix(line, "Description: ") {
+				descriptions = append(descriptions, strings.TrimPrefix(line, "Description: "))
+			}
+		}
+		assert.Equal(t, []string{"definition a", "definition b", "definition c", "definition d", "custom resource"}, descriptions)
+	}
+}
+
+func TestOfflineMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		contains []string
+	}{
+		{name: "offline without local paths reads the embedded dataset", args: []string{"list", "-k", "v1.20.0", "--offline", "-o", "csv"},
+			wantCode: ExitOK, contains: []string{"batch,v1beta1,CronJob,v1.21,v1.25"}},
+		{name: "offline scan of the embedded dataset", args: []string{"scan", "../scanner/testdata/fixture/manifests", "-k", "v1.25.0", "--offline", "-o", "json"},
+			wantCode: ExitFindings, contains: []string{`"kind": "CronJob"`, `"status": "removed"`}},
+		{name: "offline without a deprecation guide path", args: []string{"list", "-k", "v1.20.0", "--offline",
+			"--swagger-path", "../collector/swagger/testdata/fixture/versions"}, wantCode: ExitUsage},
+		{name: "offline diff without local paths", args: []string{"diff", "-k", "v1.20.0", "--offline"}, wantCode: ExitUsage},
+		{name: "offline with local paths", args: []string{"list", "-k", "v1.20.0", "--offline",
+			"--swagger-path", "../collector/swagger/testdata/fixture/versions",
+			"--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md"},
+			wantCode: ExitOK, contains: []string{"rbac.authorization.k8s.io.v1alpha1.ClusterRoleBinding", "flowcontrol.apiserver.k8s.io.v1beta1.FlowSchema"}},
+		{name: "offline openapi v3 documents", args: []string{"list", "-k", "v1.20.0", "--offline", "--openapi-v3", "--group-versions", "policy/v1beta1",
+			"--swagger-path", "../collector/swagger/testdata/fixture/openapi-v3",
+			"--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md"},
+			wantCode: ExitOK, contains: []string{"policy.v1beta1.PodDisruptionBudget"}},
+		{name: "offline with prerelease lifecycle", args: []string{"list", "-k", "v1.20.0", "--offline", "-o", "csv",
+			"--swagger-path", "../collector/swagger/testdata/fixture/versions",
+			"--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md",
+			"--lifecycle-path", "../collector/lifecycle/testdata/fixture/api"},
+			wantCode: ExitOK, contains: []string{"flowcontrol.apiserver.k8s.io,v1beta2,PriorityLevelConfiguration,v1.26,v1.29", ",v1.23\n"}},
+		{name: "offline scan of deprecated fields", args: []string{"scan", "../scanner/testdata/fixture/fields", "-k", "v1.24.0", "--offline", "-o", "json",
+			"--swagger-path", "../collector/swagger/testdata/fixture/fields",
+			"--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md"},
+			wantCode: ExitFindings, contains: []string{`"field": "spec.serviceAccount"`, `"replacement": "serviceAccountName"`, `"field": "spec.volumes[].gitRepo"`}},
+		{name: "offline with custom resource definitions", args: []string{"list", "-k", "v1.20.0", "--offline", "-o", "csv",
+			"--swagger-path", "../collector/swagger/testdata/fixture/versions",
+			"--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md",
+			"--crd-path", "../collector/crd/testdata/fixture/crds"},
+			wantCode: ExitOK, contains: []string{"cert-manager.io,v1beta1,Certificate", "networking.istio.io,v1alpha3,VirtualService"}},
+		{name: "offline scan of custom resources", args: []string{"scan", "../collector/crd/testdata/fixture/resources", "-k", "v1.20.0", "--offline", "-o", "json",
+			"--swagger-path", "../collector/swagger/testdata/fixture/versions",
+			"--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md",
+			"--crd-path", "../collector/crd/testdata/fixture/crds"},
+			wantCode: ExitFindings, contains: []string{`"name": "web-tls"`, `"status": "deprecated"`, `"replacement": "cert-manager.io/v1 Certificate"`}},
+		{name: "offline explain of a custom resource", args: []string{"explain", "cert-manager.io/v1beta1", "Certificate", "-k", "v1.20.0", "--offline",
+			"--swagger-path", "../collector/swagger/testdata/fixture/versions",
+			"--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md",
+			"--crd-path", "../collector/crd/testdata/fixture/crds"},
+			wantCode: ExitOK, contains: []string{"Source:      custom resource definition", "API:         cert-manager.io.v1beta1.Certificate"}},
+		{name: "group versions without openapi v3", args: []string{"list", "-k", "v1.20.0", "--group-versions", "batch/v1beta1"}, wantCode: ExitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Execute(tt.args, &stdout, &stderr)
+			assert.Equal(t, tt.wantCode, code)
+			for _, c := range tt.contains {
+				assert.Contains(t, stdout.String(), c)
+			}
+		})
+	}
+}
+
+func TestClusterScan(t *testing.T) {
+	server := kubetest.NewServer("v1.25.2", "token", []kubetest.Resource{
+		{Group: "batch", Version: "v1", Kind: "CronJob", Name: "cronjobs", Namespaced: true, Items: []map[string]interface{}{
+			kubetest.Object("hello", "default", `{"apiVersion":"batch/v1beta1","kind":"CronJob"}`),
+		}},
+	})
+	defer server.Close()
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, server.WriteKubeconfig(kubeconfig))
+
+	code, stdout, _ := runCommand("scan", "--cluster", "--kubeconfig", kubeconfig, "-o", "json")
+	assert.Equal(t, ExitFindings, code)
+	assert.Contains(t, stdout, `"targetVersion": "v1.25.2"`)
+	assert.Contains(t, stdout, `"file": "/apis/batch/v1/namespaces/default/cronjobs/hello"`)
+	assert.Contains(t, stdout, `"status": "removed"`)
+
+	code, _, _ = runCommand("scan", "--cluster", "--kubeconfig", kubeconfig, "./testdata/fixture")
+	assert.Equal(t, ExitUsage, code)
+	code, _, _ = runCommand("scan", "-k", "v1.20.0")
+	assert.Equal(t, ExitUsage, code)
+	t.Setenv("HELM_DRIVER", "sql")
+	code, _, _ = runCommand("scan", "--cluster", "--kubeconfig", kubeconfig, "--helm-releases")
+	assert.Equal(t, ExitUsage, code)
+
+	server.OpenAPIV3 = map[string]map[string]interface{}{"apis/batch/v1beta1": {"components": map[string]interface{}{"schemas": map[string]interface{}{
+		"io.k8s.api.batch.v1beta1.CronJob": map[string]interface{}{
+			"description":                     "CronJob represents the configuration of a single cron job. Deprecated in v1.21, planned for removal in v1.25.",
+			"x-kubernetes-group-version-kind": []interface{}{map[string]interface{}{"group": "batch", "version": "v1beta1", "kind": "CronJob"}},
+		},
+	}}}}
+	var out, stderr bytes.Buffer
+	code = Execute([]string{"scan", "--cluster", "--kubeconfig", kubeconfig, "-o", "json", "--openapi-v3", "--offline", "--swagger-path", "./testdata/missing",
+		"--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md"}, &out, &stderr)
+	assert.Equal(t, ExitFindings, code, stderr.String())
+	assert.Contains(t, out.String(), `"file": "/apis/batch/v1/namespaces/default/cronjobs/hello"`)
+	assert.Contains(t, stderr.String(), "fetched openapi-v3 apis/batch/v1beta1 (1/1)")
+}
+
+func TestClusterScanCustomResources(t *testing.T) {
+	definition := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "certificates.cert-manager.io"},
+		"spec": map[string]interface{}{"group": "cert-manager.io", "names": map[string]interface{}{"kind": "Certificate"}, "versions": []interface{}{
+			map[string]interface{}{"name": "v1beta1", "served": true, "deprecated": true},
+			map[string]interface{}{"name": "v1", "served": true, "storage": true},
+		}},
+	}
+	server := kubetest.NewServer("v1.25.2", "", []kubetest.Resource{
+		{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition", Name: "customresourcedefinitions", Items: []map[string]interface{}{definition}},
+		{Group: "cert-manager.io", Version: "v1", Kind: "Certificate", Name: "certificates", Namespaced: true, Items: []map[string]interface{}{
+			kubetest.Object("web-tls", "default", `{"apiVersion":"cert-manager.io/v1beta1","kind":"Certificate"}`),
+		}},
+	})
+	defer server.Close()
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, server.WriteKubeconfig(kubeconfig))
+
+	// the default collectors read the definitions of the cluster, the core api collectors are faked
+	c, fake := defaultCollectors(), fakeCollectors()
+	c.swagger, c.markdown, c.lifecycle, c.fields = fake.swagger, fake.markdown, fake.lifecycle, nil
+	run := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		root := NewRootCommand(c)
+		root.SetArgs(args)
+		root.SetOut(&stdout)
+		root.SetErr(&stderr)
+		code := exitCode(root.Execute(), &stderr)
+		assert.NotContains(t, stderr.String(), "Error", stderr.String())
+		return code, stdout.String()
+	}
+	code, stdout := run("scan", "--cluster", "--kubeconfig", kubeconfig, "-o", "json")
+	assert.Equal(t, ExitFindings, code)
+	assert.Contains(t, stdout, `"file": "/apis/cert-manager.io/v1/namespaces/default/certificates/web-tls"`)
+	assert.Contains(t, stdout, `"replacement": "cert-manager.io/v1 Certificate"`)
+
+	code, _ = run("scan", "--cluster", "--kubeconfig", kubeconfig, "--crds=false")
+	assert.Equal(t, ExitOK, code)
+}
+
+func TestDataset(t *testing.T) {
+	t.Setenv(sourceDateEpochEnv, "1714638615")
+	path := filepath.Join(t.TempDir(), "dataset.json")
+	fixtures := []string{"--swagger-path", "../collector/swagger/testdata/fixture/versions",
+		"--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md",
+		"--lifecycle-path", "../collector/lifecycle/testdata/fixture/api"}
+	execute := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := Execute(args, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, _, stderr := execute(append([]string{"dataset", "generate", path, "-k", "v1.20.0", "--offline", "--commit", "markdown=3f2a1c9"}, fixtures...)...)
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stderr, "wrote 13 apis and 0 deprecated fields to "+path)
+	// the same collector results give the same file
+	code, stdout, _ := execute(append([]string{"dataset", "generate", "-k", "v1.20.0", "--offline", "--commit", "markdown=3f2a1c9"}, fixtures...)...)
+	assert.Equal(t, ExitOK, code)
+	generated, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(generated), stdout)
+
+	code, stdout, _ = execute("dataset", "info", "--dataset", path)
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "generated: 2024-05-02T08:30:15Z\nk8s versions: v1.20 to v1.21 (2 releases)\napis: 13\n")
+	assert.Contains(t, stdout, "3f2a1c9")
+	code, stdout, _ = execute("dataset", "info", "--dataset", path, "-o", "json")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, `"k8sVersions": [
+    "v1.20",
+    "v1.21"
+  ]`)
+
+	// the dataset lists the same apis as the collectors it was generated from, without reading them
+	_, live, _ := execute(append([]string{"list", "-k", "v1.20.0", "--offline", "-o", "csv"}, fixtures...)...)
+	code, stdout, stderr = execute("list", "-k", "v1.20.0", "--offline", "-o", "csv", "--dataset", path)
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, live, stdout)
+	assert.Empty(t, stderr)
+	// a dataset read from a later release than it was generated from leaves out the swagger apis the collectors leave out
+	from := filepath.Join(t.TempDir(), "from.json")
+	code, _, _ = execute(append([]string{"dataset", "generate", from, "-k", "v1.19.0", "--offline"}, fixtures...)...)
+	assert.Equal(t, ExitOK, code)
+	for _, k8sVersion := range []string{"v1.19.0", "v1.20.0", "v1.21.0"} {
+		_, live, _ = execute(append([]string{"list", "-k", k8sVersion, "--offline", "-o", "json"}, fixtures...)...)
+		code, stdout, stderr = execute("list", "-k", k8sVersion, "--offline", "-o", "json", "--dataset", from)
+		assert.Equal(t, ExitOK, code)
+		assert.Equal(t, live, stdout, k8sVersion)
+		assert.Empty(t, stderr)
+	}
+	assert.NotContains(t, stdout, "rbac.authorization.k8s.io")
+
+	code, _, stderr = execute("list", "-k", "v1.19.0", "--offline", "--dataset", path)
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stderr, "warning: dataset "+path+" does not cover v1.19, the apis removed before v1.20 may be missing")
+	code, stdout, _ = execute("scan", "./testdata/fixture", "-k", "v1.25.0", "-o", "json", "--dataset", path,
+		"--crd-path", "../collector/crd/testdata/fixture/crds")
+	assert.Equal(t, ExitFindings, code)
+	assert.Contains(t, stdout, `"status": "removed"`)
+
+	// a dataset missing a core source is not written
+	code, _, stderr = execute("dataset", "generate", filepath.Join(t.TempDir(), "incomplete.json"), "-k", "v1.20.0", "--offline",
+		"--swagger-path", "../collector/swagger/testdata/fixture/versions", "--deprecation-guide-path", "../collector/markdown/testdata/fixture/deprecation-guide.md")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "the dataset has no lifecycle, it is not written")
+
+	empty := filepath.Join(t.TempDir(), "empty.json")
+	assert.NoError(t, os.WriteFile(empty, []byte(`{"schemaVersion": 1, "sources": []}`), 0o600))
+	code, _, stderr = execute("list", "-k", "v1.20.0", "--dataset", empty)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "has no apis, write one with k8s-outdated dataset generate")
+
+	incomplete := filepath.Join(t.TempDir(), "incomplete.json")
+	assert.NoError(t, os.WriteFile(incomplete, []byte(`{"schemaVersion": 1, "sources": [{"name": "markdown", "priority": 20,
+		"apis": [{"group": "batch", "version": "v1beta1", "kind": "CronJob", "removed": "v1.25", "source": "markdown"}]}]}`), 0o600))
+	code, stdout, stderr = execute("list", "-k", "v1.20.0", "--dataset", incomplete, "-o", "csv")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "CronJob")
+	assert.Contains(t, stderr, "warning: dataset "+incomplete+" is incomplete, it has no swagger, lifecycle, k8sVersions, regenerate it with k8s-outdated dataset generate")
+
+	for _, args := range [][]string{
+		{"list", "-k", "v1.20.0", "--dataset", path, "--swagger-path", "../collector/swagger/testdata/fixture/versions"},
+		{"diff", "-k", "v1.20.0", "--dataset", path},
+		{"explain", "batch/v1beta1", "CronJob", "-k", "v1.20.0", "--dataset", path},
+		{"dataset", "generate", "-k", "v1.20.0", "--dataset", path},
+		append([]string{"dataset", "generate", "-k", "v1.20.0", "--offline", "--commit", "lifecycle"}, fixtures...),
+		append([]string{"dataset", "generate", "-k", "v1.20.0", "--offline", "--commit", "crd=3f2a1c9"}, fixtures...),
+	} {
+		code, _, _ = execute(args...)
+		assert.Equal(t, ExitUsage, code, strings.Join(args, " "))
+	}
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"k8s-outdated/collector"
+	"k8s-outdated/helm"
+	"k8s-outdated/kube"
+	"k8s-outdated/output"
+	"k8s-outdated/scanner"
+	"os"
+)
+
+type scanOptions struct {
+	k8sVersion    string
+	targetVersion string
+	output        string
+	cluster       bool
+	kubeconfig    string
+	context       string
+	helmReleases  []string
+	clusterHelm   bool
+	metadataPath  string
+	crds          bool
+}
+
+func newScanCommand(c *collectors) *cobra.Command {
+	opts := &scanOptions{}
+	cmd := &cobra.Command{
+		Use:   "scan <file|dir|->... | --cluster",
+		Short: "Scan k8s manifests or a live cluster for deprecated and removed API usage",
+		Long: "Scan yaml and json manifest files and directories, including multi document files and List kinds,\n" +
+			"and report every object using an API which is deprecated or removed at the target k8s version.\n" +
+			"Objects setting deprecated fields or carrying deprecated annotations and labels are reported as well.\n\n" +
+			"With --cluster the objects stored in the cluster are listed through the kubeconfig and checked by the\n" +
+			"apiVersion recorded in their kubectl.kubernetes.io/last-applied-configuration annotation, or as listed\n" +
+			"when they have none, against the cluster server version.\n\n" +
+			"Helm chart directories and packaged .tgz charts are detected among the paths. Their templates are not\n" +
+			"rendered, every literal apiVersion of a template document is checked except in the else branches of a\n" +
+			".Capabilities condition. Deployed helm releases are read from release secret files with --helm-release,\n" +
+			"or from the cluster with --cluster --helm-releases, from configmaps with HELM_DRIVER=configmap.",
+		Example: "  k8s-outdated scan ./manifests -k v1.20.0 --target-version v1.25.0\n" +
+			"  helm template ./chart | k8s-outdated scan - -k v1.20.0\n" +
+			"  k8s-outdated scan --cluster --context prod --helm-releases\n" +
+			"  k8s-outdated scan ./charts/mychart-1.2.3.tgz --helm-release ./release-secret.yaml -k v1.20.0",
+		Args: usageArgs(cobra.ArbitraryArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.cluster {
+				return runClusterScan(cmd, c, opts, args)
+			}
+			return runScan(cmd, c, opts, args)
+		},
+	}
+	addK8sVersionFlag(cmd, &opts.k8sVersion)
+	addOutputFlag(cmd, &opts.output)
+	cmd.Flags().StringVarP(&opts.targetVersion, targetVersionFlag, "t", "",
+		"k8s version the objects are checked against (default --k8s-version, or the server version with --cluster)")
+	cmd.Flags().BoolVar(&opts.cluster, "cluster", false, "scan the objects stored in the cluster instead of manifest files")
+	cmd.Flags().StringVar(&opts.kubeconfig, "kubeconfig", "", "path to the kubeconfig file (default $KUBECONFIG or ~/.kube/config)")
+	cmd.Flags().StringVar(&opts.context, "context", "", "kubeconfig context to use (default current-context)")
+	cmd.Flags().StringArrayVar(&opts.helmReleases, "helm-release", nil,
+		"helm release secret or config map manifest, or raw release payload file to scan (repeatable)")
+	cmd.Flags().BoolVar(&opts.clusterHelm, "helm-releases", false, "with --cluster, also scan the deployed helm releases stored in secrets, or in configmaps with HELM_DRIVER=configmap")
+	cmd.Flags().BoolVar(&opts.crds, "crds", true, "with --cluster, also check custom resources against the versions their CustomResourceDefinitions deprecate")
+	cmd.Flags().StringVar(&opts.metadataPath, metadataPathFlag, os.Getenv(metadataPathEnv),
+		"yaml file of deprecated annotations and labels checked in addition to the built-in ones")
+	return cmd
+}
+
+func runScan(cmd *cobra.Command, c *collectors, opts *scanOptions, paths []string) error {
+	if len(paths) == 0 && len(opts.helmReleases) == 0 {
+		return usageErrorf("at least one file, directory or - is required")
+	}
+	if err := validateK8sVersion(opts.k8sVersion); err != nil {
+		return err
+	}
+	target := opts.targetVersion
+	if len(target) == 0 {
+		target = opts.k8sVersion
+	}
+	if err := validateVersion(targetVersionFlag, target); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(opts.output)
+	if err != nil {
+		return err
+	}
+	s, err := newScanner(cmd, c, opts, opts.k8sVersion, target)
+	if err != nil {
+		return err
+	}
+	findings, err := s.ScanPaths(paths)
+	if err != nil {
+		return err
+	}
+	for _, release := range opts.helmReleases {
+		releaseFindings, err := s.ScanReleaseFile(release)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, releaseFindings...)
+	}
+	return writeFindings(cmd, format, target, findings)
+}
+
+func runClusterScan(cmd *cobra.Command, c *collectors, opts *scanOptions, args []string) error {
+	if len(args) > 0 || len(opts.helmReleases) > 0 {
+		return usageErrorf("--cluster does not accept file arguments")
+	}
+	format, err := parseOutputFormat(opts.output)
+	if err != nil {
+		return err
+	}
+	driver, err := helm.ParseStorageDriver(os.Getenv(helm.DriverEnv))
+	if err != nil && opts.clusterHelm {
+		return &UsageError{Err: err}
+	}
+	cfg, err := kube.LoadConfig(opts.kubeconfig, opts.context)
+	if err != nil {
+		return err
+	}
+	client, err := kube.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	serverVersion, err := scanner.ClusterVersion(client)
+	if err != nil {
+		return err
+	}
+	k8sVersion, target := opts.k8sVersion, opts.targetVersion
+	if len(k8sVersion) == 0 {
+		k8sVersion = serverVersion
+	}
+	if len(target) == 0 {
+		target = serverVersion
+	}
+	if err := validateK8sVersion(k8sVersion); err != nil {
+		return err
+	}
+	if err := validateVersion(targetVersionFlag, target); err != nil {
+		return err
+	}
+	if c.opts.openAPIV3 {
+		c.server = client
+	}
+	if opts.crds {
+		c.crdLister = client
+	}
+	s, err := newScanner(cmd, c, opts, k8sVersion, target)
+	if err != nil {
+		return err
+	}
+	findings, err := s.ScanCluster(client)
+	if err != nil {
+		return err
+	}
+	if opts.clusterHelm {
+		releaseFindings, err := s.ScanClusterReleases(client, driver)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, releaseFindings...)
+	}
+	return writeFindings(cmd, format, target, findings)
+}
+
+//newScanner build the scanner of the outdated apis, deprecated fields and deprecated annotations and labels
+func newScanner(cmd *cobra.Command, c *collectors, opts *scanOptions, k8sVersion string, target string) (*scanner.Scanner, error) {
+	metadata := collector.DeprecatedMetadataDataset()
+	if len(opts.metadataPath) > 0 {
+		extra, err := collector.ReadDeprecatedMetadata(opts.metadataPath)
+		if err != nil {
+			return nil, err
+		}
+		metadata = collector.MergeDeprecatedMetadata(metadata, extra)
+	}
+	apis, err := c.collectMerged(cmd.Context(), k8sVersion)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := c.collectFields(cmd.Context(), k8sVersion)
+	if err != nil {
+		return nil, err
+	}
+	s, err := scanner.NewScanner(apis, target)
+	if err != nil {
+		return nil, err
+	}
+	return s.WithDeprecatedFields(fields).WithDeprecatedMetadata(metadata), nil
+}
+
+func writeFindings(cmd *cobra.Command, format output.Format, target string, findings []scanner.Finding) error {
+	if err := output.WriteFindings(cmd.OutOrStdout(), format, target, findings); err != nil {
+		return err
+	}
+	if len(findings) > 0 {
+		return &FindingsError{Count: len(findings)}
+	}
+	return nil
+}
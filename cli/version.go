@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+//Version and Commit of the binary, set at build time with -ldflags "-X k8s-outdated/cli.Version=..."
+var (
+	Version = "dev"
+	Commit  = "none"
+)
+
+func newVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the k8s-outdated version",
+		Args:  usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintf(cmd.OutOrStdout(), "k8s-outdated %s (commit %s)\n", Version, Commit)
+		},
+	}
+}
@@ -0,0 +1,266 @@
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Client defaults
+const (
+	DefaultTimeout = 60 * time.Second
+	DefaultRetries = 3
+	// DefaultMaxWait is the longest a rate limited request waits for the limit to reset before failing
+	DefaultMaxWait = time.Minute
+
+	defaultBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+	maxErrorBody   = 512
+
+	//TokenEnv holds the github token sent to github hosts, raising the api rate limit from 60 to 5000 requests an hour
+	TokenEnv = "GITHUB_TOKEN"
+)
+
+//hosts the github token is sent to, it must never leak to other hosts
+var githubHosts = map[string]bool{"api.github.com": true, "github.com": true, "raw.githubusercontent.com": true}
+
+//ClientOptions configure the http client shared by the collectors
+type ClientOptions struct {
+	// Timeout of every single request attempt, including reading the body
+	Timeout time.Duration
+	// Retries of a request failing with a network error, 429, 5xx or a rate limited 403
+	Retries int
+	// MaxWait is the longest Retry-After or X-RateLimit-Reset delay waited for
+	MaxWait time.Duration
+	// Token is sent as bearer token to github hosts only
+	Token string
+	// Proxy url, the HTTPS_PROXY/HTTP_PROXY/NO_PROXY environment is used when empty
+	Proxy string
+	// CABundle is a pem file of certificate authorities trusted in addition to the system pool
+	CABundle string
+}
+
+//Client http client shared by the collectors, retrying with exponential backoff and returning *HTTPError on non 2xx/3xx answers
+type Client struct {
+	http    *http.Client
+	token   string
+	retries int
+	maxWait time.Duration
+	backoff time.Duration
+	now     func() time.Time
+}
+
+//HTTPError non successful http answer
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	// Body is the beginning of the answer body
+	Body string
+	// RateLimited is set when github rejected the request for exceeding the rate limit
+	RateLimited bool
+	// Reset is when the rate limit resets, zero when unknown
+	Reset time.Time
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+	if e.RateLimited {
+		msg += ": rate limit exceeded"
+		if !e.Reset.IsZero() {
+			msg += ", resets at " + e.Reset.Format(time.RFC3339)
+		}
+		msg += ", set " + TokenEnv + " to raise the limit"
+	} else if len(e.Body) > 0 {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+//NewClient build the collector http client
+func NewClient(opts ClientOptions) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(opts.Proxy) > 0 {
+		proxy, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url %q: %w", opts.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if len(opts.CABundle) > 0 {
+		pool, err := certPool(opts.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	maxWait := opts.MaxWait
+	if maxWait == 0 {
+		maxWait = DefaultMaxWait
+	}
+	return &Client{
+		http:    &http.Client{Transport: transport, Timeout: timeout},
+		token:   opts.Token,
+		retries: opts.Retries,
+		maxWait: maxWait,
+		backoff: defaultBackoff,
+		now:     time.Now,
+	}, nil
+}
+
+//DefaultClient build a client with default options and the github token of the environment
+func DefaultClient() *Client {
+	c, _ := NewClient(ClientOptions{Retries: DefaultRetries, Token: os.Getenv(TokenEnv)})
+	return c
+}
+
+func certPool(caBundle string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caBundle)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in ca bundle %s", caBundle)
+	}
+	return pool, nil
+}
+
+//Do send a request without body, retrying failures. Answers with a status of 400 or more are returned as *HTTPError
+func (c Client) Do(req *http.Request) (*http.Response, error) {
+	if len(c.token) > 0 && githubHosts[req.URL.Hostname()] && len(req.Header.Get("Authorization")) == 0 {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for attempt := 0; ; attempt++ {
+		res, err := c.http.Do(req)
+		var wait time.Duration
+		retry := true
+		if err != nil {
+			if req.Context().Err() != nil {
+				return nil, req.Context().Err()
+			}
+			wait = c.backoffDelay(attempt)
+		} else {
+			if res.StatusCode < http.StatusBadRequest {
+				return res, nil
+			}
+			httpErr := c.newHTTPError(req, res)
+			err = httpErr
+			wait, retry = c.retryDelay(res, httpErr, attempt)
+		}
+		if !retry || attempt >= c.retries {
+			return nil, err
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+//Get download url and return its body
+func (c Client) Get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	return io.ReadAll(res.Body)
+}
+
+//newHTTPError read and close the body of a failed answer
+func (c Client) newHTTPError(req *http.Request, res *http.Response) *HTTPError {
+	defer res.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	httpErr := &HTTPError{
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Body:       strings.TrimSpace(string(body)),
+	}
+	if res.StatusCode == http.StatusTooManyRequests ||
+		(res.StatusCode == http.StatusForbidden && (res.Header.Get("X-RateLimit-Remaining") == "0" || len(res.Header.Get("Retry-After")) > 0)) {
+		httpErr.RateLimited = true
+		if reset, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			httpErr.Reset = time.Unix(reset, 0).UTC()
+		}
+	}
+	return httpErr
+}
+
+//retryDelay return how long to wait before retrying a failed answer, false when it must not be retried
+func (c Client) retryDelay(res *http.Response, httpErr *HTTPError, attempt int) (time.Duration, bool) {
+	if !httpErr.RateLimited && res.StatusCode < http.StatusInternalServerError {
+		return 0, false
+	}
+	if wait, ok := retryAfter(res.Header.Get("Retry-After"), c.now()); ok {
+		return wait, wait <= c.maxWait
+	}
+	if !httpErr.Reset.IsZero() {
+		wait := httpErr.Reset.Sub(c.now())
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, wait <= c.maxWait
+	}
+	return c.backoffDelay(attempt), true
+}
+
+//backoffDelay exponential backoff of the attempt, capped at maxBackoff
+func (c Client) backoffDelay(attempt int) time.Duration {
+	wait := c.backoff << uint(attempt)
+	if wait <= 0 || wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
+
+//retryAfter parse a Retry-After header holding seconds or an http date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,180 @@
+package collector
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testClient(t *testing.T, opts ClientOptions) *Client {
+	c, err := NewClient(opts)
+	assert.NoError(t, err)
+	c.backoff = time.Millisecond
+	return c
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		retries     int
+		answers     []func(w http.ResponseWriter)
+		wantBody    string
+		wantStatus  int
+		wantLimited bool
+		wantCalls   int32
+	}{
+		{name: "5xx retried", retries: 3, wantBody: "ok", wantCalls: 3, answers: []func(w http.ResponseWriter){
+			status(http.StatusBadGateway), status(http.StatusServiceUnavailable), body("ok")}},
+		{name: "retries exhausted", retries: 1, wantStatus: http.StatusInternalServerError, wantCalls: 2, answers: []func(w http.ResponseWriter){
+			status(http.StatusInternalServerError), status(http.StatusInternalServerError), body("ok")}},
+		{name: "429 honours Retry-After", retries: 2, wantBody: "ok", wantCalls: 2, answers: []func(w http.ResponseWriter){
+			func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			}, body("ok")}},
+		{name: "rate limit reset soon is waited for", retries: 2, wantBody: "ok", wantCalls: 2, answers: []func(w http.ResponseWriter){
+			rateLimited(time.Now().Add(-time.Second)), body("ok")}},
+		{name: "rate limit reset too far fails fast", retries: 3, wantStatus: http.StatusForbidden, wantLimited: true, wantCalls: 1, answers: []func(w http.ResponseWriter){
+			rateLimited(time.Now().Add(time.Hour)), body("ok")}},
+		{name: "404 not retried", retries: 3, wantStatus: http.StatusNotFound, wantCalls: 1, answers: []func(w http.ResponseWriter){
+			status(http.StatusNotFound), body("ok")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				tt.answers[n-1](w)
+			}))
+			defer srv.Close()
+			data, err := testClient(t, ClientOptions{Retries: tt.retries}).Get(context.Background(), srv.URL)
+			assert.Equal(t, tt.wantCalls, atomic.LoadInt32(&calls))
+			if tt.wantStatus > 0 {
+				var httpErr *HTTPError
+				assert.True(t, errors.As(err, &httpErr))
+				assert.Equal(t, tt.wantStatus, httpErr.StatusCode)
+				assert.Equal(t, tt.wantLimited, httpErr.RateLimited)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(data))
+		})
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	srv := httptest.NewServer(rateLimitedHandler(time.Unix(1700000000, 0)))
+	defer srv.Close()
+	_, err := testClient(t, ClientOptions{}).Get(context.Background(), srv.URL)
+	assert.EqualError(t, err, fmt.Sprintf("GET %s: 403 Forbidden: rate limit exceeded, resets at 2023-11-14T22:13:20Z, set GITHUB_TOKEN to raise the limit", srv.URL))
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+	_, err = testClient(t, ClientOptions{}).Get(context.Background(), srv.URL+"/v1.99.0/swagger.json")
+	assert.EqualError(t, err, fmt.Sprintf("GET %s/v1.99.0/swagger.json: 404 Not Found: Not Found", srv.URL))
+}
+
+func TestClientToken(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+	c := testClient(t, ClientOptions{Token: "secret"})
+	_, err := c.Get(context.Background(), srv.URL)
+	assert.NoError(t, err)
+	assert.Empty(t, auth, "token must only be sent to github hosts")
+
+	githubHosts["127.0.0.1"] = true
+	defer delete(githubHosts, "127.0.0.1")
+	_, err = c.Get(context.Background(), srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer secret", auth)
+}
+
+func TestClientProxy(t *testing.T) {
+	var requested string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		_, _ = w.Write([]byte("proxied"))
+	}))
+	defer proxy.Close()
+	data, err := testClient(t, ClientOptions{Proxy: proxy.URL}).Get(context.Background(), "http://k8s.example/swagger.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "proxied", string(data))
+	assert.Equal(t, "http://k8s.example/swagger.json", requested)
+
+	_, err = NewClient(ClientOptions{Proxy: "://bad"})
+	assert.Error(t, err)
+}
+
+func TestClientCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("trusted"))
+	}))
+	defer srv.Close()
+	_, err := testClient(t, ClientOptions{}).Get(context.Background(), srv.URL)
+	assert.Error(t, err)
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(bundle, cert, 0600))
+	data, err := testClient(t, ClientOptions{CABundle: bundle}).Get(context.Background(), srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "trusted", string(data))
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	assert.NoError(t, os.WriteFile(empty, []byte("no certificate"), 0600))
+	_, err = NewClient(ClientOptions{CABundle: empty})
+	assert.Error(t, err)
+}
+
+func TestClientCancelledWhileWaiting(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := testClient(t, ClientOptions{Retries: 3}).Get(ctx, srv.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func status(code int) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(code)
+	}
+}
+
+func body(b string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		_, _ = w.Write([]byte(b))
+	}
+}
+
+func rateLimited(reset time.Time) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}
+}
+
+func rateLimitedHandler(reset time.Time) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rateLimited(reset)(w)
+	})
+}
@@ -0,0 +1,218 @@
+//Package crd collect the deprecated and unserved custom resource versions declared by CustomResourceDefinitions
+package crd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"k8s-outdated/collector"
+	"k8s-outdated/helm"
+	"k8s-outdated/kube"
+	"k8s-outdated/scanner"
+	"sort"
+)
+
+const (
+	crdGroup    = "apiextensions.k8s.io"
+	crdVersion  = "v1"
+	crdResource = "customresourcedefinitions"
+	crdKind     = "CustomResourceDefinition"
+)
+
+//Lister list the objects of a resource, implemented by kube.Client
+type Lister interface {
+	List(group string, version string, resource string) ([]map[string]interface{}, error)
+}
+
+//CustomResourceDefinition fields of a v1 or v1beta1 CustomResourceDefinition describing the lifecycle of its versions
+type CustomResourceDefinition struct {
+	Metadata struct {
+		Name string `json:"name" yaml:"name"`
+	} `json:"metadata" yaml:"metadata"`
+	Spec struct {
+		Group string `json:"group" yaml:"group"`
+		Names struct {
+			Kind string `json:"kind" yaml:"kind"`
+		} `json:"names" yaml:"names"`
+		// Version is the single version of v1beta1 definitions without versions
+		Version  string    `json:"version" yaml:"version"`
+		Versions []Version `json:"versions" yaml:"versions"`
+	} `json:"spec" yaml:"spec"`
+}
+
+//Version custom resource version of a CustomResourceDefinition
+type Version struct {
+	Name               string `json:"name" yaml:"name"`
+	Served             bool   `json:"served" yaml:"served"`
+	Storage            bool   `json:"storage" yaml:"storage"`
+	Deprecated         bool   `json:"deprecated" yaml:"deprecated"`
+	DeprecationWarning string `json:"deprecationWarning" yaml:"deprecationWarning"`
+}
+
+//CustomResources collector of the CustomResourceDefinitions of manifest files or of a cluster
+type CustomResources struct {
+	paths  []string
+	lister Lister
+}
+
+//NewLocalCustomResources instansiate new CustomResources reading the definitions from manifest files and directories
+func NewLocalCustomResources(paths ...string) *CustomResources {
+	return &CustomResources{paths: paths}
+}
+
+//NewClusterCustomResources instansiate new CustomResources listing the definitions stored in a cluster
+func NewClusterCustomResources(lister Lister) *CustomResources {
+	return &CustomResources{lister: lister}
+}
+
+//Name return the source of the custom resource versions
+func (cr CustomResources) Name() string {
+	return collector.SourceCRD
+}
+
+//Priority return the merge priority of the definitions, the lowest so they never change what the core api collectors report
+func (cr CustomResources) Priority() int {
+	return collector.PriorityCRD
+}
+
+//Collect collect the deprecated and unserved custom resource versions, the options are unused
+func (cr CustomResources) Collect(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+	return cr.CollectOutdatedAPI(ctx)
+}
+
+//CollectOutdatedAPI return an outdated api for every deprecated or unserved version of the definitions,
+//the first definition of a group and kind wins
+func (cr CustomResources) CollectOutdatedAPI(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+	definitions, err := cr.definitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	apis := make([]*collector.OutdatedAPI, 0)
+	seen := make(map[collector.Gvk]bool)
+	for _, d := range definitions {
+		for _, api := range OutdatedVersions(d) {
+			if seen[api.Gav] {
+				continue
+			}
+			seen[api.Gav] = true
+			apis = append(apis, api)
+		}
+	}
+	sort.SliceStable(apis, func(i, j int) bool {
+		return apis[i].Gav.String() < apis[j].Gav.String()
+	})
+	return apis, nil
+}
+
+func (cr CustomResources) definitions(ctx context.Context) ([]CustomResourceDefinition, error) {
+	if cr.lister != nil {
+		return cr.clusterDefinitions(ctx)
+	}
+	return cr.localDefinitions(ctx)
+}
+
+//localDefinitions decode the CustomResourceDefinitions of the manifest files, helm charts are skipped
+func (cr CustomResources) localDefinitions(ctx context.Context) ([]CustomResourceDefinition, error) {
+	files, err := scanner.ManifestFiles(cr.paths)
+	if err != nil {
+		return nil, err
+	}
+	definitions := make([]CustomResourceDefinition, 0)
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if file != scanner.StdinPath && helm.IsChart(file) {
+			continue
+		}
+		objects, err := scanner.ReadObjects(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			gvk, err := collector.ParseGvk(obj.APIVersion, obj.Kind)
+			if err != nil || gvk.Group != crdGroup || gvk.Kind != crdKind {
+				continue
+			}
+			var d CustomResourceDefinition
+			if err := obj.Node.Decode(&d); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", obj.File, obj.Line, err)
+			}
+			definitions = append(definitions, d)
+		}
+	}
+	return definitions, nil
+}
+
+//clusterDefinitions list the CustomResourceDefinitions of the cluster
+func (cr CustomResources) clusterDefinitions(ctx context.Context) ([]CustomResourceDefinition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	items, err := cr.lister.List(crdGroup, crdVersion, crdResource)
+	// clusters older than v1.16 do not serve apiextensions.k8s.io/v1
+	if kube.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	definitions := make([]CustomResourceDefinition, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var d CustomResourceDefinition
+		if err := json.Unmarshal(data, &d); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, d)
+	}
+	return definitions, nil
+}
+
+//OutdatedVersions return an outdated api for every deprecated or unserved version of a definition, the replacement
+//is the storage version, or the first served version, when it is neither deprecated nor unserved
+func OutdatedVersions(d CustomResourceDefinition) []*collector.OutdatedAPI {
+	versions := d.Spec.Versions
+	if len(versions) == 0 && len(d.Spec.Version) > 0 {
+		versions = []Version{{Name: d.Spec.Version, Served: true, Storage: true}}
+	}
+	replacement := ""
+	for _, v := range versions {
+		if v.Served && !v.Deprecated && (v.Storage || len(replacement) == 0) {
+			replacement = v.Name
+		}
+	}
+	apis := make([]*collector.OutdatedAPI, 0)
+	for _, v := range versions {
+		if v.Served && !v.Deprecated {
+			continue
+		}
+		api := &collector.OutdatedAPI{
+			Description:    description(d, v),
+			Source:         collector.SourceCRD,
+			Gav:            collector.Gvk{Group: d.Spec.Group, Version: v.Name, Kind: d.Spec.Names.Kind},
+			CustomResource: true,
+			Unserved:       !v.Served,
+		}
+		if len(replacement) > 0 {
+			api.Replacement = collector.Gvk{Group: d.Spec.Group, Version: replacement, Kind: d.Spec.Names.Kind}
+		}
+		apis = append(apis, api)
+	}
+	return apis
+}
+
+//description return the deprecation warning of a version, or a sentence naming its lifecycle and definition
+func description(d CustomResourceDefinition, v Version) string {
+	if v.Served && len(v.DeprecationWarning) > 0 {
+		return v.DeprecationWarning
+	}
+	gv := d.Spec.Group + "/" + v.Name
+	if !v.Served {
+		return fmt.Sprintf("%s %s is not served by the CustomResourceDefinition %s.", gv, d.Spec.Names.Kind, d.Metadata.Name)
+	}
+	return fmt.Sprintf("%s %s is deprecated by the CustomResourceDefinition %s.", gv, d.Spec.Names.Kind, d.Metadata.Name)
+}
@@ -0,0 +1,112 @@
+package crd
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"k8s-outdated/kube"
+	"net/http"
+	"testing"
+)
+
+func apiNames(apis []*collector.OutdatedAPI) []string {
+	names := make([]string, 0, len(apis))
+	for _, a := range apis {
+		names = append(names, a.Gav.APIVersion()+" "+a.Gav.Kind)
+	}
+	return names
+}
+
+var fixtureAPIs = []string{"cert-manager.io/v1alpha2 Certificate", "cert-manager.io/v1beta1 Certificate", "networking.istio.io/v1alpha3 VirtualService"}
+
+func TestCollectLocalOutdatedAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "directory", paths: []string{"./testdata/fixture/crds"}, want: fixtureAPIs},
+		{name: "same definitions twice", paths: []string{"./testdata/fixture/crds", "./testdata/fixture/crds/cert-manager.yaml"}, want: fixtureAPIs},
+		{name: "list of definitions", paths: []string{"./testdata/fixture/crds/nested/operators.yaml"}, want: []string{"networking.istio.io/v1alpha3 VirtualService"}},
+		{name: "missing path", paths: []string{"./testdata/fixture/missing"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apis, err := NewLocalCustomResources(tt.paths...).CollectOutdatedAPI(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, apiNames(apis))
+		})
+	}
+}
+
+func TestCollectRecord(t *testing.T) {
+	apis, err := NewLocalCustomResources("./testdata/fixture/crds").CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	certificate := collector.Gvk{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	assert.Equal(t, &collector.OutdatedAPI{
+		Description:    "cert-manager.io/v1alpha2 Certificate is not served by the CustomResourceDefinition certificates.cert-manager.io.",
+		Source:         collector.SourceCRD,
+		Gav:            collector.Gvk{Group: "cert-manager.io", Version: "v1alpha2", Kind: "Certificate"},
+		Replacement:    certificate,
+		CustomResource: true,
+		Unserved:       true,
+	}, apis[0])
+	assert.Equal(t, "cert-manager.io/v1beta1 Certificate is deprecated, use cert-manager.io/v1 Certificate", apis[1].Description)
+	assert.False(t, apis[1].Unserved)
+	assert.True(t, apis[1].CustomResource)
+	assert.Equal(t, certificate, apis[1].Replacement)
+	// the storage version is deprecated, the replacement is the first served version
+	assert.Equal(t, collector.Gvk{Group: "networking.istio.io", Version: "v1beta1", Kind: "VirtualService"}, apis[2].Replacement)
+}
+
+func TestOutdatedVersions(t *testing.T) {
+	d := CustomResourceDefinition{}
+	d.Spec.Group, d.Spec.Names.Kind = "example.com", "Widget"
+	d.Spec.Versions = []Version{{Name: "v1", Served: true, Deprecated: true}}
+	apis := OutdatedVersions(d)
+	assert.Equal(t, []string{"example.com/v1 Widget"}, apiNames(apis))
+	assert.True(t, apis[0].Replacement.IsZero())
+	d.Spec.Versions = nil
+	d.Spec.Version = "v1"
+	assert.Empty(t, OutdatedVersions(d))
+}
+
+//fakeLister serve the definitions of a cluster
+type fakeLister struct {
+	items []map[string]interface{}
+	err   error
+}
+
+func (l fakeLister) List(group string, version string, resource string) ([]map[string]interface{}, error) {
+	if group != crdGroup || version != crdVersion || resource != crdResource {
+		return nil, errors.New("unexpected resource")
+	}
+	return l.items, l.err
+}
+
+func TestCollectClusterOutdatedAPI(t *testing.T) {
+	lister := fakeLister{items: []map[string]interface{}{{
+		"metadata": map[string]interface{}{"name": "widgets.example.com"},
+		"spec": map[string]interface{}{"group": "example.com", "names": map[string]interface{}{"kind": "Widget"}, "versions": []interface{}{
+			map[string]interface{}{"name": "v1beta1", "served": true, "deprecated": true},
+			map[string]interface{}{"name": "v1", "served": true, "storage": true},
+		}},
+	}}}
+	apis, err := NewClusterCustomResources(lister).CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"example.com/v1beta1 Widget"}, apiNames(apis))
+	assert.Equal(t, "v1", apis[0].Replacement.Version)
+
+	apis, err = NewClusterCustomResources(fakeLister{err: &kube.StatusError{Code: http.StatusNotFound}}).CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, apis)
+
+	_, err = NewClusterCustomResources(fakeLister{err: errors.New("forbidden")}).CollectOutdatedAPI(context.Background())
+	assert.EqualError(t, err, "forbidden")
+}
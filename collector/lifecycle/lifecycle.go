@@ -0,0 +1,421 @@
+//Package lifecycle collect the api lifecycle of the prerelease api versions from the code generated in k8s.io/api
+package lifecycle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"k8s-outdated/cache"
+	"k8s-outdated/collector"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	lifecycleFile = "zz_generated.prerelease-lifecycle.go"
+	docFile       = "doc.go"
+	groupNameTag  = "+groupName="
+
+	introducedFunc  = "APILifecycleIntroduced"
+	deprecatedFunc  = "APILifecycleDeprecated"
+	removedFunc     = "APILifecycleRemoved"
+	replacementFunc = "APILifecycleReplacement"
+
+	//DefaultRef k8s.io/api branch or tag the generated code is downloaded from
+	DefaultRef = "master"
+
+	// k8s.io/api as vendored in a kubernetes/kubernetes checkout
+	stagingDir = "staging/src/k8s.io/api"
+)
+
+//tarballURL k8s.io/api source archive, a variable so tests can point it at a fake server
+var tarballURL = "https://github.com/kubernetes/api/archive/%s.tar.gz"
+
+//PrereleaseLifecycle collector of the zz_generated.prerelease-lifecycle.go files of k8s.io/api
+type PrereleaseLifecycle struct {
+	localPath string
+	ref       string
+	cache     *cache.Cache
+	client    *collector.Client
+	progress  io.Writer
+}
+
+//NewPrereleaseLifecycle instansiate new PrereleaseLifecycle downloading the k8s.io/api source archive from github
+func NewPrereleaseLifecycle() *PrereleaseLifecycle {
+	return &PrereleaseLifecycle{}
+}
+
+//NewLocalPrereleaseLifecycle instansiate new PrereleaseLifecycle reading the generated code from a local path instead of github.
+//path may be a k8s.io/api checkout, a kubernetes/kubernetes checkout or a k8s.io/api .tar.gz source archive
+func NewLocalPrereleaseLifecycle(path string) *PrereleaseLifecycle {
+	return &PrereleaseLifecycle{localPath: path}
+}
+
+//NewCachedPrereleaseLifecycle instansiate new PrereleaseLifecycle keeping the downloaded source archive in c,
+//archives of tags are kept forever and archives of branches are revalidated on every run
+func NewCachedPrereleaseLifecycle(c *cache.Cache) *PrereleaseLifecycle {
+	return &PrereleaseLifecycle{cache: c}
+}
+
+//WithClient return a copy of the collector downloading through client instead of collector.DefaultClient
+func (pl PrereleaseLifecycle) WithClient(client *collector.Client) *PrereleaseLifecycle {
+	pl.client = client
+	return &pl
+}
+
+//WithRef return a copy of the collector downloading the k8s.io/api archive of a branch or tag (e.g. v0.28.4), default DefaultRef
+func (pl PrereleaseLifecycle) WithRef(ref string) *PrereleaseLifecycle {
+	pl.ref = ref
+	return &pl
+}
+
+//WithProgress return a copy of the collector reporting to w when a stale cached archive is read because it cannot be revalidated
+func (pl PrereleaseLifecycle) WithProgress(w io.Writer) *PrereleaseLifecycle {
+	pl.progress = w
+	return &pl
+}
+
+//Name return the source of the lifecycle apis
+func (pl PrereleaseLifecycle) Name() string {
+	return collector.SourceLifecycle
+}
+
+//Priority return the merge priority of the lifecycle, the highest as its versions are generated from the api source
+func (pl PrereleaseLifecycle) Priority() int {
+	return collector.PriorityLifecycle
+}
+
+//Collect collect the api lifecycle, the options are unused
+func (pl PrereleaseLifecycle) Collect(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+	return pl.CollectOutdatedAPI(ctx)
+}
+
+//CollectOutdatedAPI collect the lifecycle of every prerelease api that is deprecated or removed, the download is aborted when ctx is done
+func (pl PrereleaseLifecycle) CollectOutdatedAPI(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+	files, err := pl.sourceFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parsePackages(files)
+}
+
+//sourceFiles return the doc.go and lifecycle files keyed by slash separated path relative to the k8s.io/api root
+func (pl PrereleaseLifecycle) sourceFiles(ctx context.Context) (map[string][]byte, error) {
+	if len(pl.localPath) > 0 {
+		return pl.localSourceFiles()
+	}
+	ref := pl.ref
+	if len(ref) == 0 {
+		ref = DefaultRef
+	}
+	url := fmt.Sprintf(tarballURL, ref)
+	var (
+		data []byte
+		err  error
+	)
+	if pl.cache != nil {
+		// tags never change, branches move on
+		data, err = pl.cache.Fetch(ctx, url, "github/kubernetes-api/"+ref+".tar.gz", strings.HasPrefix(ref, "v"))
+		err = cache.ReportStale(pl.progress, err)
+	} else {
+		data, err = pl.httpClient().Get(ctx, url)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return readTarball(bytes.NewReader(data))
+}
+
+func (pl PrereleaseLifecycle) httpClient() *collector.Client {
+	if pl.client != nil {
+		return pl.client
+	}
+	return collector.DefaultClient()
+}
+
+func (pl PrereleaseLifecycle) localSourceFiles() (map[string][]byte, error) {
+	info, err := os.Stat(pl.localPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		f, err := os.Open(filepath.Clean(pl.localPath))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readTarball(f)
+	}
+	root := pl.localPath
+	// kubernetes/kubernetes checkout
+	if staging := filepath.Join(root, filepath.FromSlash(stagingDir)); isDir(staging) {
+		root = staging
+	}
+	return readDir(root)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+//readDir read the doc.go and lifecycle files of the api packages of a k8s.io/api checkout
+func readDir(root string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != lifecycleFile && d.Name() != docFile {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(filepath.Clean(p))
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+//readTarball read the doc.go and lifecycle files of a gzipped k8s.io/api source archive, dropping the archive top directory
+func readTarball(r io.Reader) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	files := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Base(header.Name)
+		if header.Typeflag != tar.TypeReg || (name != lifecycleFile && name != docFile) {
+			continue
+		}
+		parts := strings.SplitN(path.Clean(header.Name), "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[parts[1]] = data
+	}
+}
+
+//parsePackages parse the lifecycle file of every package directory, the group comes from the +groupName tag of its doc.go
+func parsePackages(files map[string][]byte) ([]*collector.OutdatedAPI, error) {
+	dirs := make([]string, 0)
+	for name := range files {
+		if path.Base(name) == lifecycleFile {
+			dirs = append(dirs, path.Dir(name))
+		}
+	}
+	sort.Strings(dirs)
+	apis := make([]*collector.OutdatedAPI, 0)
+	for _, dir := range dirs {
+		group, version := path.Dir(dir), path.Base(dir)
+		if doc, ok := files[path.Join(dir, docFile)]; ok {
+			if name, ok := groupName(doc); ok {
+				group = name
+			}
+		}
+		pkg, err := parseLifecycle(path.Join(dir, lifecycleFile), files[path.Join(dir, lifecycleFile)], group, version)
+		if err != nil {
+			return nil, err
+		}
+		apis = append(apis, pkg...)
+	}
+	return apis, nil
+}
+
+//groupName read the +groupName tag of a package doc.go, e.g. +groupName=batch
+func groupName(src []byte) (string, bool) {
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "//"))
+		if strings.HasPrefix(line, groupNameTag) {
+			return strings.TrimSpace(strings.TrimPrefix(line, groupNameTag)), true
+		}
+	}
+	return "", false
+}
+
+//parseLifecycle parse the APILifecycle methods of a generated lifecycle file into one api per kind,
+//kinds without a deprecated or removed version are skipped
+func parseLifecycle(filename string, src []byte, group string, version string) ([]*collector.OutdatedAPI, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	byKind := make(map[string]*collector.OutdatedAPI)
+	kinds := make([]string, 0)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Body == nil {
+			continue
+		}
+		kind := receiverType(fn.Recv.List[0].Type)
+		if len(kind) == 0 {
+			continue
+		}
+		api, ok := byKind[kind]
+		if !ok {
+			api = &collector.OutdatedAPI{Source: collector.SourceLifecycle, Gav: collector.Gvk{Group: group, Version: version, Kind: kind}}
+			byKind[kind] = api
+			kinds = append(kinds, kind)
+		}
+		results := returnValues(fn.Body)
+		switch fn.Name.Name {
+		case introducedFunc:
+			api.Introduced, err = releaseVersion(results)
+		case deprecatedFunc:
+			api.Deprecated, err = releaseVersion(results)
+		case removedFunc:
+			api.Removed, err = releaseVersion(results)
+		case replacementFunc:
+			api.Replacement, err = groupVersionKind(results)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s.%s: %w", filename, kind, fn.Name.Name, err)
+		}
+	}
+	apis := make([]*collector.OutdatedAPI, 0, len(kinds))
+	for _, kind := range kinds {
+		api := byKind[kind]
+		if api.Deprecated.IsZero() && api.Removed.IsZero() {
+			continue
+		}
+		// list types share the lifecycle of their item kind
+		if item, ok := byKind[strings.TrimSuffix(kind, "List")]; ok && item != api {
+			continue
+		}
+		api.Description = lifecycleDescription(api)
+		apis = append(apis, api)
+	}
+	return apis, nil
+}
+
+func receiverType(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+//returnValues return the results of the first return statement of a method body
+func returnValues(body *ast.BlockStmt) []ast.Expr {
+	for _, stmt := range body.List {
+		if ret, ok := stmt.(*ast.ReturnStmt); ok {
+			return ret.Results
+		}
+	}
+	return nil
+}
+
+//releaseVersion convert the returned major and minor ints to a release version, e.g. v1.25
+func releaseVersion(results []ast.Expr) (collector.KubeVersion, error) {
+	if len(results) != 2 {
+		return collector.KubeVersion{}, fmt.Errorf("expected major and minor version, got %d values", len(results))
+	}
+	parts := make([]string, 0, 2)
+	for _, r := range results {
+		lit, ok := r.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return collector.KubeVersion{}, fmt.Errorf("version is not an int literal")
+		}
+		parts = append(parts, lit.Value)
+	}
+	return collector.ParseKubeVersion(strings.Join(parts, "."))
+}
+
+//groupVersionKind read the returned schema.GroupVersionKind composite literal
+func groupVersionKind(results []ast.Expr) (collector.Gvk, error) {
+	if len(results) != 1 {
+		return collector.Gvk{}, fmt.Errorf("expected a group version kind, got %d values", len(results))
+	}
+	lit, ok := results[0].(*ast.CompositeLit)
+	if !ok {
+		return collector.Gvk{}, fmt.Errorf("group version kind is not a composite literal")
+	}
+	var gvk collector.Gvk
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		value, ok := kv.Value.(*ast.BasicLit)
+		if !ok || value.Kind != token.STRING {
+			continue
+		}
+		s, err := strconv.Unquote(value.Value)
+		if err != nil {
+			return collector.Gvk{}, err
+		}
+		switch key.Name {
+		case "Group":
+			gvk.Group = s
+		case "Version":
+			gvk.Version = s
+		case "Kind":
+			gvk.Kind = s
+		}
+	}
+	return gvk, nil
+}
+
+func lifecycleDescription(api *collector.OutdatedAPI) string {
+	parts := make([]string, 0, 4)
+	if !api.Introduced.IsZero() {
+		parts = append(parts, "introduced in "+api.Introduced.String())
+	}
+	if !api.Deprecated.IsZero() {
+		parts = append(parts, "deprecated in "+api.Deprecated.String())
+	}
+	if !api.Removed.IsZero() {
+		parts = append(parts, "removed in "+api.Removed.String())
+	}
+	description := fmt.Sprintf("%s %s is %s", api.Gav.APIVersion(), api.Gav.Kind, strings.Join(parts, ", "))
+	if !api.Replacement.IsZero() {
+		description += ", use " + api.Replacement.String() + " instead"
+	}
+	return description + "."
+}
@@ -0,0 +1,185 @@
+package lifecycle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/cache"
+	"k8s-outdated/collector"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//tarball pack the fixture k8s.io/api checkout the way github archives it, under an api-<ref> top directory
+func tarball(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	root := "./testdata/fixture/api"
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: "api-master/" + filepath.ToSlash(rel), Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func apiNames(apis []*collector.OutdatedAPI) []string {
+	names := make([]string, 0, len(apis))
+	for _, a := range apis {
+		names = append(names, a.Gav.APIVersion()+" "+a.Gav.Kind)
+	}
+	return names
+}
+
+var fixtureAPIs = []string{"batch/v1beta1 CronJob", "batch/v1beta1 JobTemplate",
+	"flowcontrol.apiserver.k8s.io/v1beta2 FlowSchema", "flowcontrol.apiserver.k8s.io/v1beta2 PriorityLevelConfiguration"}
+
+func TestCollectLocalOutdatedAPI(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "api.tar.gz")
+	assert.NoError(t, os.WriteFile(archive, tarball(t), 0600))
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "k8s.io/api checkout", path: "./testdata/fixture/api", want: fixtureAPIs},
+		{name: "kubernetes checkout", path: "./testdata/fixture/kubernetes", want: []string{"policy/v1beta1 PodDisruptionBudget"}},
+		{name: "source archive", path: archive, want: fixtureAPIs},
+		{name: "not an archive", path: "./testdata/fixture/api/batch/v1beta1/doc.go", wantErr: true},
+		{name: "missing path", path: "./testdata/fixture/missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apis, err := NewLocalPrereleaseLifecycle(tt.path).CollectOutdatedAPI(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, apiNames(apis))
+		})
+	}
+}
+
+func TestCollectLifecycleRecord(t *testing.T) {
+	apis, err := NewLocalPrereleaseLifecycle("./testdata/fixture/api").CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &collector.OutdatedAPI{
+		Description: "batch/v1beta1 CronJob is introduced in v1.8, deprecated in v1.21, removed in v1.25, use batch/v1 CronJob instead.",
+		Introduced:  collector.MustParseKubeVersion("v1.8"),
+		Deprecated:  collector.MustParseKubeVersion("v1.21"),
+		Removed:     collector.MustParseKubeVersion("v1.25"),
+		Source:      collector.SourceLifecycle,
+		Gav:         collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"},
+		Replacement: collector.Gvk{Group: "batch", Version: "v1", Kind: "CronJob"},
+	}, apis[0])
+	assert.True(t, apis[1].Replacement.IsZero())
+}
+
+func TestParseLifecycle(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "kind without deprecation", src: "package v1\nfunc (in *Pod) APILifecycleIntroduced() (major, minor int) {\n\treturn 1, 0\n}\n"},
+		{name: "removed only", src: "package v1\nfunc (in *Pod) APILifecycleRemoved() (major, minor int) {\n\treturn 1, 22\n}\n", want: []string{"v1 Pod"}},
+		{name: "non literal version", src: "package v1\nfunc (in *Pod) APILifecycleRemoved() (major, minor int) {\n\treturn major, 22\n}\n", wantErr: true},
+		{name: "invalid go", src: "package v1\nfunc (", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apis, err := parseLifecycle(lifecycleFile, []byte(tt.src), "", "v1")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.want), len(apis))
+			if len(tt.want) > 0 {
+				assert.Equal(t, tt.want, apiNames(apis))
+			}
+		})
+	}
+}
+
+func TestGroupName(t *testing.T) {
+	name, ok := groupName([]byte("// +k8s:openapi-gen=true\n\n// +groupName=batch\n\npackage v1"))
+	assert.True(t, ok)
+	assert.Equal(t, "batch", name)
+	name, ok = groupName([]byte("// +groupName=\npackage v1"))
+	assert.True(t, ok)
+	assert.Equal(t, "", name)
+	_, ok = groupName([]byte("package v1"))
+	assert.False(t, ok)
+}
+
+func TestCollectOutdatedAPI(t *testing.T) {
+	archive := tarball(t)
+	requested, fail := make([]string, 0), false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if fail {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	defer srv.Close()
+	original := tarballURL
+	tarballURL = srv.URL + "/%s.tar.gz"
+	defer func() { tarballURL = original }()
+	client, err := collector.NewClient(collector.ClientOptions{})
+	assert.NoError(t, err)
+
+	apis, err := NewPrereleaseLifecycle().WithClient(client).CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, fixtureAPIs, apiNames(apis))
+
+	downloads := cache.NewCache(t.TempDir(), false).WithClient(client)
+	for i := 0; i < 2; i++ {
+		apis, err = NewCachedPrereleaseLifecycle(downloads).WithClient(client).WithRef("v0.28.4").CollectOutdatedAPI(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, fixtureAPIs, apiNames(apis))
+	}
+	// the archive of a tag is downloaded once
+	assert.Equal(t, []string{"/master.tar.gz", "/v0.28.4.tar.gz"}, requested)
+	entries, err := downloads.Entries()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(entries[0].Key, "github/kubernetes-api/v0.28.4"))
+
+	// the archive of a branch is revalidated, a failure reads the stale archive with a warning
+	_, err = NewCachedPrereleaseLifecycle(downloads).WithClient(client).WithRef("main").CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	fail = true
+	var progress bytes.Buffer
+	apis, err = NewCachedPrereleaseLifecycle(downloads).WithClient(client).WithRef("main").WithProgress(&progress).CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, fixtureAPIs, apiNames(apis))
+	assert.Contains(t, progress.String(), "warning: using github/kubernetes-api/main.tar.gz cached ")
+}
@@ -0,0 +1,15 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+//+k8s:deepcopy-gen=package
+//+k8s:protobuf-gen=package
+//+k8s:openapi-gen=true
+//+k8s:prerelease-lifecycle-gen=true
+
+//+groupName=
+
+package v1 // import "k8s.io/api/core/v1"
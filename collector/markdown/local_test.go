@@ -0,0 +1,53 @@
+package markdown
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollectLocalOutdatedAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "guide file", path: "./testdata/fixture/deprecation-guide.md", want: []string{
+			"flowcontrol.apiserver.k8s.io/v1beta1/FlowSchema", "flowcontrol.apiserver.k8s.io/v1beta1/PriorityLevelConfiguration", "batch/v1beta1/CronJob",
+			"admissionregistration.k8s.io/v1beta1/MutatingWebhookConfiguration", "admissionregistration.k8s.io/v1beta1/ValidatingWebhookConfiguration",
+			"extensions/v1beta1/Ingress", "networking.k8s.io/v1beta1/Ingress"}},
+		{name: "website checkout", path: "./testdata/fixture/website", want: []string{
+			"flowcontrol.apiserver.k8s.io/v1beta1/FlowSchema", "flowcontrol.apiserver.k8s.io/v1beta1/PriorityLevelConfiguration", "batch/v1beta1/CronJob",
+			"admissionregistration.k8s.io/v1beta1/MutatingWebhookConfiguration", "admissionregistration.k8s.io/v1beta1/ValidatingWebhookConfiguration",
+			"extensions/v1beta1/Ingress", "networking.k8s.io/v1beta1/Ingress"}},
+		{name: "missing path", path: "./testdata/fixture/missing.md", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sObj, err := NewLocalDeprecationGuide(tt.path).CollectOutdatedAPI(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			got := make([]string, 0)
+			for _, obj := range k8sObj {
+				got = append(got, obj.Gav.Group+"/"+obj.Gav.Version+"/"+obj.Gav.Kind)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCollectReplacement(t *testing.T) {
+	k8sObj, err := NewLocalDeprecationGuide("./testdata/fixture/deprecation-guide.md").CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	got := make([]string, 0)
+	for _, obj := range k8sObj {
+		got = append(got, obj.Replacement.String())
+	}
+	assert.Equal(t, []string{"flowcontrol.apiserver.k8s.io/v1beta2 FlowSchema", "flowcontrol.apiserver.k8s.io/v1beta2 PriorityLevelConfiguration", "batch/v1 CronJob",
+		"admissionregistration.k8s.io/v1 MutatingWebhookConfiguration", "admissionregistration.k8s.io/v1 ValidatingWebhookConfiguration",
+		"networking.k8s.io/v1 Ingress", "networking.k8s.io/v1 Ingress"}, got)
+}
@@ -1,29 +1,58 @@
 package markdown
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
 	"io"
+	"k8s-outdated/cache"
 	"k8s-outdated/collector"
-	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 )
 
 const (
 	willNoLongerBeServed = "will no longer be served in"
 	isNoLongerServedAsOf = "is no longer served as of"
-	apiVersionOf         = "API version of"
-	apiVersionsOf        = "API versions of"
-	apiVersions          = "API version"
-	theUpper             = "The"
-	theLower             = "the"
-	in                   = "in"
-	and                  = "and"
-
-	depGuide = "https://raw.githubusercontent.com/kubernetes/website/main/content/en/docs/reference/using-api/deprecation-guide.md"
+	notableChanges       = "notable changes"
+	noNotableChanges     = "no notable changes"
+
+	//DefaultRef kubernetes/website branch, tag or commit the guide is downloaded from
+	DefaultRef = "main"
+
+	depGuideFile = "content/en/docs/reference/using-api/deprecation-guide.md"
+
+	depGuideCacheKey = "website/deprecation-guide.md"
+
+	guidePage = "https://kubernetes.io/docs/reference/using-api/deprecation-guide/"
+)
+
+var (
+	// depGuideURL raw github url of the guide at a ref, a variable so tests can point it at a fake server
+	depGuideURL = "https://raw.githubusercontent.com/kubernetes/website/%s/" + depGuideFile
+	// commitRe match a full commit hash, the guide of a commit never changes
+	commitRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	// releaseRe match the headings naming a release, e.g. v1.22, the ones which do not parse are reported
+	releaseRe = regexp.MustCompile(`^v\d`)
+	// kindsRe capture the kinds clause of "API version(s) of A, B and C is/are/will ..."
+	kindsRe = regexp.MustCompile(`API versions? of (.+?) (?:is|are|will)\b`)
+	kindRe  = regexp.MustCompile(`\b[A-Z][A-Za-z0-9]*\b`)
 )
 
 //DeprecationGuide object
 type DeprecationGuide struct {
+	localPath string
+	ref       string
+	cache     *cache.Cache
+	client    *collector.Client
+	warn      collector.WarningFunc
+	progress  io.Writer
 }
 
 //NewDeprecationGuide instansiate new DeprecationGuide
@@ -31,117 +60,276 @@ func NewDeprecationGuide() *DeprecationGuide {
 	return &DeprecationGuide{}
 }
 
-//CollectOutdatedAPI collect removed api version from k8s deprecation guide
-func (vz DeprecationGuide) CollectOutdatedAPI() ([]*collector.OutdatedAPI, error) {
-	res, err := http.Get(depGuide)
+//NewLocalDeprecationGuide instansiate new DeprecationGuide reading the guide from a local path instead of github.
+//path may be the deprecation-guide.md file or a kubernetes/website checkout
+func NewLocalDeprecationGuide(path string) *DeprecationGuide {
+	return &DeprecationGuide{localPath: path}
+}
+
+//NewCachedDeprecationGuide instansiate new DeprecationGuide keeping the downloaded guide in c, revalidated on every run
+func NewCachedDeprecationGuide(c *cache.Cache) *DeprecationGuide {
+	return &DeprecationGuide{cache: c}
+}
+
+//WithClient return a copy of the guide downloading through client instead of collector.DefaultClient
+func (vz DeprecationGuide) WithClient(client *collector.Client) *DeprecationGuide {
+	vz.client = client
+	return &vz
+}
+
+//WithRef return a copy of the guide downloaded from a kubernetes/website branch, tag or commit, default DefaultRef
+func (vz DeprecationGuide) WithRef(ref string) *DeprecationGuide {
+	vz.ref = ref
+	return &vz
+}
+
+//WithWarnings return a copy of the guide reporting the release headings it cannot parse to warn instead of skipping them silently
+func (vz DeprecationGuide) WithWarnings(warn collector.WarningFunc) *DeprecationGuide {
+	vz.warn = warn
+	return &vz
+}
+
+//WithProgress return a copy of the guide reporting to w when a stale cached guide is read because it cannot be revalidated
+func (vz DeprecationGuide) WithProgress(w io.Writer) *DeprecationGuide {
+	vz.progress = w
+	return &vz
+}
+
+//Name return the source of the guide apis
+func (vz DeprecationGuide) Name() string {
+	return collector.SourceMarkdown
+}
+
+//Priority return the merge priority of the guide, its removed versions win over the swagger api ones
+func (vz DeprecationGuide) Priority() int {
+	return collector.PriorityMarkdown
+}
+
+//Collect collect the apis the guide removes, the options are unused
+func (vz DeprecationGuide) Collect(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+	return vz.CollectOutdatedAPI(ctx)
+}
+
+//CollectOutdatedAPI collect removed api version from k8s deprecation guide, the download is aborted when ctx is done
+func (vz DeprecationGuide) CollectOutdatedAPI(ctx context.Context) ([]*collector.OutdatedAPI, error) {
+	if len(vz.localPath) > 0 {
+		return vz.collectLocalOutdatedAPI()
+	}
+	ref, key := vz.ref, depGuideCacheKey
+	if len(ref) == 0 {
+		ref = DefaultRef
+	}
+	if ref != DefaultRef {
+		key = "website/" + ref + "/deprecation-guide.md"
+	}
+	url := fmt.Sprintf(depGuideURL, ref)
+	if vz.cache != nil {
+		data, err := vz.cache.Fetch(ctx, url, key, commitRe.MatchString(ref))
+		if err = cache.ReportStale(vz.progress, err); err != nil {
+			return nil, err
+		}
+		return vz.markdownToObject(bytes.NewReader(data))
+	}
+	client := vz.client
+	if client == nil {
+		client = collector.DefaultClient()
+	}
+	data, err := client.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return vz.markdownToObject(bytes.NewReader(data))
+}
+
+func (vz DeprecationGuide) collectLocalOutdatedAPI() ([]*collector.OutdatedAPI, error) {
+	path := vz.localPath
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	// kubernetes/website checkout
+	if info.IsDir() {
+		path = filepath.Join(path, filepath.FromSlash(depGuideFile))
+	}
+	f, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return nil, err
 	}
-	return vz.markdownToObject(res.Body)
+	defer f.Close()
+	return vz.markdownToObject(f)
 }
 
+//markdownToObject parse the deprecation guide: every "#### Kind {#anchor}" section under a "### vX.Y" release heading
+//names the api versions removed in that release, and its bullets how to migrate off them
 func (vz DeprecationGuide) markdownToObject(markdownReader io.Reader) ([]*collector.OutdatedAPI, error) {
+	source, err := io.ReadAll(markdownReader)
+	if err != nil {
+		return nil, err
+	}
+	doc := goldmark.New(goldmark.WithParserOptions(parser.WithHeadingAttribute())).Parser().Parse(text.NewReader(source))
 	k8sObjects := make([]*collector.OutdatedAPI, 0)
-	scanner := bufio.NewScanner(markdownReader)
-	scanner.Split(bufio.ScanLines)
-	var currentVersion string
-	k8sAPIs := make(map[string][]string)
-	for scanner.Scan() {
-		line := scanner.Text()
-		lineWithoutSpace := strings.TrimSpace(line)
-		if len(lineWithoutSpace) == 0 {
-			continue
-		}
-		if strings.Contains(line, "### v1.") {
-			currentVersion = strings.Replace(lineWithoutSpace, "###", "", -1)
-			if _, ok := k8sAPIs[currentVersion]; !ok {
-				k8sAPIs[currentVersion] = []string{}
+	var (
+		release collector.KubeVersion
+		current *section
+	)
+	for node := doc.FirstChild(); node != nil; node = node.NextSibling() {
+		switch n := node.(type) {
+		case *ast.Heading:
+			current.finish()
+			current = nil
+			switch {
+			case n.Level <= 2:
+				release = collector.KubeVersion{}
+			case n.Level == 3:
+				release = vz.releaseHeading(string(n.Text(source)))
+			case n.Level == 4 && !release.IsZero():
+				current = newSection(n, source)
 			}
-			continue
-		}
-		if _, ok := k8sAPIs[currentVersion]; ok {
-			if strings.Contains(line, willNoLongerBeServed) || strings.Contains(line, isNoLongerServedAsOf) {
-				removedVersion := findVersion(line, []string{willNoLongerBeServed, isNoLongerServedAsOf})
-				if len(removedVersion) == 0 {
-					continue
+		case *ast.Paragraph:
+			if release.IsZero() {
+				continue
+			}
+			objs := removedAPIs(n, source, release)
+			if current != nil {
+				current.objects = append(current.objects, objs...)
+				for _, obj := range objs {
+					obj.Migration = current.migration
 				}
-				k8sObjects = vz.createAPIObject(line, k8sObjects, removedVersion)
-				k8sAPIs[removedVersion] = append(k8sAPIs[removedVersion], line)
+			}
+			k8sObjects = append(k8sObjects, objs...)
+		case *ast.List:
+			if current != nil {
+				current.addBullets(n, source)
 			}
 		}
 	}
+	current.finish()
 	return k8sObjects, nil
 }
 
-func (vz DeprecationGuide) createAPIObject(line string, k8sObjects []*collector.OutdatedAPI, removedVersion string) []*collector.OutdatedAPI {
-	groups := findResourcesGroups([]string{theUpper}, []string{apiVersionOf, apiVersionsOf, apiVersions}, line, []string{"**"})
-	var resources []string
-	if strings.HasPrefix(line, theLower) || strings.HasPrefix(line, theUpper) {
-		resources = findResourcesGroups([]string{apiVersionOf, apiVersionsOf}, []string{willNoLongerBeServed, isNoLongerServedAsOf}, line, []string{",", and, theLower})
-	} else {
-		resources = findResourcesGroups([]string{}, []string{in}, line, []string{",", and, theLower})
+//section "#### Kind {#anchor}" part of a release of the guide
+type section struct {
+	migration *collector.Migration
+	objects   []*collector.OutdatedAPI
+}
+
+func newSection(heading *ast.Heading, source []byte) *section {
+	migration := &collector.Migration{Title: strings.TrimSpace(string(heading.Text(source)))}
+	if id, ok := heading.AttributeString("id"); ok {
+		if anchor, ok := id.([]byte); ok {
+			migration.Anchor = string(anchor)
+			migration.URL = guidePage + "#" + migration.Anchor
+		}
 	}
-	for _, api := range groups {
-		apiParts := strings.Split(api, "/")
-		if len(apiParts) == 2 {
-			for _, res := range resources {
-				k8sObjects = append(k8sObjects, &collector.OutdatedAPI{Description: line, Removed: removedVersion, Gav: collector.Gvk{Group: apiParts[0], Version: apiParts[1], Kind: res}})
+	return &section{migration: migration}
+}
+
+//addBullets record the migration steps and notable changes of a section bullet list
+func (s *section) addBullets(list *ast.List, source []byte) {
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		bullet := blockText(item.FirstChild(), source)
+		lower := strings.ToLower(bullet)
+		switch {
+		case len(bullet) == 0 || strings.HasPrefix(lower, noNotableChanges):
+		case strings.HasPrefix(lower, notableChanges):
+			for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+				if nested, ok := child.(*ast.List); ok {
+					for change := nested.FirstChild(); change != nil; change = change.NextSibling() {
+						if text := blockText(change.FirstChild(), source); len(text) > 0 {
+							s.migration.NotableChanges = append(s.migration.NotableChanges, text)
+						}
+					}
+				}
 			}
+		default:
+			s.migration.Steps = append(s.migration.Steps, bullet)
 		}
 	}
-	return k8sObjects
 }
 
-func findVersion(line string, keyWords []string) string {
-	var partLine string
-	for _, keyWord := range keyWords {
-		partLine = collector.FindRemovedDeprecatedVersion(strings.ToLower(line), keyWord)
-		if strings.HasPrefix(partLine, "v1.") {
-			return partLine
-		}
+//finish set the replacement of the section apis from its migration steps
+func (s *section) finish() {
+	if s == nil {
+		return
 	}
-	return ""
-}
-
-func findResourcesGroups(beginWords []string, endWords []string, line string, removedSigns []string) []string {
-	resources := make([]string, 0)
-	var beginWord string
-	var beginIndex = -1
-	var endIndex int
-	for _, b := range beginWords {
-		beginIndex = strings.Index(line, b)
-		if beginIndex == -1 {
-			continue
-		} else {
-			beginWord = b
-			break
-		}
+	for _, step := range s.migration.Steps {
+		setReplacement(s.objects, step)
 	}
-	for _, e := range endWords {
-		endIndex = strings.Index(line, e)
-		if endIndex == -1 {
-			continue
-		} else {
-			break
-		}
+}
+
+//releaseHeading return the release of a "### vX.Y" heading, unknown for other headings and for release headings which
+//do not parse, the latter are reported to warn so their apis are not silently dropped
+func (vz DeprecationGuide) releaseHeading(heading string) collector.KubeVersion {
+	heading = strings.TrimSpace(heading)
+	if !releaseRe.MatchString(heading) {
+		return collector.KubeVersion{}
+	}
+	release, err := collector.ParseKubeVersion(heading)
+	if err != nil {
+		vz.warn.Warn(collector.Warning{Source: collector.SourceMarkdown, Field: "release heading", Value: heading, Err: err})
+	}
+	return release
+}
+
+//removedAPIs parse a "The **group/version** API version of Kind will no longer be served in vX.Y." paragraph,
+//the api versions are the strong group/version spans and the kinds the identifiers between "of" and the verb
+func removedAPIs(paragraph *ast.Paragraph, source []byte, release collector.KubeVersion) []*collector.OutdatedAPI {
+	description := blockText(paragraph, source)
+	lower := strings.ToLower(description)
+	if !strings.Contains(lower, willNoLongerBeServed) && !strings.Contains(lower, isNoLongerServedAsOf) {
+		return nil
+	}
+	match := kindsRe.FindStringSubmatch(description)
+	if match == nil {
+		return nil
 	}
-	if beginIndex == -1 {
-		beginIndex = 0
+	kinds := kindRe.FindAllString(match[1], -1)
+	objs := make([]*collector.OutdatedAPI, 0)
+	for _, gv := range strongGroupVersions(paragraph, source) {
+		for _, kind := range kinds {
+			objs = append(objs, &collector.OutdatedAPI{Description: description, Removed: release, Source: collector.SourceMarkdown,
+				Gav: collector.Gvk{Group: gv[0], Version: gv[1], Kind: kind}})
+		}
 	}
-	resourceLine := line[beginIndex+len(beginWord) : endIndex]
-	splitResource := strings.Split(resourceLine, " ")
-	for _, r := range splitResource {
-		if len(strings.TrimSpace(r)) == 0 {
-			continue
+	return objs
+}
+
+//strongGroupVersions return the group and version of every **group/version** span of a paragraph
+func strongGroupVersions(paragraph ast.Node, source []byte) [][2]string {
+	gvs := make([][2]string, 0)
+	_ = ast.Walk(paragraph, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		emphasis, ok := n.(*ast.Emphasis)
+		if !entering || !ok || emphasis.Level != 2 {
+			return ast.WalkContinue, nil
 		}
-		for _, sign := range removedSigns {
-			r = strings.Replace(r, sign, " ", -1)
+		parts := strings.Split(strings.TrimSpace(string(emphasis.Text(source))), "/")
+		if len(parts) == 2 && len(parts[0]) > 0 && len(parts[1]) > 0 {
+			gvs = append(gvs, [2]string{parts[0], parts[1]})
 		}
-		r = strings.TrimSpace(r)
-		if len(r) == 0 {
-			continue
+		return ast.WalkSkipChildren, nil
+	})
+	return gvs
+}
+
+//blockText return the markdown source of a paragraph or text block, lines joined by a space
+func blockText(node ast.Node, source []byte) string {
+	if node == nil || node.Type() != ast.TypeBlock {
+		return ""
+	}
+	lines := node.Lines()
+	parts := make([]string, 0, lines.Len())
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		parts = append(parts, strings.TrimSpace(string(segment.Value(source))))
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+//setReplacement set the replacement api of objects from a section migration bullet
+func setReplacement(objects []*collector.OutdatedAPI, line string) {
+	for _, obj := range objects {
+		if replacement, ok := collector.FindReplacementAPI(line, obj.Gav.Kind); ok && len(obj.Replacement.Version) == 0 {
+			obj.Replacement = replacement
 		}
-		resources = append(resources, strings.TrimSpace(r))
 	}
-	return resources
 }
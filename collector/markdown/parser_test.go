@@ -1,8 +1,13 @@
 package markdown
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"k8s-outdated/cache"
 	"k8s-outdated/collector"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 )
@@ -13,19 +18,21 @@ func TestMapResources(t *testing.T) {
 		K8sObject    []collector.OutdatedAPI
 		markDownLine string
 	}{
-		{name: "line #1 ", K8sObject: []collector.OutdatedAPI{{Removed: "v1.27", Gav: collector.Gvk{Version: "v1beta1", Group: "storage.k8s.io", Kind: "CSIStorageCapacity"}}}, markDownLine: "### v1.27\n\nThe **v1.27** release will stop serving theUpper following deprecated API versions:\n\n#### CSIStorageCapacity {#csistoragecapacity-v127}\n\nThe **storage.k8s.io/v1beta1** API version of CSIStorageCapacity will no longer be served in v1.27."},
-		{name: "line #2 ", K8sObject: []collector.OutdatedAPI{{Removed: "v1.26", Gav: collector.Gvk{Version: "v1beta1", Group: "flowcontrol.apiserver.k8s.io", Kind: "FlowSchema"}},
-			{Removed: "v1.26", Gav: collector.Gvk{Version: "v1beta1", Group: "flowcontrol.apiserver.k8s.io", Kind: "PriorityLevelConfiguration"}}}, markDownLine: "### v1.26\n\nThe **v1.26** release will stop serving theUpper following deprecated API versions:\n\n#### Flow control resources {#flowcontrol-resources-v126}\n\nThe **flowcontrol.apiserver.k8s.io/v1beta1** API version of FlowSchema and PriorityLevelConfiguration will no longer be served in v1.26."},
-		{name: "line #3 ", K8sObject: []collector.OutdatedAPI{{Removed: "v1.25", Gav: collector.Gvk{Version: "v1beta1", Group: "batch", Kind: "CronJob"}}}, markDownLine: "### v1.25\n\nThe **v1.25** release will stop serving theUpper following deprecated API versions:\n\n#### CronJob {#cronjob-v125}\n\nThe **batch/v1beta1** API version of CronJob will no longer be served in v1.25."},
-		{name: "line #4 ", K8sObject: []collector.OutdatedAPI{{Removed: "v1.25", Gav: collector.Gvk{Version: "v2beta1", Group: "autoscaling", Kind: "HorizontalPodAutoscaler"}}}, markDownLine: "### v1.25\n\n#### HorizontalPodAutoscaler {#horizontalpodautoscaler-v125}\n\nThe **autoscaling/v2beta1** API version of HorizontalPodAutoscaler will no longer be served in v1.25."},
-		{name: "line #5 ", K8sObject: []collector.OutdatedAPI{{Removed: "v1.22", Gav: collector.Gvk{Version: "v1beta1", Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}},
-			{Removed: "v1.22", Gav: collector.Gvk{Version: "v1beta1", Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}}}, markDownLine: "### v1.22\n\nThe **v1.22** release stopped serving theUpper following deprecated API versions:\n\n#### Webhook resources {#webhook-resources-v122}\n\nThe **admissionregistration.k8s.io/v1beta1** API version of MutatingWebhookConfiguration and ValidatingWebhookConfiguration is no longer served as of v1.22."},
-		{name: "line #6 ", K8sObject: []collector.OutdatedAPI{{Removed: "v1.25", Gav: collector.Gvk{Version: "v2beta1", Group: "node.k8s.io", Kind: "RuntimeClass"}}}, markDownLine: "\t\t{name: \"line #4 \", OutdatedAPI: []collector.OutdatedAPI{{Removed: \"v1.25\", Gvk: collector.Gvk{Version: \"v2beta1\", Group: \"autoscaling\", Kind: \"HorizontalPodAutoscaler\"}}}, markDownLine: \"### v1.25\\n\\n#### HorizontalPodAutoscaler {#horizontalpodautoscaler-v125}\\n\\nThe **autoscaling/v2beta1** API version of HorizontalPodAutoscaler will no longer be served in v1.25.\"},\n"},
+		{name: "line #1 ", K8sObject: []collector.OutdatedAPI{{Removed: collector.MustParseKubeVersion("v1.27"), Gav: collector.Gvk{Version: "v1beta1", Group: "storage.k8s.io", Kind: "CSIStorageCapacity"}}}, markDownLine: "### v1.27\n\nThe **v1.27** release will stop serving the following deprecated API versions:\n\n#### CSIStorageCapacity {#csistoragecapacity-v127}\n\nThe **storage.k8s.io/v1beta1** API version of CSIStorageCapacity will no longer be served in v1.27."},
+		{name: "line #2 ", K8sObject: []collector.OutdatedAPI{{Removed: collector.MustParseKubeVersion("v1.26"), Gav: collector.Gvk{Version: "v1beta1", Group: "flowcontrol.apiserver.k8s.io", Kind: "FlowSchema"}},
+			{Removed: collector.MustParseKubeVersion("v1.26"), Gav: collector.Gvk{Version: "v1beta1", Group: "flowcontrol.apiserver.k8s.io", Kind: "PriorityLevelConfiguration"}}}, markDownLine: "### v1.26\n\nThe **v1.26** release will stop serving the following deprecated API versions:\n\n#### Flow control resources {#flowcontrol-resources-v126}\n\nThe **flowcontrol.apiserver.k8s.io/v1beta1** API version of FlowSchema and PriorityLevelConfiguration will no longer be served in v1.26."},
+		{name: "line #3 ", K8sObject: []collector.OutdatedAPI{{Removed: collector.MustParseKubeVersion("v1.25"), Gav: collector.Gvk{Version: "v1beta1", Group: "batch", Kind: "CronJob"}}}, markDownLine: "### v1.25\n\nThe **v1.25** release will stop serving the following deprecated API versions:\n\n#### CronJob {#cronjob-v125}\n\nThe **batch/v1beta1** API version of CronJob will no longer be served in v1.25."},
+		{name: "line #4 ", K8sObject: []collector.OutdatedAPI{{Removed: collector.MustParseKubeVersion("v1.25"), Gav: collector.Gvk{Version: "v2beta1", Group: "autoscaling", Kind: "HorizontalPodAutoscaler"}}}, markDownLine: "### v1.25\n\n#### HorizontalPodAutoscaler {#horizontalpodautoscaler-v125}\n\nThe **autoscaling/v2beta1** API version of HorizontalPodAutoscaler will no longer be served in v1.25."},
+		{name: "line #5 ", K8sObject: []collector.OutdatedAPI{{Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Version: "v1beta1", Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}},
+			{Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Version: "v1beta1", Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}}}, markDownLine: "### v1.22\n\nThe **v1.22** release stopped serving the following deprecated API versions:\n\n#### Webhook resources {#webhook-resources-v122}\n\nThe **admissionregistration.k8s.io/v1beta1** API version of MutatingWebhookConfiguration and ValidatingWebhookConfiguration is no longer served as of v1.22."},
+		{name: "line #6 ", markDownLine: "### v1.25\n\n#### RuntimeClass {#runtimeclass-v125}\n\nRuntimeClass in the **node.k8s.io/v1beta1** API version is deprecated."},
+		{name: "line #7 ", markDownLine: "## Removed APIs\n\nThe **batch/v1beta1** API version of CronJob will no longer be served in v1.25."},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			k8sObj, err := NewDeprecationGuide().markdownToObject(strings.NewReader(tt.markDownLine))
 			assert.NoError(t, err)
+			assert.Equal(t, len(tt.K8sObject), len(k8sObj))
 			for index, obj := range k8sObj {
 				assert.Equal(t, obj.Gav.Version, tt.K8sObject[index].Gav.Version)
 				assert.Equal(t, obj.Gav.Group, tt.K8sObject[index].Gav.Group)
@@ -35,3 +42,76 @@ func TestMapResources(t *testing.T) {
 		})
 	}
 }
+
+func TestMigration(t *testing.T) {
+	k8sObj, err := NewLocalDeprecationGuide("./testdata/fixture/deprecation-guide.md").CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	ingress := k8sObj[len(k8sObj)-1]
+	assert.Equal(t, collector.Gvk{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}, ingress.Gav)
+	assert.Equal(t, "v1.22", ingress.Removed.String())
+	assert.Equal(t, collector.Gvk{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, ingress.Replacement)
+	assert.Equal(t, &collector.Migration{
+		Title:  "Ingress",
+		Anchor: "ingress-v122",
+		URL:    "https://kubernetes.io/docs/reference/using-api/deprecation-guide/#ingress-v122",
+		Steps: []string{"Migrate manifests and API clients to use the **networking.k8s.io/v1** API version, available since v1.19.",
+			"All existing persisted objects are accessible via the new API"},
+		NotableChanges: []string{"`spec.backend` is renamed to `spec.defaultBackend`", "The backend `serviceName` field is renamed to `service.name`",
+			"Numeric backend `servicePort` fields are renamed to `service.port.number`"},
+	}, ingress.Migration)
+	// apis of the same section share its migration
+	assert.Same(t, ingress.Migration, k8sObj[len(k8sObj)-2].Migration)
+	cronJob := k8sObj[2]
+	assert.Equal(t, "CronJob", cronJob.Migration.Title)
+	assert.Empty(t, cronJob.Migration.NotableChanges)
+}
+
+func TestReleaseHeadingWarnings(t *testing.T) {
+	warnings := make([]collector.Warning, 0)
+	guide := NewDeprecationGuide().WithWarnings(func(w collector.Warning) {
+		warnings = append(warnings, w)
+	})
+	k8sObj, err := guide.markdownToObject(strings.NewReader("### v1.x\n\n#### CronJob {#cronjob-v1x}\n\nThe **batch/v1beta1** API version of CronJob will no longer be served in v1.x.\n\n" +
+		"### Upgrade notes\n\nThe **batch/v1beta1** API version of CronJob will no longer be served soon.\n\n" +
+		"### v1.25.\n\n#### CronJob {#cronjob-v125}\n\nThe **batch/v1beta1** API version of CronJob will no longer be served in v1.25."))
+	assert.NoError(t, err)
+	assert.Len(t, k8sObj, 1)
+	assert.Equal(t, collector.MustParseKubeVersion("v1.25"), k8sObj[0].Removed)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, collector.SourceMarkdown, warnings[0].Source)
+	assert.Equal(t, "v1.x", warnings[0].Value)
+	assert.Error(t, warnings[0].Err)
+}
+
+func TestCollectOutdatedAPI(t *testing.T) {
+	guide, err := os.ReadFile("./testdata/fixture/deprecation-guide.md")
+	assert.NoError(t, err)
+	requested := make([]string, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_, _ = w.Write(guide)
+	}))
+	defer srv.Close()
+	original := depGuideURL
+	depGuideURL = srv.URL + "/%s/deprecation-guide.md"
+	defer func() { depGuideURL = original }()
+	client, err := collector.NewClient(collector.ClientOptions{})
+	assert.NoError(t, err)
+
+	apis, err := NewDeprecationGuide().WithClient(client).CollectOutdatedAPI(context.Background())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, apis)
+
+	// the guide of a commit is downloaded once, the one of a branch is revalidated
+	commit := "0123456789abcdef0123456789abcdef01234567"
+	downloads := cache.NewCache(t.TempDir(), false).WithClient(client)
+	for i := 0; i < 2; i++ {
+		apis, err = NewCachedDeprecationGuide(downloads).WithClient(client).WithRef(commit).CollectOutdatedAPI(context.Background())
+		assert.NoError(t, err)
+		assert.NotEmpty(t, apis)
+	}
+	assert.Equal(t, []string{"/main/deprecation-guide.md", "/" + commit + "/deprecation-guide.md"}, requested)
+	entries, err := downloads.Entries()
+	assert.NoError(t, err)
+	assert.Equal(t, "website/"+commit+"/deprecation-guide.md", entries[0].Key)
+}
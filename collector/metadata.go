@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//MetadataType tell whether a deprecated metadata key is an annotation or a label
+type MetadataType string
+
+//Metadata types of the deprecated keys
+const (
+	Annotation MetadataType = "annotation"
+	Label      MetadataType = "label"
+)
+
+//DeprecatedMetadata well known annotation or label replaced by a field or by another key
+type DeprecatedMetadata struct {
+	Type MetadataType `yaml:"type"`
+	// Key of the annotation or label, a trailing * matches every key with the prefix, e.g. container.seccomp.security.alpha.kubernetes.io/*
+	Key string `yaml:"key"`
+	// Kinds limit the objects the key is checked on, every kind when empty
+	Kinds      []string    `yaml:"kinds,omitempty"`
+	Deprecated KubeVersion `yaml:"deprecated,omitempty"`
+	Removed    KubeVersion `yaml:"removed,omitempty"`
+	// Replacement is the field or key to set instead, e.g. spec.ingressClassName
+	Replacement string `yaml:"replacement,omitempty"`
+	Description string `yaml:"description,omitempty"`
+}
+
+//deprecatedMetadata curated annotations and labels, the pod level keys are also checked on pod templates
+var deprecatedMetadata = []DeprecatedMetadata{
+	{Type: Annotation, Key: "seccomp.security.alpha.kubernetes.io/pod", Deprecated: MustParseKubeVersion("v1.19"), Removed: MustParseKubeVersion("v1.25"), Replacement: "spec.securityContext.seccompProfile",
+		Description: "seccomp annotations are ignored by the kubelet since v1.25, set the pod securityContext.seccompProfile field instead."},
+	{Type: Annotation, Key: "container.seccomp.security.alpha.kubernetes.io/*", Deprecated: MustParseKubeVersion("v1.19"), Removed: MustParseKubeVersion("v1.25"), Replacement: "spec.containers[].securityContext.seccompProfile",
+		Description: "seccomp annotations are ignored by the kubelet since v1.25, set the container securityContext.seccompProfile field instead."},
+	{Type: Annotation, Key: "container.apparmor.security.beta.kubernetes.io/*", Deprecated: MustParseKubeVersion("v1.30"), Replacement: "spec.containers[].securityContext.appArmorProfile",
+		Description: "AppArmor annotations are deprecated since v1.30, set the container securityContext.appArmorProfile field instead."},
+	{Type: Annotation, Key: "scheduler.alpha.kubernetes.io/critical-pod", Deprecated: MustParseKubeVersion("v1.13"), Removed: MustParseKubeVersion("v1.16"), Replacement: "spec.priorityClassName",
+		Description: "the critical-pod annotation was removed in v1.16, use the system-cluster-critical or system-node-critical priority class instead."},
+	{Type: Annotation, Key: "pod.beta.kubernetes.io/init-containers", Deprecated: MustParseKubeVersion("v1.6"), Removed: MustParseKubeVersion("v1.8"), Replacement: "spec.initContainers",
+		Description: "init containers annotations were removed in v1.8, use the initContainers field instead."},
+	{Type: Annotation, Key: "kubernetes.io/ingress.class", Kinds: []string{"Ingress"}, Deprecated: MustParseKubeVersion("v1.18"), Replacement: "spec.ingressClassName",
+		Description: "the ingress class annotation is deprecated since v1.18, use the ingressClassName field instead."},
+	{Type: Annotation, Key: "service.alpha.kubernetes.io/tolerate-unready-endpoints", Kinds: []string{"Service"}, Deprecated: MustParseKubeVersion("v1.11"), Replacement: "spec.publishNotReadyAddresses",
+		Description: "the tolerate-unready-endpoints annotation is deprecated since v1.11, use the publishNotReadyAddresses field instead."},
+	{Type: Annotation, Key: "service.beta.kubernetes.io/external-traffic", Kinds: []string{"Service"}, Deprecated: MustParseKubeVersion("v1.7"), Replacement: "spec.externalTrafficPolicy",
+		Description: "the external-traffic beta annotation is deprecated since v1.7, use the externalTrafficPolicy field instead."},
+	{Type: Annotation, Key: "service.beta.kubernetes.io/healthcheck-nodeport", Kinds: []string{"Service"}, Deprecated: MustParseKubeVersion("v1.7"), Replacement: "spec.healthCheckNodePort",
+		Description: "the healthcheck-nodeport beta annotation is deprecated since v1.7, use the healthCheckNodePort field instead."},
+	{Type: Annotation, Key: "service.kubernetes.io/topology-aware-hints", Kinds: []string{"Service"}, Deprecated: MustParseKubeVersion("v1.27"), Replacement: "service.kubernetes.io/topology-mode",
+		Description: "the topology-aware-hints annotation is deprecated since v1.27, use the topology-mode annotation instead."},
+	{Type: Annotation, Key: "volume.beta.kubernetes.io/storage-class", Kinds: []string{"PersistentVolumeClaim", "PersistentVolume"}, Deprecated: MustParseKubeVersion("v1.6"), Replacement: "spec.storageClassName",
+		Description: "the storage-class beta annotation is deprecated since v1.6, use the storageClassName field instead."},
+	{Type: Annotation, Key: "volume.beta.kubernetes.io/storage-provisioner", Kinds: []string{"PersistentVolumeClaim"}, Deprecated: MustParseKubeVersion("v1.23"), Replacement: "volume.kubernetes.io/storage-provisioner",
+		Description: "the storage-provisioner beta annotation is deprecated since v1.23, use the volume.kubernetes.io/storage-provisioner annotation instead."},
+	{Type: Label, Key: "beta.kubernetes.io/arch", Deprecated: MustParseKubeVersion("v1.14"), Replacement: "kubernetes.io/arch",
+		Description: "the beta arch label is deprecated since v1.14, use the kubernetes.io/arch label instead."},
+	{Type: Label, Key: "beta.kubernetes.io/os", Deprecated: MustParseKubeVersion("v1.14"), Replacement: "kubernetes.io/os",
+		Description: "the beta os label is deprecated since v1.14, use the kubernetes.io/os label instead."},
+	{Type: Label, Key: "beta.kubernetes.io/instance-type", Deprecated: MustParseKubeVersion("v1.17"), Replacement: "node.kubernetes.io/instance-type",
+		Description: "the beta instance-type label is deprecated since v1.17, use the node.kubernetes.io/instance-type label instead."},
+	{Type: Label, Key: "failure-domain.beta.kubernetes.io/zone", Deprecated: MustParseKubeVersion("v1.17"), Replacement: "topology.kubernetes.io/zone",
+		Description: "the failure-domain zone label is deprecated since v1.17, use the topology.kubernetes.io/zone label instead."},
+	{Type: Label, Key: "failure-domain.beta.kubernetes.io/region", Deprecated: MustParseKubeVersion("v1.17"), Replacement: "topology.kubernetes.io/region",
+		Description: "the failure-domain region label is deprecated since v1.17, use the topology.kubernetes.io/region label instead."},
+}
+
+//DeprecatedMetadataDataset return a copy of the curated deprecated annotations and labels
+func DeprecatedMetadataDataset() []*DeprecatedMetadata {
+	dataset := make([]*DeprecatedMetadata, 0, len(deprecatedMetadata))
+	for _, m := range deprecatedMetadata {
+		m := m
+		dataset = append(dataset, &m)
+	}
+	return dataset
+}
+
+//ReadDeprecatedMetadata read a yaml list of deprecated annotations and labels, the format of DeprecatedMetadata
+func ReadDeprecatedMetadata(path string) ([]*DeprecatedMetadata, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	var entries []*DeprecatedMetadata
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, m := range entries {
+		if m.Type != Annotation && m.Type != Label {
+			return nil, fmt.Errorf("%s: entry %d: type must be %s or %s", path, i, Annotation, Label)
+		}
+		if len(m.Key) == 0 {
+			return nil, fmt.Errorf("%s: entry %d: key is required", path, i)
+		}
+	}
+	return entries, nil
+}
+
+//MergeDeprecatedMetadata add the extra entries to the dataset, an extra entry replaces the one of the same type and key
+func MergeDeprecatedMetadata(dataset []*DeprecatedMetadata, extra []*DeprecatedMetadata) []*DeprecatedMetadata {
+	merged := make([]*DeprecatedMetadata, 0, len(dataset)+len(extra))
+	index := make(map[string]int)
+	for _, m := range append(append([]*DeprecatedMetadata{}, dataset...), extra...) {
+		key := string(m.Type) + "/" + m.Key
+		if i, ok := index[key]; ok {
+			merged[i] = m
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+//Matches check whether the metadata key of an object of kind is the deprecated one
+func (m DeprecatedMetadata) Matches(kind string, key string) bool {
+	if len(m.Kinds) > 0 {
+		found := false
+		for _, k := range m.Kinds {
+			found = found || k == kind
+		}
+		if !found {
+			return false
+		}
+	}
+	if strings.HasSuffix(m.Key, "*") {
+		return strings.HasPrefix(key, strings.TrimSuffix(m.Key, "*"))
+	}
+	return m.Key == key
+}
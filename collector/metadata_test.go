@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeprecatedMetadataMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata DeprecatedMetadata
+		kind     string
+		key      string
+		want     bool
+	}{
+		{name: "exact key", metadata: DeprecatedMetadata{Key: "seccomp.security.alpha.kubernetes.io/pod"}, kind: "Deployment", key: "seccomp.security.alpha.kubernetes.io/pod", want: true},
+		{name: "other key", metadata: DeprecatedMetadata{Key: "seccomp.security.alpha.kubernetes.io/pod"}, kind: "Pod", key: "seccomp.security.alpha.kubernetes.io/podx"},
+		{name: "key prefix", metadata: DeprecatedMetadata{Key: "container.seccomp.security.alpha.kubernetes.io/*"}, kind: "Pod", key: "container.seccomp.security.alpha.kubernetes.io/app", want: true},
+		{name: "listed kind", metadata: DeprecatedMetadata{Key: "kubernetes.io/ingress.class", Kinds: []string{"Ingress"}}, kind: "Ingress", key: "kubernetes.io/ingress.class", want: true},
+		{name: "other kind", metadata: DeprecatedMetadata{Key: "kubernetes.io/ingress.class", Kinds: []string{"Ingress"}}, kind: "Service", key: "kubernetes.io/ingress.class"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.metadata.Matches(tt.kind, tt.key))
+		})
+	}
+}
+
+func TestDeprecatedMetadataDataset(t *testing.T) {
+	dataset := DeprecatedMetadataDataset()
+	keys := make(map[string]bool)
+	for _, m := range dataset {
+		assert.Contains(t, []MetadataType{Annotation, Label}, m.Type)
+		assert.NotEmpty(t, m.Deprecated, m.Key)
+		assert.NotEmpty(t, m.Replacement, m.Key)
+		assert.False(t, keys[m.Key], "duplicate key %s", m.Key)
+		keys[m.Key] = true
+	}
+	assert.True(t, keys["kubernetes.io/ingress.class"])
+	// the dataset is copied, callers cannot change the curated entries
+	dataset[0].Removed = MustParseKubeVersion("v1.0")
+	assert.NotEqual(t, MustParseKubeVersion("v1.0"), DeprecatedMetadataDataset()[0].Removed)
+}
+
+func TestReadDeprecatedMetadata(t *testing.T) {
+	extra, err := ReadDeprecatedMetadata("./testdata/fixture/metadata.yaml")
+	assert.NoError(t, err)
+	assert.Len(t, extra, 2)
+	assert.Equal(t, &DeprecatedMetadata{Type: Label, Key: "example.com/legacy-tier", Deprecated: MustParseKubeVersion("v1.20"), Replacement: "example.com/tier"}, extra[1])
+
+	dataset := DeprecatedMetadataDataset()
+	merged := MergeDeprecatedMetadata(dataset, extra)
+	assert.Len(t, merged, len(dataset)+1)
+	for _, m := range merged {
+		if m.Key == "kubernetes.io/ingress.class" {
+			assert.Equal(t, "v1.40", m.Removed.String())
+		}
+	}
+	assert.Equal(t, "example.com/legacy-tier", merged[len(merged)-1].Key)
+
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown type", content: "- type: taint\n  key: a\n"},
+		{name: "missing key", content: "- type: label\n"},
+		{name: "not a list", content: "type: label\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "metadata.yaml")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+			_, err := ReadDeprecatedMetadata(path)
+			assert.Error(t, err)
+		})
+	}
+	_, err = ReadDeprecatedMetadata("./testdata/fixture/missing.yaml")
+	assert.Error(t, err)
+}
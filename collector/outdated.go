@@ -2,15 +2,96 @@ package collector
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
+//Sources of outdated api data
+const (
+	SourceSwagger   = "swagger"
+	SourceMarkdown  = "markdown"
+	SourceOpenAPIV3 = "openapi-v3"
+	SourceLifecycle = "lifecycle"
+	SourceCRD       = "crd"
+)
+
 //OutdatedAPI object
 type OutdatedAPI struct {
 	Description string
-	Deprecated  string
-	Removed     string
-	Gav         Gvk
+	// Introduced is the release the api was added in, only known from the prerelease lifecycle
+	Introduced KubeVersion
+	Deprecated KubeVersion
+	Removed    KubeVersion
+	Source     string
+	Gav        Gvk
+	// Replacement is the api to migrate to, zero when no source names one
+	Replacement Gvk
+	// Conflicts hold the fields the merged sources disagree on
+	Conflicts []Conflict
+	// Migration is the deprecation guide section of the api, nil when the guide has none
+	Migration *Migration
+	// Provenance is the source of the merged value of a field, e.g. removed: markdown, fields missing are from Source
+	Provenance map[string]string
+	// CustomResource is set for the deprecated or unserved versions of a CustomResourceDefinition, they carry no k8s
+	// version and are outdated at any target version
+	CustomResource bool
+	// Unserved is set for custom resource versions their CustomResourceDefinition no longer serves
+	Unserved bool
+}
+
+//Status lifecycle status of an api at a k8s version
+type Status string
+
+//Lifecycle statuses of an outdated api
+const (
+	StatusDeprecated Status = "deprecated"
+	StatusRemoved    Status = "removed"
+)
+
+//StatusAt return the lifecycle status of the api at target, false when the api is still fully supported. Custom
+//resource versions are removed when unserved and deprecated otherwise, whatever the target version
+func (o OutdatedAPI) StatusAt(target KubeVersion) (Status, bool) {
+	switch {
+	case o.Unserved || o.Removed.AtOrBefore(target):
+		return StatusRemoved, true
+	case o.CustomResource || o.Deprecated.AtOrBefore(target):
+		return StatusDeprecated, true
+	}
+	return "", false
+}
+
+//DeprecatedField object field marked deprecated in the api schema of a kind that may itself still be served
+type DeprecatedField struct {
+	Gav Gvk
+	// Path of the field in the object, array items are written [] and map values *, e.g. spec.template.spec.serviceAccount
+	Path        string
+	Description string
+	Deprecated  KubeVersion
+	Removed     KubeVersion
+	// Replacement is the field to set instead when the description names one, e.g. serviceAccountName
+	Replacement string
+	Source      string
+}
+
+//Migration deprecation guide section describing how to move off an outdated api
+type Migration struct {
+	// Title of the section, e.g. CronJob or Webhook resources
+	Title string
+	// Anchor of the section, e.g. cronjob-v125
+	Anchor string
+	// URL of the section on the kubernetes website
+	URL string
+	// Steps are the section bullets, e.g. Migrate manifests and API clients to use the **batch/v1** API version
+	Steps []string
+	// NotableChanges are the schema changes listed under the notable changes bullet
+	NotableChanges []string
+}
+
+//Conflict field which the merged sources report different values for
+type Conflict struct {
+	Field string
+	// Values by source
+	Values map[string]string
 }
 
 //Gvk group/version/kind object
@@ -20,27 +101,194 @@ type Gvk struct {
 	Kind    string `json:"kind"`
 }
 
+//ParseGvk build Gvk from a manifest apiVersion (group/version or version for the core group) and kind
+func ParseGvk(apiVersion string, kind string) (Gvk, error) {
+	parts := strings.Split(apiVersion, "/")
+	switch {
+	case len(kind) == 0:
+		return Gvk{}, fmt.Errorf("kind is missing")
+	case len(parts) == 1 && len(parts[0]) > 0:
+		return Gvk{Version: parts[0], Kind: kind}, nil
+	case len(parts) == 2 && len(parts[0]) > 0 && len(parts[1]) > 0:
+		return Gvk{Group: parts[0], Version: parts[1], Kind: kind}, nil
+	}
+	return Gvk{}, fmt.Errorf("invalid apiVersion %q", apiVersion)
+}
+
+//IsZero check whether the Gvk is unset
+func (g Gvk) IsZero() bool {
+	return g == Gvk{}
+}
+
+//String return the Gvk as apiVersion and kind, e.g. batch/v1 CronJob
+func (g Gvk) String() string {
+	if g.IsZero() {
+		return ""
+	}
+	return g.APIVersion() + " " + g.Kind
+}
+
+//APIVersion return the manifest apiVersion of the Gvk
+func (g Gvk) APIVersion() string {
+	if len(g.Group) == 0 {
+		return g.Version
+	}
+	return g.Group + "/" + g.Version
+}
+
 //K8sAPI object
 type K8sAPI struct {
 	API               string `header:"k8s api"`
 	DeprecatedVersion string `header:"deprecated Version"`
 	RemovedVersion    string `header:"removed Version"`
+	Replacement       string `header:"replacement"`
+}
+
+//mergeSources merge the apis of a source into the base apis on their Gvk with merge, apis unknown to the base are
+//added. The result holds copies, one api per Gvk, sorted by group, version and kind
+func mergeSources(base []*OutdatedAPI, other []*OutdatedAPI, merge func(merged *OutdatedAPI, api *OutdatedAPI)) []*OutdatedAPI {
+	merged := make([]*OutdatedAPI, 0, len(base)+len(other))
+	byGvk := make(map[Gvk]*OutdatedAPI, len(base))
+	add := func(apis []*OutdatedAPI, merge func(merged *OutdatedAPI, api *OutdatedAPI)) {
+		for _, a := range apis {
+			if val, ok := byGvk[a.Gav]; ok {
+				merge(val, a)
+				val.Source = joinSources(val.Source, a.Source)
+				continue
+			}
+			api := *a
+			// conflicts are added to while merging, the values of the source must not change with them
+			api.Conflicts = make([]Conflict, 0, len(a.Conflicts))
+			for _, c := range a.Conflicts {
+				values := make(map[string]string, len(c.Values))
+				for source, value := range c.Values {
+					values[source] = value
+				}
+				api.Conflicts = append(api.Conflicts, Conflict{Field: c.Field, Values: values})
+			}
+			api.Provenance = make(map[string]string, len(a.Provenance))
+			for field, value := range map[string]string{"introduced": a.Introduced.String(), "deprecated": a.Deprecated.String(),
+				"removed": a.Removed.String(), "replacement": a.Replacement.String()} {
+				if len(value) > 0 {
+					api.Provenance[field] = a.SourceOf(field)
+				}
+			}
+			byGvk[api.Gav] = &api
+			merged = append(merged, &api)
+		}
+	}
+	// entries of the same source sharing a Gvk only fill the fields the first one lacks
+	add(base, func(merged *OutdatedAPI, api *OutdatedAPI) {
+		mergeVersion(merged, "introduced", &merged.Introduced, api, api.Introduced, false)
+		mergeVersion(merged, "deprecated", &merged.Deprecated, api, api.Deprecated, false)
+		mergeVersion(merged, "removed", &merged.Removed, api, api.Removed, false)
+		mergeReplacement(merged, api, false)
+	})
+	add(other, merge)
+	SortOutdatedAPIs(merged)
+	return merged
+}
+
+//SortOutdatedAPIs sort apis by group, version and kind
+func SortOutdatedAPIs(apis []*OutdatedAPI) {
+	sort.SliceStable(apis, func(i, j int) bool {
+		a, b := apis[i].Gav, apis[j].Gav
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Kind < b.Kind
+	})
+}
+
+//SourceOf return the source which reported the merged value of a field, e.g. removed
+func (o OutdatedAPI) SourceOf(field string) string {
+	if source, ok := o.Provenance[field]; ok {
+		return source
+	}
+	return o.Source
 }
 
-//MergeMdSwaggerVersions merge swagger and marjdown collector results
-func MergeMdSwaggerVersions(objs []*OutdatedAPI, mDetails map[string]*OutdatedAPI) []K8sAPI {
-	apis := make([]K8sAPI, 0)
+//mergeVersion fold the value api reports for a field into merged. The merged value is kept unless it is empty or
+//override is set, a different value is recorded as a conflict keyed by the source of each value
+func mergeVersion(merged *OutdatedAPI, field string, current *KubeVersion, api *OutdatedAPI, value KubeVersion, override bool) {
+	switch {
+	case value.IsZero() || *current == value:
+		return
+	case !current.IsZero():
+		addConflict(merged, field, current.String(), api.SourceOf(field), value.String())
+		if !override {
+			return
+		}
+	}
+	*current = value
+	merged.Provenance[field] = api.SourceOf(field)
+}
+
+//mergeReplacement fold the replacement api reports into merged with the mergeVersion rules
+func mergeReplacement(merged *OutdatedAPI, api *OutdatedAPI, override bool) {
+	switch {
+	case api.Replacement.IsZero() || merged.Replacement == api.Replacement:
+		return
+	case !merged.Replacement.IsZero():
+		addConflict(merged, "replacement", merged.Replacement.String(), api.SourceOf("replacement"), api.Replacement.String())
+		if !override {
+			return
+		}
+	}
+	merged.Replacement = api.Replacement
+	merged.Provenance["replacement"] = api.SourceOf("replacement")
+}
 
-	for _, obj := range objs {
-		definition := strings.TrimSpace(fmt.Sprintf("%s.%s.%s", obj.Gav.Group, obj.Gav.Version, obj.Gav.Kind))
-		if val, ok := mDetails[fmt.Sprintf("io.k8s.api.%s", definition)]; ok {
-			val.Removed = obj.Removed
-			continue
+//addConflict record the value a source reports for a field next to the merged value and its source. Values are keyed
+//by source, so a source reporting a Gvk twice cannot disagree with itself: its first value is kept and the other one
+//is dropped on purpose, e.g. the swagger spec describing an api under two definitions
+func addConflict(merged *OutdatedAPI, field string, current string, source string, value string) {
+	currentSource := merged.SourceOf(field)
+	if currentSource == source {
+		return
+	}
+	for i := range merged.Conflicts {
+		if merged.Conflicts[i].Field == field {
+			merged.Conflicts[i].Values[source] = value
+			return
 		}
-		apis = append(apis, K8sAPI{API: fmt.Sprintf("%s.%s.%s", obj.Gav.Group, obj.Gav.Version, obj.Gav.Kind), DeprecatedVersion: obj.Deprecated, RemovedVersion: obj.Removed})
 	}
-	for _, md := range mDetails {
-		apis = append(apis, K8sAPI{API: fmt.Sprintf("%s.%s.%s", md.Gav.Group, md.Gav.Version, md.Gav.Kind), DeprecatedVersion: md.Deprecated, RemovedVersion: md.Removed})
+	merged.Conflicts = append(merged.Conflicts, Conflict{Field: field, Values: map[string]string{currentSource: current, source: value}})
+}
+
+//ToK8sAPIs convert outdated apis to table rows
+func ToK8sAPIs(outdated []*OutdatedAPI) []K8sAPI {
+	apis := make([]K8sAPI, 0, len(outdated))
+	for _, o := range outdated {
+		apis = append(apis, K8sAPI{API: fmt.Sprintf("%s.%s.%s", o.Gav.Group, o.Gav.Version, o.Gav.Kind), DeprecatedVersion: o.Deprecated.String(), RemovedVersion: o.Removed.String(), Replacement: replacementColumn(o)})
 	}
 	return apis
 }
+
+//replacementColumn return the replacement of the table row, flagged when the sources disagree on it
+func replacementColumn(o *OutdatedAPI) string {
+	for _, c := range o.Conflicts {
+		if c.Field == "replacement" {
+			return o.Replacement.String() + " (sources disagree)"
+		}
+	}
+	return o.Replacement.String()
+}
+
+func joinSources(a string, b string) string {
+	switch {
+	case len(a) == 0:
+		return b
+	case len(b) == 0:
+		return a
+	}
+	for _, source := range strings.Split(a, ",") {
+		if source == b {
+			return a
+		}
+	}
+	return a + "," + b
+}
@@ -5,34 +5,82 @@ import (
 	"testing"
 )
 
-func TestMapResources(t *testing.T) {
+func TestToK8sAPIs(t *testing.T) {
+	cronJob := Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+	replacement := Gvk{Group: "batch", Version: "v1", Kind: "CronJob"}
 	tests := []struct {
-		name       string
-		mdAPI      []*OutdatedAPI
-		swaggerAPI map[string]*OutdatedAPI
-		want       []K8sAPI
+		name      string
+		conflicts []Conflict
+		want      string
 	}{
-		{name: "override removed version",
-			mdAPI:      []*OutdatedAPI{{Removed: "1.25", Gav: Gvk{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIStorageCapacity"}}},
-			swaggerAPI: map[string]*OutdatedAPI{"io.k8s.api.storage.k8s.io.v1beta1.CSIStorageCapacity": {Removed: "1.23", Deprecated: "1.21", Gav: Gvk{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIStorageCapacity"}}},
-			want:       []K8sAPI{{DeprecatedVersion: "1.21", RemovedVersion: "1.25", API: "storage.k8s.io.v1beta1.CSIStorageCapacity"}},
-		},
-		{name: "append api",
-			mdAPI:      []*OutdatedAPI{{Removed: "1.25", Gav: Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema"}}},
-			swaggerAPI: map[string]*OutdatedAPI{"io.k8s.api.storage.k8s.io.v1beta1.CSIStorageCapacity": {Removed: "1.23", Deprecated: "1.21", Gav: Gvk{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIStorageCapacity"}}},
-			want: []K8sAPI{{DeprecatedVersion: "", RemovedVersion: "1.25", API: "flowcontrol.apiserver.k8s.io.v1beta1.FlowSchema"},
-				{DeprecatedVersion: "1.21", RemovedVersion: "1.23", API: "storage.k8s.io.v1beta1.CSIStorageCapacity"}},
-		},
+		{name: "no conflict", want: "batch/v1 CronJob"},
+		{name: "removed conflict", want: "batch/v1 CronJob",
+			conflicts: []Conflict{{Field: "removed", Values: map[string]string{SourceSwagger: "v1.26", SourceLifecycle: "v1.25"}}}},
+		{name: "replacement conflict", want: "batch/v1 CronJob (sources disagree)",
+			conflicts: []Conflict{{Field: "replacement", Values: map[string]string{SourceSwagger: "batch/v2alpha1 CronJob", SourceLifecycle: "batch/v1 CronJob"}}}},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToK8sAPIs([]*OutdatedAPI{{Gav: cronJob, Replacement: replacement, Conflicts: tt.conflicts}})
+			assert.Equal(t, 1, len(got))
+			assert.Equal(t, "batch.v1beta1.CronJob", got[0].API)
+			assert.Equal(t, tt.want, got[0].Replacement)
+		})
+	}
+}
 
+func TestMergeSources(t *testing.T) {
+	cronJob := Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+	base := func() []*OutdatedAPI {
+		return []*OutdatedAPI{
+			{Deprecated: MustParseKubeVersion("v1.21"), Removed: MustParseKubeVersion("v1.26"), Source: SourceSwagger, Gav: cronJob,
+				Conflicts: []Conflict{{Field: "deprecated", Values: map[string]string{SourceSwagger: "v1.21", SourceMarkdown: "v1.22"}}}},
+			// a source disagreeing with itself is not a conflict, the first value is kept
+			{Removed: MustParseKubeVersion("v1.30"), Source: SourceSwagger, Gav: cronJob},
+		}
+	}
+	other := func() []*OutdatedAPI {
+		return []*OutdatedAPI{{Deprecated: MustParseKubeVersion("v1.23"), Removed: MustParseKubeVersion("v1.25"), Source: SourceLifecycle, Gav: cronJob}}
+	}
+	merge := func(merged *OutdatedAPI, api *OutdatedAPI) {
+		mergeVersion(merged, "deprecated", &merged.Deprecated, api, api.Deprecated, true)
+		mergeVersion(merged, "removed", &merged.Removed, api, api.Removed, true)
+	}
+	in, lifecycle := base(), other()
+	got := mergeSources(in, lifecycle, merge)
+	assert.Equal(t, 1, len(got))
+	assert.Equal(t, "v1.23", got[0].Deprecated.String())
+	assert.Equal(t, "v1.25", got[0].Removed.String())
+	assert.Equal(t, []Conflict{
+		{Field: "deprecated", Values: map[string]string{SourceSwagger: "v1.21", SourceMarkdown: "v1.22", SourceLifecycle: "v1.23"}},
+		{Field: "removed", Values: map[string]string{SourceSwagger: "v1.26", SourceLifecycle: "v1.25"}},
+	}, got[0].Conflicts)
+	// the inputs are not modified, merging them again gives the same result
+	assert.Equal(t, base(), in)
+	assert.Equal(t, other(), lifecycle)
+	assert.Equal(t, got, mergeSources(in, lifecycle, merge))
+}
+
+func TestStatusAt(t *testing.T) {
+	target := MustParseKubeVersion("v1.22")
+	tests := []struct {
+		name   string
+		api    OutdatedAPI
+		want   Status
+		wantOk bool
+	}{
+		{name: "removed", api: OutdatedAPI{Deprecated: MustParseKubeVersion("v1.16"), Removed: MustParseKubeVersion("v1.22")}, want: StatusRemoved, wantOk: true},
+		{name: "deprecated", api: OutdatedAPI{Deprecated: MustParseKubeVersion("v1.21"), Removed: MustParseKubeVersion("v1.25")}, want: StatusDeprecated, wantOk: true},
+		{name: "deprecated later", api: OutdatedAPI{Deprecated: MustParseKubeVersion("v1.23")}},
+		{name: "no version", api: OutdatedAPI{Description: "a markdown section without a removed release"}},
+		{name: "served custom resource version", api: OutdatedAPI{CustomResource: true}, want: StatusDeprecated, wantOk: true},
+		{name: "unserved custom resource version", api: OutdatedAPI{CustomResource: true, Unserved: true}, want: StatusRemoved, wantOk: true},
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := MergeMdSwaggerVersions(tt.mdAPI, tt.swaggerAPI)
-			for index, r := range got {
-				assert.Equal(t, r.API, tt.want[index].API)
-				assert.Equal(t, r.DeprecatedVersion, tt.want[index].DeprecatedVersion)
-				assert.Equal(t, r.RemovedVersion, tt.want[index].RemovedVersion)
-			}
+			got, ok := tt.api.StatusAt(target)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
 		})
 	}
 }
@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"context"
+	"sort"
+)
+
+//Priorities of the built-in collectors, a collector of higher priority wins every field the merged sources disagree on
+const (
+	PriorityCRD       = 0
+	PrioritySwagger   = 10
+	PriorityMarkdown  = 20
+	PriorityLifecycle = 30
+)
+
+//Options of a collect run shared by every collector, a collector ignores the options it has no use for
+type Options struct {
+	// K8sVersion is the k8s version the apis are collected from onward, e.g. v1.20.0
+	K8sVersion string
+}
+
+//Collector data source of outdated apis
+type Collector interface {
+	// Name is the source of the collected apis, e.g. swagger
+	Name() string
+	// Priority rank the collector in the merge, see PrioritySwagger
+	Priority() int
+	// Collect return the outdated apis of the source
+	Collect(ctx context.Context, opts Options) ([]*OutdatedAPI, error)
+}
+
+//Collected outdated apis of a collector, the input of MergeByPriority
+type Collected struct {
+	Name     string
+	Priority int
+	APIs     []*OutdatedAPI
+}
+
+//funcCollector Collector calling a function
+type funcCollector struct {
+	name     string
+	priority int
+	collect  func(ctx context.Context, opts Options) ([]*OutdatedAPI, error)
+}
+
+//NewCollector instansiate a Collector named name calling collect
+func NewCollector(name string, priority int, collect func(ctx context.Context, opts Options) ([]*OutdatedAPI, error)) Collector {
+	return funcCollector{name: name, priority: priority, collect: collect}
+}
+
+func (f funcCollector) Name() string {
+	return f.name
+}
+
+func (f funcCollector) Priority() int {
+	return f.priority
+}
+
+func (f funcCollector) Collect(ctx context.Context, opts Options) ([]*OutdatedAPI, error) {
+	return f.collect(ctx, opts)
+}
+
+//Registry collectors of a collect run, one per name
+type Registry struct {
+	collectors []Collector
+}
+
+//NewRegistry instansiate new Registry of collectors
+func NewRegistry(collectors ...Collector) *Registry {
+	r := &Registry{}
+	for _, c := range collectors {
+		r = r.WithCollector(c)
+	}
+	return r
+}
+
+//WithCollector return a copy of the registry with c, c replaces the collector of the same name
+func (r Registry) WithCollector(c Collector) *Registry {
+	collectors := make([]Collector, 0, len(r.collectors)+1)
+	for _, registered := range r.collectors {
+		if registered.Name() != c.Name() {
+			collectors = append(collectors, registered)
+		}
+	}
+	r.collectors = append(collectors, c)
+	return &r
+}
+
+//Collectors return the registered collectors by ascending priority, in registration order on equal priorities
+func (r Registry) Collectors() []Collector {
+	collectors := append([]Collector{}, r.collectors...)
+	sort.SliceStable(collectors, func(i, j int) bool {
+		return collectors[i].Priority() < collectors[j].Priority()
+	})
+	return collectors
+}
+
+//Collect run every collector and merge their apis with MergeByPriority, the first failure aborts the run
+func (r Registry) Collect(ctx context.Context, opts Options) ([]*OutdatedAPI, error) {
+	collected := make([]Collected, 0, len(r.collectors))
+	for _, c := range r.Collectors() {
+		apis, err := c.Collect(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		collected = append(collected, Collected{Name: c.Name(), Priority: c.Priority(), APIs: apis})
+	}
+	return MergeByPriority(collected...), nil
+}
+
+//MergeByPriority merge the apis of any number of collectors on their Gvk. A collector of higher priority wins every
+//field it reports, the values it replaces are kept as conflicts, and the deprecation guide section of the highest
+//priority collector having one is kept. The apis without a source are attributed to the name of their collector
+func MergeByPriority(collected ...Collected) []*OutdatedAPI {
+	ordered := append([]Collected{}, collected...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority < ordered[j].Priority
+	})
+	merged := make([]*OutdatedAPI, 0)
+	for _, c := range ordered {
+		named := make([]*OutdatedAPI, 0, len(c.APIs))
+		for _, api := range c.APIs {
+			if len(api.Source) == 0 {
+				copied := *api
+				copied.Source = c.Name
+				api = &copied
+			}
+			named = append(named, api)
+		}
+		// the apis a collector reports twice are folded first, its own first value wins
+		deduped := mergeSources(named, nil, nil)
+		merged = mergeSources(merged, deduped, func(merged *OutdatedAPI, api *OutdatedAPI) {
+			mergeVersion(merged, "introduced", &merged.Introduced, api, api.Introduced, true)
+			mergeVersion(merged, "deprecated", &merged.Deprecated, api, api.Deprecated, true)
+			mergeVersion(merged, "removed", &merged.Removed, api, api.Removed, true)
+			mergeReplacement(merged, api, true)
+			if api.Migration != nil {
+				merged.Migration = api.Migration
+			}
+			merged.CustomResource = merged.CustomResource || api.CustomResource
+			merged.Unserved = merged.Unserved || api.Unserved
+		})
+	}
+	return merged
+}
+
+//SortedAPIs return the apis of a swagger collect, keyed by definition name, as a slice sorted by group, version and
+//kind, the apis sharing a Gvk are in definition name order
+func SortedAPIs(byName map[string]*OutdatedAPI) []*OutdatedAPI {
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	apis := make([]*OutdatedAPI, 0, len(names))
+	for _, name := range names {
+		apis = append(apis, byName[name])
+	}
+	SortOutdatedAPIs(apis)
+	return apis
+}
@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func staticCollector(name string, priority int, apis ...*OutdatedAPI) Collector {
+	return NewCollector(name, priority, func(ctx context.Context, opts Options) ([]*OutdatedAPI, error) {
+		return apis, nil
+	})
+}
+
+func TestRegistryCollectors(t *testing.T) {
+	r := NewRegistry(
+		staticCollector(SourceLifecycle, PriorityLifecycle),
+		staticCollector(SourceSwagger, PrioritySwagger),
+		staticCollector("go-source", PrioritySwagger),
+		staticCollector(SourceCRD, PriorityCRD),
+	)
+	names := func(r *Registry) []string {
+		names := make([]string, 0)
+		for _, c := range r.Collectors() {
+			names = append(names, c.Name())
+		}
+		return names
+	}
+	assert.Equal(t, []string{SourceCRD, SourceSwagger, "go-source", SourceLifecycle}, names(r))
+
+	// a collector replaces the one of the same name, the registry it is added to is unchanged
+	replaced := r.WithCollector(staticCollector(SourceSwagger, PriorityLifecycle+10))
+	assert.Equal(t, []string{SourceCRD, "go-source", SourceLifecycle, SourceSwagger}, names(replaced))
+	assert.Equal(t, []string{SourceCRD, SourceSwagger, "go-source", SourceLifecycle}, names(r))
+}
+
+func TestRegistryCollect(t *testing.T) {
+	cronJob := Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+	var k8sVersion string
+	swagger := NewCollector(SourceSwagger, PrioritySwagger, func(ctx context.Context, opts Options) ([]*OutdatedAPI, error) {
+		k8sVersion = opts.K8sVersion
+		return []*OutdatedAPI{{Deprecated: MustParseKubeVersion("v1.21"), Removed: MustParseKubeVersion("v1.26"), Source: SourceSwagger, Gav: cronJob}}, nil
+	})
+	markdown := staticCollector(SourceMarkdown, PriorityMarkdown,
+		&OutdatedAPI{Removed: MustParseKubeVersion("v1.25"), Source: SourceMarkdown, Gav: cronJob, Migration: &Migration{URL: "https://kubernetes.io/docs/reference/using-api/deprecation-guide/#cronjob-v125"}})
+	got, err := NewRegistry(markdown, swagger).Collect(context.Background(), Options{K8sVersion: "v1.20.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.20.0", k8sVersion)
+	assert.Equal(t, 1, len(got))
+	assert.Equal(t, "v1.25", got[0].Removed.String())
+	assert.Equal(t, "swagger,markdown", got[0].Source)
+	assert.NotNil(t, got[0].Migration)
+
+	failing := NewCollector("failing", PriorityMarkdown, func(ctx context.Context, opts Options) ([]*OutdatedAPI, error) {
+		return nil, errors.New("unavailable")
+	})
+	_, err = NewRegistry(swagger, failing).Collect(context.Background(), Options{})
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestMergeByPriority(t *testing.T) {
+	cronJob := Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+	flowSchema := Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema"}
+	certificate := Gvk{Group: "cert-manager.io", Version: "v1alpha2", Kind: "Certificate"}
+	swagger := Collected{Name: SourceSwagger, Priority: PrioritySwagger, APIs: []*OutdatedAPI{
+		{Deprecated: MustParseKubeVersion("v1.21"), Removed: MustParseKubeVersion("v1.26"), Source: SourceSwagger, Gav: cronJob,
+			Replacement: Gvk{Group: "batch", Version: "v2alpha1", Kind: "CronJob"}},
+		// a collector reporting a Gvk twice only fills the fields its first api lacks
+		{Introduced: MustParseKubeVersion("v1.8"), Removed: MustParseKubeVersion("v1.30"), Source: SourceSwagger, Gav: cronJob},
+	}}
+	lifecycle := Collected{Name: SourceLifecycle, Priority: PriorityLifecycle, APIs: []*OutdatedAPI{
+		{Deprecated: MustParseKubeVersion("v1.21"), Removed: MustParseKubeVersion("v1.25"), Source: SourceLifecycle, Gav: cronJob,
+			Replacement: Gvk{Group: "batch", Version: "v1", Kind: "CronJob"}},
+	}}
+	markdown := Collected{Name: SourceMarkdown, Priority: PriorityMarkdown, APIs: []*OutdatedAPI{
+		{Removed: MustParseKubeVersion("v1.27"), Source: SourceMarkdown, Gav: cronJob},
+		{Removed: MustParseKubeVersion("v1.26"), Source: SourceMarkdown, Gav: flowSchema},
+	}}
+	// the apis of a collector without a source are attributed to the collector
+	crds := Collected{Name: SourceCRD, Priority: PriorityCRD, APIs: []*OutdatedAPI{{Unserved: true, Gav: certificate}}}
+
+	// the merge does not depend on the order the collectors are given in
+	for _, collected := range [][]Collected{{swagger, markdown, lifecycle, crds}, {lifecycle, crds, markdown, swagger}} {
+		got := MergeByPriority(collected...)
+		assert.Equal(t, 3, len(got))
+		assert.Equal(t, cronJob, got[0].Gav)
+		assert.Equal(t, "v1.8", got[0].Introduced.String())
+		assert.Equal(t, "v1.25", got[0].Removed.String())
+		assert.Equal(t, Gvk{Group: "batch", Version: "v1", Kind: "CronJob"}, got[0].Replacement)
+		assert.Equal(t, "swagger,markdown,lifecycle", got[0].Source)
+		assert.Equal(t, SourceLifecycle, got[0].SourceOf("removed"))
+		assert.Equal(t, SourceSwagger, got[0].SourceOf("introduced"))
+		assert.Equal(t, []Conflict{
+			{Field: "removed", Values: map[string]string{SourceSwagger: "v1.26", SourceMarkdown: "v1.27", SourceLifecycle: "v1.25"}},
+			{Field: "replacement", Values: map[string]string{SourceSwagger: "batch/v2alpha1 CronJob", SourceLifecycle: "batch/v1 CronJob"}},
+		}, got[0].Conflicts)
+		assert.Equal(t, certificate, got[1].Gav)
+		assert.Equal(t, SourceCRD, got[1].Source)
+		assert.True(t, got[1].Unserved)
+		assert.Equal(t, flowSchema, got[2].Gav)
+	}
+	// collector results are not modified by the merge
+	assert.Equal(t, "v1.26", swagger.APIs[0].Removed.String())
+	assert.Empty(t, crds.APIs[0].Source)
+}
+
+func TestSortedAPIs(t *testing.T) {
+	got := SortedAPIs(map[string]*OutdatedAPI{
+		"io.k8s.api.extensions.v1beta1.Ingress":        {Gav: Gvk{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}},
+		"io.k8s.api.batch.v1beta1.CronJob":             {Gav: Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}, Description: "second"},
+		"io.k8s.api.batch.v1beta1.CronJob.alternative": {Gav: Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}, Description: "third"},
+		"io.k8s.api.batch.v1beta1.AnotherCronJob":      {Gav: Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}, Description: "first"},
+	})
+	descriptions := make([]string, 0)
+	for _, api := range got {
+		descriptions = append(descriptions, api.Description)
+	}
+	assert.Equal(t, []string{"first", "second", "third", ""}, descriptions)
+}
@@ -0,0 +1,104 @@
+package swagger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//fakeGithub serve the swagger spec of every tag, tracking the number of downloads in flight
+type fakeGithub struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+	delay    time.Duration
+	fail     string
+}
+
+func (f *fakeGithub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.max {
+		f.max = f.inFlight
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+	tag := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+	if tag == f.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	select {
+	case <-time.After(f.delay):
+	case <-r.Context().Done():
+		return
+	}
+	fmt.Fprintf(w, `{"info": {"version": %q}}`, tag)
+}
+
+func withFakeGithub(t *testing.T, f *fakeGithub) {
+	srv := httptest.NewServer(f)
+	original := baseURL
+	baseURL = srv.URL
+	t.Cleanup(func() {
+		baseURL = original
+		srv.Close()
+	})
+}
+
+func noRetryClient(t *testing.T) *collector.Client {
+	client, err := collector.NewClient(collector.ClientOptions{})
+	assert.NoError(t, err)
+	return client
+}
+
+func TestFetchSwaggerVersions(t *testing.T) {
+	f := &fakeGithub{delay: 20 * time.Millisecond}
+	withFakeGithub(t, f)
+	versions := []string{"v1.20.0", "v1.20.1", "v1.21.0", "v1.21.1", "v1.22.0", "v1.23.0", "v1.24.0"}
+	var progress bytes.Buffer
+	data, err := NewOpenAPISpec().WithClient(noRetryClient(t)).WithConcurrency(3).WithProgress(&progress).fetchSwaggerVersions(context.Background(), versions)
+	assert.NoError(t, err)
+	assert.Len(t, data, len(versions))
+	for i, v := range versions {
+		assert.Equal(t, v, data[i]["info"].(map[string]interface{})["version"])
+	}
+	assert.LessOrEqual(t, f.max, 3)
+	assert.Greater(t, f.max, 1)
+	assert.Equal(t, len(versions), strings.Count(progress.String(), "fetched swagger"))
+	assert.Contains(t, progress.String(), fmt.Sprintf("(%d/%d)", len(versions), len(versions)))
+}
+
+func TestFetchSwaggerVersionsErrors(t *testing.T) {
+	t.Run("first failure is returned", func(t *testing.T) {
+		withFakeGithub(t, &fakeGithub{delay: 10 * time.Millisecond, fail: "v1.21.0"})
+		_, err := NewOpenAPISpec().WithClient(noRetryClient(t)).fetchSwaggerVersions(context.Background(), []string{"v1.20.0", "v1.21.0", "v1.22.0"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "v1.21.0")
+		var httpErr *collector.HTTPError
+		assert.True(t, errors.As(err, &httpErr))
+		assert.Equal(t, http.StatusInternalServerError, httpErr.StatusCode)
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		withFakeGithub(t, &fakeGithub{delay: time.Minute})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := NewOpenAPISpec().WithClient(noRetryClient(t)).WithConcurrency(2).fetchSwaggerVersions(ctx, []string{"v1.20.0", "v1.21.0", "v1.22.0"})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+}
@@ -0,0 +1,202 @@
+package swagger
+
+import (
+	"k8s-outdated/collector"
+	"math"
+	"sort"
+	"strings"
+)
+
+//fieldPath deprecated field found under a schema, relative to it
+type fieldPath struct {
+	path        string
+	description string
+}
+
+//noCut is the cut depth of a walk which did not come back to a schema being walked
+const noCut = math.MaxInt
+
+//fieldWalker find the deprecated fields of the schemas of a document, following $ref into nested schemas
+type fieldWalker struct {
+	schemas map[string]interface{}
+	memo    map[string][]fieldPath
+	// visiting holds the depth of the schemas being walked
+	visiting map[string]int
+}
+
+//deprecatedFields return the fields marked deprecated in the property descriptions of every kind of the documents.
+//The first document marking a field wins, the versions are the ones its description names, zero when it names none
+func deprecatedFields(docs []map[string]interface{}, warn collector.WarningFunc) []*collector.DeprecatedField {
+	fields := make([]*collector.DeprecatedField, 0)
+	seen := make(map[string]bool)
+	for _, doc := range docs {
+		p, source, ok := schemaDefinitions(doc)
+		if !ok {
+			continue
+		}
+		schemas, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		w := fieldWalker{schemas: schemas, memo: make(map[string][]fieldPath), visiting: make(map[string]int)}
+		for _, name := range sortedKeys(schemas) {
+			gvk, ok := schemaKind(schemas[name])
+			if !ok {
+				continue
+			}
+			found, _ := w.fields(name)
+			for _, fp := range found {
+				key := gvk.String() + "#" + fp.path
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				field := &collector.DeprecatedField{Gav: gvk, Path: fp.path, Description: fp.description, Source: source}
+				field.Deprecated, field.Removed = kubeVersions(fp.description, warn, collector.Warning{Source: source, Gav: gvk, Field: fp.path})
+				field.Replacement, _ = collector.FindReplacementField(fp.description)
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+//schemaKind return the kind of a schema, schemas shared by many kinds such as DeleteOptions are skipped
+func schemaKind(schema interface{}) (collector.Gvk, bool) {
+	mval, ok := schema.(map[string]interface{})
+	if !ok {
+		return collector.Gvk{}, false
+	}
+	gav, ok := mval["x-kubernetes-group-version-kind"]
+	if !ok {
+		return collector.Gvk{}, false
+	}
+	ga, err := OpenAPISpec{}.parseSwaggerData(gav)
+	if err != nil || len(ga) != 1 || len(ga[0].Kind) == 0 {
+		return collector.Gvk{}, false
+	}
+	return ga[0], true
+}
+
+//documentRelease return the minor release of a document from its info.version, e.g. v1.25, unknown when unversioned
+func documentRelease(doc map[string]interface{}) collector.KubeVersion {
+	info, _ := doc["info"].(map[string]interface{})
+	s, _ := info["version"].(string)
+	v, err := collector.ParseKubeVersion(s)
+	if err != nil {
+		return collector.KubeVersion{}
+	}
+	return v
+}
+
+//documentReleases return the distinct releases of the documents in release order, unversioned documents are skipped
+func documentReleases(docs []map[string]interface{}) []collector.KubeVersion {
+	releases := make([]collector.KubeVersion, 0, len(docs))
+	seen := make(map[collector.KubeVersion]bool, len(docs))
+	for _, doc := range docs {
+		release := documentRelease(doc)
+		if release.IsZero() || seen[release] {
+			continue
+		}
+		seen[release] = true
+		releases = append(releases, release)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Compare(releases[j]) < 0
+	})
+	return releases
+}
+
+//documentTags return the distinct release tags of the documents in the order they were read, unversioned documents are
+//skipped
+func documentTags(docs []map[string]interface{}) []string {
+	tags := make([]string, 0, len(docs))
+	seen := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		if documentRelease(doc).IsZero() {
+			continue
+		}
+		info, _ := doc["info"].(map[string]interface{})
+		tag, _ := info["version"].(string)
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+//fields return the deprecated field paths of a schema, a schema referring back to one being walked ends the walk.
+//The cut depth is the one of the shallowest schema the walk came back to, noCut when it came back to none. The paths
+//of a walk cut short above name are not memoized, walked from another schema the same name finds more of them
+func (w fieldWalker) fields(name string) ([]fieldPath, int) {
+	if found, ok := w.memo[name]; ok {
+		return found, noCut
+	}
+	if depth, ok := w.visiting[name]; ok {
+		return nil, depth
+	}
+	depth, cut := len(w.visiting), noCut
+	w.visiting[name] = depth
+	defer delete(w.visiting, name)
+	schema, _ := w.schemas[name].(map[string]interface{})
+	properties, _ := schema["properties"].(map[string]interface{})
+	found := make([]fieldPath, 0)
+	for _, prop := range sortedKeys(properties) {
+		property, ok := properties[prop].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if description, _ := property["description"].(string); collector.IsDeprecatedField(description) {
+			found = append(found, fieldPath{path: prop, description: description})
+			continue
+		}
+		ref, suffix := childRef(property)
+		if len(ref) == 0 {
+			continue
+		}
+		children, childCut := w.fields(ref)
+		if childCut < cut {
+			cut = childCut
+		}
+		for _, child := range children {
+			found = append(found, fieldPath{path: prop + suffix + "." + child.path, description: child.description})
+		}
+	}
+	if cut < depth {
+		return found, cut
+	}
+	w.memo[name] = found
+	return found, noCut
+}
+
+//childRef return the schema name a property refers to, with [] for array items and .* for map values
+func childRef(property map[string]interface{}) (string, string) {
+	if ref, ok := property["$ref"].(string); ok {
+		return ref[strings.LastIndex(ref, "/")+1:], ""
+	}
+	// openapi v3 wraps a described $ref in allOf
+	if allOf, ok := property["allOf"].([]interface{}); ok && len(allOf) == 1 {
+		if wrapped, ok := allOf[0].(map[string]interface{}); ok {
+			return childRef(wrapped)
+		}
+	}
+	if items, ok := property["items"].(map[string]interface{}); ok {
+		ref, suffix := childRef(items)
+		return ref, "[]" + suffix
+	}
+	if values, ok := property["additionalProperties"].(map[string]interface{}); ok {
+		ref, suffix := childRef(values)
+		return ref, ".*" + suffix
+	}
+	return "", ""
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,111 @@
+package swagger
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"testing"
+)
+
+func fieldNames(fields []*collector.DeprecatedField) []string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.Gav.Kind+" "+f.Path)
+	}
+	return names
+}
+
+func TestCollectDeprecatedFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		k8sVer string
+		want   []string
+	}{
+		{name: "single release", path: "./testdata/fixture/fields/swagger-v1.24.0.json", k8sVer: "v1.24.0", want: []string{
+			"Deployment spec.template.spec.serviceAccount", "Deployment spec.template.spec.volumes[].gitRepo",
+			"Pod spec.serviceAccount", "Pod spec.volumes[].gitRepo"}},
+		{name: "versions directory", path: "./testdata/fixture/fields", k8sVer: "v1.24.0", want: []string{
+			"Deployment spec.template.spec.serviceAccount", "Deployment spec.template.spec.volumes[].gitRepo",
+			"Pod spec.serviceAccount", "Pod spec.volumes[].gitRepo",
+			"Deployment spec.template.spec.hostAliases", "Pod spec.hostAliases"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := NewLocalOpenAPISpec(tt.path).CollectSpecs(context.Background(), tt.k8sVer)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, fieldNames(specs.Fields))
+		})
+	}
+}
+
+func TestDeprecatedFieldRecord(t *testing.T) {
+	specs, err := NewLocalOpenAPISpec("./testdata/fixture/fields").CollectSpecs(context.Background(), "v1.24.0")
+	assert.NoError(t, err)
+	byName := make(map[string]*collector.DeprecatedField)
+	for _, f := range specs.Fields {
+		byName[f.Gav.Kind+" "+f.Path] = f
+	}
+	serviceAccount := byName["Pod spec.serviceAccount"]
+	assert.Equal(t, collector.Gvk{Version: "v1", Kind: "Pod"}, serviceAccount.Gav)
+	assert.True(t, serviceAccount.Deprecated.IsZero())
+	assert.True(t, serviceAccount.Removed.IsZero())
+	assert.Equal(t, "serviceAccountName", serviceAccount.Replacement)
+	assert.Equal(t, collector.SourceSwagger, serviceAccount.Source)
+
+	hostAliases := byName["Deployment spec.template.spec.hostAliases"]
+	assert.Equal(t, collector.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}, hostAliases.Gav)
+	assert.Equal(t, "v1.25", hostAliases.Deprecated.String())
+	assert.Equal(t, "v1.28", hostAliases.Removed.String())
+	assert.Equal(t, "hostsFile", hostAliases.Replacement)
+
+	assert.Equal(t, "", byName["Pod spec.volumes[].gitRepo"].Replacement)
+}
+
+func TestFieldWalkerCycles(t *testing.T) {
+	ref := func(name string) map[string]interface{} {
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	}
+	schemas := map[string]interface{}{
+		"A": map[string]interface{}{"properties": map[string]interface{}{"b": ref("B")}},
+		"B": map[string]interface{}{"properties": map[string]interface{}{"back": ref("A"),
+			"x": map[string]interface{}{"description": "Deprecated: use y instead."}}},
+	}
+	w := fieldWalker{schemas: schemas, memo: make(map[string][]fieldPath), visiting: make(map[string]int)}
+	paths := func(found []fieldPath) []string {
+		got := make([]string, 0, len(found))
+		for _, fp := range found {
+			got = append(got, fp.path)
+		}
+		return got
+	}
+	found, cut := w.fields("A")
+	assert.Equal(t, []string{"b.x"}, paths(found))
+	assert.Equal(t, noCut, cut)
+	// B was cut short by the walk coming back to A, walked on its own it finds the fields of A as well
+	found, _ = w.fields("B")
+	assert.Equal(t, []string{"back.b.x", "x"}, paths(found))
+}
+
+func TestChildRef(t *testing.T) {
+	tests := []struct {
+		name       string
+		property   map[string]interface{}
+		wantRef    string
+		wantSuffix string
+	}{
+		{name: "ref", property: map[string]interface{}{"$ref": "#/definitions/io.k8s.api.core.v1.PodSpec"}, wantRef: "io.k8s.api.core.v1.PodSpec"},
+		{name: "openapi v3 allOf", property: map[string]interface{}{"allOf": []interface{}{
+			map[string]interface{}{"$ref": "#/components/schemas/io.k8s.api.core.v1.PodSpec"}}}, wantRef: "io.k8s.api.core.v1.PodSpec"},
+		{name: "array items", property: map[string]interface{}{"items": map[string]interface{}{"$ref": "#/definitions/v"}}, wantRef: "v", wantSuffix: "[]"},
+		{name: "map values", property: map[string]interface{}{"additionalProperties": map[string]interface{}{"$ref": "#/definitions/v"}}, wantRef: "v", wantSuffix: ".*"},
+		{name: "scalar", property: map[string]interface{}{"type": "string"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, suffix := childRef(tt.property)
+			assert.Equal(t, tt.wantRef, ref)
+			assert.Equal(t, tt.wantSuffix, suffix)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package swagger
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	localFilePrefix = "swagger-"
+	localFileSuffix = ".json"
+)
+
+//localDocuments read the swagger specs or openapi v3 documents stored on the local file system
+func (vc OpenAPISpec) localDocuments(k8sVer string) ([]map[string]interface{}, error) {
+	files, err := vc.localSwaggerFiles(k8sVer)
+	if vc.v3 {
+		files, err = vc.localOpenAPIV3Files(k8sVer)
+	}
+	if err != nil {
+		return nil, err
+	}
+	vList := make([]map[string]interface{}, 0, len(files))
+	for _, file := range files {
+		apiMap, err := readSwaggerFile(file)
+		if err != nil {
+			return nil, err
+		}
+		vList = append(vList, apiMap)
+	}
+	return vList, nil
+}
+
+//localSwaggerFiles resolve the swagger files to read from the local path
+func (vc OpenAPISpec) localSwaggerFiles(k8sVer string) ([]string, error) {
+	info, err := os.Stat(vc.localPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{vc.localPath}, nil
+	}
+	// kubernetes/kubernetes checkout
+	checkoutFile := filepath.Join(vc.localPath, filepath.FromSlash(fileURL))
+	if _, err := os.Stat(checkoutFile); err == nil {
+		return []string{checkoutFile}, nil
+	}
+	return matchingVersionFiles(vc.localPath, k8sVer, vc.tagPolicy)
+}
+
+//matchingVersionFiles list swagger-vX.Y.Z.json files in dir with version equal or greater than k8sVer, picked by the tag policy
+func matchingVersionFiles(dir string, k8sVer string, policy TagPolicy) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[*version.Version]string)
+	versions := make([]*version.Version, 0)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, localFilePrefix) || !strings.HasSuffix(name, localFileSuffix) {
+			continue
+		}
+		v, err := version.NewVersion(strings.TrimSuffix(strings.TrimPrefix(name, localFilePrefix), localFileSuffix))
+		if err != nil {
+			continue
+		}
+		paths[v] = filepath.Join(dir, name)
+		versions = append(versions, v)
+	}
+	selected, err := selectVersions(versions, k8sVer, policy)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no %sX.Y.Z%s files for version %s or above found in %s", localFilePrefix, localFileSuffix, k8sVer, dir)
+	}
+	files := make([]string, 0, len(selected))
+	for _, v := range selected {
+		files = append(files, paths[v])
+	}
+	return files, nil
+}
+
+func readSwaggerFile(path string) (map[string]interface{}, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeSwagger(f)
+}
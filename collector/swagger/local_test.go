@@ -0,0 +1,77 @@
+package swagger
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"sort"
+	"testing"
+)
+
+func TestCollectLocalOutdatedAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		k8sVer  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "single swagger file", path: "./testdata/fixture/k8s_v1.20.1.api.json", k8sVer: "v1.25.0", want: []string{
+			"io.k8s.api.admissionregistration.v1beta1.MutatingWebhookConfiguration", "io.k8s.api.rbac.v1alpha1.ClusterRole",
+			"io.k8s.api.rbac.v1alpha1.ClusterRoleBinding", "io.k8s.api.rbac.v1alpha1.RoleBinding"}},
+		{name: "versions directory from v1.20.0", path: "./testdata/fixture/versions", k8sVer: "v1.20.0", want: []string{
+			"io.k8s.api.admissionregistration.v1beta1.MutatingWebhookConfiguration", "io.k8s.api.batch.v1beta1.CronJob", "io.k8s.api.rbac.v1alpha1.ClusterRole",
+			"io.k8s.api.rbac.v1alpha1.ClusterRoleBinding", "io.k8s.api.rbac.v1alpha1.RoleBinding"}},
+		{name: "versions directory from v1.21.0", path: "./testdata/fixture/versions", k8sVer: "v1.21.0", want: []string{"io.k8s.api.batch.v1beta1.CronJob"}},
+		{name: "kubernetes checkout", path: "./testdata/fixture/kubernetes", k8sVer: "v1.20.0", want: []string{"io.k8s.api.batch.v1beta1.CronJob"}},
+		{name: "no matching versions", path: "./testdata/fixture/versions", k8sVer: "v1.30.0", wantErr: true},
+		{name: "missing path", path: "./testdata/fixture/missing", k8sVer: "v1.20.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sObjMap, err := NewLocalOpenAPISpec(tt.path).CollectOutdatedAPI(context.Background(), tt.k8sVer)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			got := make([]string, 0)
+			for key := range k8sObjMap {
+				got = append(got, key)
+			}
+			sort.Strings(got)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCollectSpecsReleases(t *testing.T) {
+	specs, err := NewLocalOpenAPISpec("./testdata/fixture/versions").CollectSpecs(context.Background(), "v1.20.0")
+	assert.NoError(t, err)
+	releases := make([]string, 0)
+	for _, r := range specs.Releases {
+		releases = append(releases, r.String())
+	}
+	assert.Equal(t, []string{"v1.20", "v1.21"}, releases)
+	assert.Equal(t, []string{"v1.20.1", "v1.21.0"}, specs.Tags)
+	assert.Equal(t, 5, len(specs.APIs))
+
+	// the apis of each release on their own, the rbac v1alpha1 apis are no longer in v1.21
+	byRelease, err := specs.ByRelease()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(byRelease))
+	assert.Equal(t, "v1.20", byRelease[0].Release.String())
+	assert.Equal(t, 4, len(byRelease[0].APIs))
+	assert.Contains(t, byRelease[0].APIs, "io.k8s.api.rbac.v1alpha1.ClusterRole")
+	assert.Equal(t, "v1.21", byRelease[1].Release.String())
+	assert.Equal(t, 1, len(byRelease[1].APIs))
+	assert.Contains(t, byRelease[1].APIs, "io.k8s.api.batch.v1beta1.CronJob")
+}
+
+func TestCollectReplacement(t *testing.T) {
+	k8sObjMap, err := NewLocalOpenAPISpec("./testdata/fixture/k8s_v1.20.1.api.json").CollectOutdatedAPI(context.Background(), "v1.20.0")
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Gvk{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, k8sObjMap["io.k8s.api.rbac.v1alpha1.ClusterRole"].Replacement)
+	assert.Equal(t, collector.Gvk{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"},
+		k8sObjMap["io.k8s.api.admissionregistration.v1beta1.MutatingWebhookConfiguration"].Replacement)
+}
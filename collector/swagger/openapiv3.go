@@ -0,0 +1,221 @@
+package swagger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	openAPIV3Dir        = "api/openapi-spec/v3"
+	openAPIV3FileSuffix = "_openapi.json"
+)
+
+//k8sContentsURL github contents api of the kubernetes repository, a variable so tests can point it at a fake server
+var k8sContentsURL = "https://api.github.com/repos/kubernetes/kubernetes/contents"
+
+//OpenAPIV3Server api server serving the openapi v3 document of every group version, implemented by kube.Client
+type OpenAPIV3Server interface {
+	//OpenAPIV3Paths return the server relative url of every served document keyed by group version path, e.g. apis/batch/v1
+	OpenAPIV3Paths() (map[string]string, error)
+	//OpenAPIV3Document return the document served at a server relative url
+	OpenAPIV3Document(serverRelativeURL string) (map[string]interface{}, error)
+}
+
+//contentEntry file listed by the github contents api
+type contentEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+//NewServerOpenAPISpec construct a new OpenAPISpec object reading the openapi v3 documents served by a live api server
+func NewServerOpenAPISpec(server OpenAPIV3Server) *OpenAPISpec {
+	return &OpenAPISpec{server: server, v3: true}
+}
+
+//WithOpenAPIV3 return a copy of the spec reading the per group version openapi v3 documents instead of the
+//monolithic swagger.json, limited to groupVersions (e.g. batch/v1beta1, v1) when any is given
+func (vc OpenAPISpec) WithOpenAPIV3(groupVersions ...string) *OpenAPISpec {
+	vc.v3 = true
+	vc.groupVersions = groupVersions
+	return &vc
+}
+
+//GroupVersionPath return the openapi v3 path of a group version, e.g. apis/batch/v1 for batch/v1 and api/v1 for v1
+func GroupVersionPath(groupVersion string) string {
+	if strings.Contains(groupVersion, "/") {
+		return "apis/" + groupVersion
+	}
+	return "api/" + groupVersion
+}
+
+//wantedGroupVersion report whether the document of a group version path is read, all of them without a filter
+func (vc OpenAPISpec) wantedGroupVersion(path string) bool {
+	if len(vc.groupVersions) == 0 {
+		return true
+	}
+	for _, gv := range vc.groupVersions {
+		if GroupVersionPath(gv) == path {
+			return true
+		}
+	}
+	return false
+}
+
+//wantedFile report whether an api/openapi-spec/v3 file holds a group version document to read
+func (vc OpenAPISpec) wantedFile(name string) bool {
+	if !strings.HasSuffix(name, openAPIV3FileSuffix) {
+		return false
+	}
+	path := strings.ReplaceAll(strings.TrimSuffix(name, openAPIV3FileSuffix), "__", "/")
+	return (strings.HasPrefix(path, "api/") || strings.HasPrefix(path, "apis/")) && vc.wantedGroupVersion(path)
+}
+
+//openAPIV3Documents download the openapi v3 documents of every selected release tag
+func (vc OpenAPISpec) openAPIV3Documents(ctx context.Context, tags []string) ([]map[string]interface{}, error) {
+	docs := make([]document, 0)
+	for _, tag := range tags {
+		files, err := vc.listOpenAPIV3Files(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			tag, file := tag, file
+			docs = append(docs, document{name: "openapi-v3 " + tag + " " + file, fetch: func(ctx context.Context) (map[string]interface{}, error) {
+				// released tags never change, their documents are cached forever
+				res, err := vc.fetch(ctx, fmt.Sprintf("%s/%s/%s/%s", baseURL, tag, openAPIV3Dir, file), "openapi-v3/"+tag+"/"+file, true)
+				if err != nil {
+					return nil, err
+				}
+				return decodeSwagger(res)
+			}})
+		}
+	}
+	return vc.fetchDocuments(ctx, docs)
+}
+
+//listOpenAPIV3Files list the group version documents of api/openapi-spec/v3 at tag, sorted by name
+func (vc OpenAPISpec) listOpenAPIV3Files(ctx context.Context, tag string) ([]string, error) {
+	url := fmt.Sprintf("%s/%s?ref=%s", k8sContentsURL, openAPIV3Dir, tag)
+	res, err := vc.fetch(ctx, url, "github/kubernetes-openapi-v3/"+tag+".json", true)
+	if err != nil {
+		return nil, err
+	}
+	var entries []contentEntry
+	if err := json.NewDecoder(res).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("openapi v3 files of %s: %w", tag, err)
+	}
+	files := make([]string, 0)
+	for _, e := range entries {
+		if e.Type == "file" && vc.wantedFile(e.Name) {
+			files = append(files, e.Name)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+//serverOpenAPIV3Documents read the openapi v3 documents served by the api server
+func (vc OpenAPISpec) serverOpenAPIV3Documents(ctx context.Context) ([]map[string]interface{}, error) {
+	paths, err := vc.server.OpenAPIV3Paths()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(paths))
+	for path := range paths {
+		if (strings.HasPrefix(path, "api/") || strings.HasPrefix(path, "apis/")) && vc.wantedGroupVersion(path) {
+			names = append(names, path)
+		}
+	}
+	sort.Strings(names)
+	docs := make([]document, 0, len(names))
+	for _, path := range names {
+		url := paths[path]
+		docs = append(docs, document{name: "openapi-v3 " + path, fetch: func(ctx context.Context) (map[string]interface{}, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return vc.server.OpenAPIV3Document(url)
+		}})
+	}
+	return vc.fetchDocuments(ctx, docs)
+}
+
+//localOpenAPIV3Files resolve the openapi v3 documents to read from the local path: a document, a kubernetes/kubernetes
+//checkout, a directory of documents or a directory of vX.Y.Z directories of documents picked by the tag policy
+func (vc OpenAPISpec) localOpenAPIV3Files(k8sVer string) ([]string, error) {
+	info, err := os.Stat(vc.localPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{vc.localPath}, nil
+	}
+	// kubernetes/kubernetes checkout
+	checkoutDir := filepath.Join(vc.localPath, filepath.FromSlash(openAPIV3Dir))
+	if _, err := os.Stat(checkoutDir); err == nil {
+		return vc.openAPIV3DirFiles(checkoutDir)
+	}
+	files, err := vc.openAPIV3DirFiles(vc.localPath)
+	if err != nil || len(files) > 0 {
+		return files, err
+	}
+	return vc.openAPIV3VersionDirFiles(k8sVer)
+}
+
+//openAPIV3DirFiles list the wanted group version documents of dir
+func (vc OpenAPISpec) openAPIV3DirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, e := range entries {
+		if !e.IsDir() && vc.wantedFile(e.Name()) {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+//openAPIV3VersionDirFiles list the wanted documents of the vX.Y.Z directories of the local path picked by the tag policy
+func (vc OpenAPISpec) openAPIV3VersionDirFiles(k8sVer string) ([]string, error) {
+	entries, err := os.ReadDir(vc.localPath)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[*version.Version]string)
+	versions := make([]*version.Version, 0)
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "v") {
+			continue
+		}
+		v, err := version.NewVersion(e.Name())
+		if err != nil {
+			continue
+		}
+		dirs[v] = filepath.Join(vc.localPath, e.Name())
+		versions = append(versions, v)
+	}
+	selected, err := selectVersions(versions, k8sVer, vc.tagPolicy)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, v := range selected {
+		dirFiles, err := vc.openAPIV3DirFiles(dirs[v])
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, dirFiles...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no openapi v3 documents for version %s or above found in %s", k8sVer, vc.localPath)
+	}
+	return files, nil
+}
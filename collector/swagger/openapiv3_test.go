@@ -0,0 +1,194 @@
+package swagger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func outdatedKeys(apis map[string]*collector.OutdatedAPI) []string {
+	keys := make([]string, 0, len(apis))
+	for key := range apis {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestGroupVersionPath(t *testing.T) {
+	assert.Equal(t, "apis/batch/v1beta1", GroupVersionPath("batch/v1beta1"))
+	assert.Equal(t, "api/v1", GroupVersionPath("v1"))
+}
+
+func TestCollectLocalOpenAPIV3(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		k8sVer        string
+		groupVersions []string
+		want          []string
+		wantErr       bool
+	}{
+		{name: "single document", path: "./testdata/fixture/openapi-v3/v1.24.0/apis__policy__v1beta1_openapi.json", k8sVer: "v1.20.0",
+			want: []string{"io.k8s.api.policy.v1beta1.PodDisruptionBudget"}},
+		{name: "documents directory", path: "./testdata/fixture/openapi-v3/v1.24.0", k8sVer: "v1.20.0",
+			want: []string{"io.k8s.api.batch.v1beta1.CronJob", "io.k8s.api.policy.v1beta1.PodDisruptionBudget"}},
+		{name: "documents directory limited to group versions", path: "./testdata/fixture/openapi-v3/v1.24.0", k8sVer: "v1.20.0",
+			groupVersions: []string{"batch/v1beta1", "v1"}, want: []string{"io.k8s.api.batch.v1beta1.CronJob"}},
+		{name: "versions directory from v1.20.0", path: "./testdata/fixture/openapi-v3", k8sVer: "v1.20.0", want: []string{
+			"io.k8s.api.batch.v1beta1.CronJob", "io.k8s.api.flowcontrol.v1beta2.FlowSchema", "io.k8s.api.policy.v1beta1.PodDisruptionBudget"}},
+		{name: "versions directory from v1.25.0", path: "./testdata/fixture/openapi-v3", k8sVer: "v1.25.0",
+			want: []string{"io.k8s.api.flowcontrol.v1beta2.FlowSchema"}},
+		{name: "kubernetes checkout", path: "./testdata/fixture/kubernetes", k8sVer: "v1.20.0", want: []string{"io.k8s.api.batch.v1beta1.CronJob"}},
+		{name: "no matching versions", path: "./testdata/fixture/openapi-v3", k8sVer: "v1.30.0", wantErr: true},
+		{name: "missing path", path: "./testdata/fixture/missing", k8sVer: "v1.20.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apis, err := NewLocalOpenAPISpec(tt.path).WithOpenAPIV3(tt.groupVersions...).CollectOutdatedAPI(context.Background(), tt.k8sVer)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, outdatedKeys(apis))
+		})
+	}
+}
+
+func TestCollectOpenAPIV3Record(t *testing.T) {
+	apis, err := NewLocalOpenAPISpec("./testdata/fixture/openapi-v3/v1.24.0").WithOpenAPIV3("batch/v1beta1").CollectOutdatedAPI(context.Background(), "v1.20.0")
+	assert.NoError(t, err)
+	cronJob := apis["io.k8s.api.batch.v1beta1.CronJob"]
+	assert.Equal(t, collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}, cronJob.Gav)
+	assert.Equal(t, "v1.21", cronJob.Deprecated.String())
+	assert.Equal(t, "v1.25", cronJob.Removed.String())
+	assert.Equal(t, collector.SourceOpenAPIV3, cronJob.Source)
+	assert.Equal(t, collector.Gvk{Group: "batch", Version: "v1", Kind: "CronJob"}, cronJob.Replacement)
+}
+
+//fakeContents serve the github contents listing and the raw documents of the openapi-v3 fixture versions directory
+type fakeContents struct {
+	mu         sync.Mutex
+	downloaded []string
+}
+
+func (f *fakeContents) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dir := "./testdata/fixture/openapi-v3"
+	if strings.HasPrefix(r.URL.Path, "/contents/") {
+		entries, err := os.ReadDir(filepath.Join(dir, r.URL.Query().Get("ref")))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		listing := make([]string, 0)
+		for _, e := range entries {
+			listing = append(listing, fmt.Sprintf(`{"name": %q, "type": "file"}`, e.Name()))
+		}
+		listing = append(listing, `{"name": "apis__apps__v1_openapi.json.d", "type": "dir"}`)
+		fmt.Fprintf(w, "[%s]", strings.Join(listing, ","))
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	f.mu.Lock()
+	f.downloaded = append(f.downloaded, parts[0]+"/"+parts[len(parts)-1])
+	f.mu.Unlock()
+	http.ServeFile(w, r, filepath.Join(dir, parts[0], parts[len(parts)-1]))
+}
+
+func withFakeContents(t *testing.T, f *fakeContents) {
+	srv := httptest.NewServer(f)
+	originalBase, originalContents := baseURL, k8sContentsURL
+	baseURL, k8sContentsURL = srv.URL, srv.URL+"/contents"
+	t.Cleanup(func() {
+		baseURL, k8sContentsURL = originalBase, originalContents
+		srv.Close()
+	})
+}
+
+//collectOpenAPIV3 collect outdated apis from the openapi v3 documents of tags
+func collectOpenAPIV3(spec *OpenAPISpec, tags []string) (map[string]*collector.OutdatedAPI, error) {
+	docs, err := spec.openAPIV3Documents(context.Background(), tags)
+	if err != nil {
+		return nil, err
+	}
+	return spec.versionToDetails(docs)
+}
+
+func TestCollectOpenAPIV3(t *testing.T) {
+	t.Run("all group versions", func(t *testing.T) {
+		f := &fakeContents{}
+		withFakeContents(t, f)
+		apis, err := collectOpenAPIV3(NewOpenAPISpec().WithClient(noRetryClient(t)).WithOpenAPIV3(), []string{"v1.24.0", "v1.26.0"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"io.k8s.api.batch.v1beta1.CronJob", "io.k8s.api.flowcontrol.v1beta2.FlowSchema",
+			"io.k8s.api.policy.v1beta1.PodDisruptionBudget"}, outdatedKeys(apis))
+		assert.Len(t, f.downloaded, 6)
+	})
+	t.Run("only the requested group versions are downloaded", func(t *testing.T) {
+		f := &fakeContents{}
+		withFakeContents(t, f)
+		apis, err := collectOpenAPIV3(NewOpenAPISpec().WithClient(noRetryClient(t)).WithOpenAPIV3("batch/v1beta1"), []string{"v1.24.0", "v1.26.0"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"io.k8s.api.batch.v1beta1.CronJob"}, outdatedKeys(apis))
+		assert.Equal(t, []string{"v1.24.0/apis__batch__v1beta1_openapi.json"}, f.downloaded)
+	})
+	t.Run("missing listing", func(t *testing.T) {
+		withFakeContents(t, &fakeContents{})
+		_, err := collectOpenAPIV3(NewOpenAPISpec().WithClient(noRetryClient(t)).WithOpenAPIV3(), []string{"v1.30.0"})
+		var httpErr *collector.HTTPError
+		assert.True(t, errors.As(err, &httpErr))
+		assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	})
+}
+
+//fakeServer api server serving the documents of an openapi-v3 fixture version directory
+type fakeServer struct {
+	dir string
+	err error
+}
+
+func (s fakeServer) OpenAPIV3Paths() (map[string]string, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]string{"version": "/openapi/v3/version?hash=1"}
+	for _, e := range entries {
+		path := strings.ReplaceAll(strings.TrimSuffix(e.Name(), openAPIV3FileSuffix), "__", "/")
+		paths[path] = "/openapi/v3/" + path + "?hash=1"
+	}
+	return paths, nil
+}
+
+func (s fakeServer) OpenAPIV3Document(serverRelativeURL string) (map[string]interface{}, error) {
+	path := strings.TrimPrefix(strings.Split(serverRelativeURL, "?")[0], "/openapi/v3/")
+	return readSwaggerFile(filepath.Join(s.dir, strings.ReplaceAll(path, "/", "__")+openAPIV3FileSuffix))
+}
+
+func TestCollectServerOpenAPIV3(t *testing.T) {
+	server := fakeServer{dir: "./testdata/fixture/openapi-v3/v1.24.0"}
+	apis, err := NewServerOpenAPISpec(server).CollectOutdatedAPI(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"io.k8s.api.batch.v1beta1.CronJob", "io.k8s.api.policy.v1beta1.PodDisruptionBudget"}, outdatedKeys(apis))
+
+	apis, err = NewServerOpenAPISpec(server).WithOpenAPIV3("policy/v1beta1").CollectOutdatedAPI(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"io.k8s.api.policy.v1beta1.PodDisruptionBudget"}, outdatedKeys(apis))
+
+	_, err = NewServerOpenAPISpec(fakeServer{err: errors.New("forbidden")}).CollectOutdatedAPI(context.Background(), "")
+	assert.EqualError(t, err, "forbidden")
+}
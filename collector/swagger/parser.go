@@ -1,24 +1,34 @@
 package swagger
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/hashicorp/go-version"
+	"io"
+	"k8s-outdated/cache"
 	"k8s-outdated/collector"
-	"net/http"
 	"strings"
+	"sync"
 )
 
 const (
-	k8sTagsURL = "https://api.github.com/repos/kubernetes/kubernetes/git/refs/tags"
-	baseURL    = "https://raw.githubusercontent.com/kubernetes/kubernetes"
-	fileURL    = "api/openapi-spec/swagger.json"
+	fileURL = "api/openapi-spec/swagger.json"
+
+	//DefaultConcurrency number of swagger specs downloaded at once
+	DefaultConcurrency = 4
 
 	servedIn     = "served in"
 	removedIn    = "removal in"
 	deprecatedIn = "deprecated in"
 )
 
+//download locations, variables so tests can point them at a fake server
+var (
+	k8sTagsURL = "https://api.github.com/repos/kubernetes/kubernetes/git/matching-refs/tags/v1."
+	baseURL    = "https://raw.githubusercontent.com/kubernetes/kubernetes"
+)
+
 //Reference version ref object
 type Reference struct {
 	Ref    string `json:"ref"`
@@ -28,6 +38,17 @@ type Reference struct {
 
 //OpenAPISpec open api spec object
 type OpenAPISpec struct {
+	localPath   string
+	cache       *cache.Cache
+	concurrency int
+	progress    io.Writer
+	warn        collector.WarningFunc
+	client      *collector.Client
+	tagPolicy   TagPolicy
+	// v3 read the per group version openapi v3 documents, limited to groupVersions when any is set
+	v3            bool
+	groupVersions []string
+	server        OpenAPIV3Server
 }
 
 //NewOpenAPISpec construct a new OpenAPISpec object
@@ -35,68 +56,266 @@ func NewOpenAPISpec() *OpenAPISpec {
 	return &OpenAPISpec{}
 }
 
-//CollectOutdatedAPI collect removed api version from k8s swagger api
-func (vc OpenAPISpec) CollectOutdatedAPI(k8sVer string) (map[string]*collector.OutdatedAPI, error) {
-	r, err := http.Get(k8sTagsURL)
+//NewLocalOpenAPISpec construct a new OpenAPISpec object reading swagger specs from a local path instead of github.
+//path may be a swagger.json file, a directory of swagger-vX.Y.Z.json files or a kubernetes/kubernetes checkout
+func NewLocalOpenAPISpec(path string) *OpenAPISpec {
+	return &OpenAPISpec{localPath: path}
+}
+
+//NewCachedOpenAPISpec construct a new OpenAPISpec object keeping the downloaded tag list and swagger specs in c
+func NewCachedOpenAPISpec(c *cache.Cache) *OpenAPISpec {
+	return &OpenAPISpec{cache: c}
+}
+
+//WithConcurrency return a copy of the spec downloading up to n swagger specs at once
+func (vc OpenAPISpec) WithConcurrency(n int) *OpenAPISpec {
+	vc.concurrency = n
+	return &vc
+}
+
+//WithClient return a copy of the spec downloading through client instead of collector.DefaultClient
+func (vc OpenAPISpec) WithClient(client *collector.Client) *OpenAPISpec {
+	vc.client = client
+	return &vc
+}
+
+//WithTagPolicy return a copy of the spec reading the swagger specs of the release tags picked by policy, default LatestPatch
+func (vc OpenAPISpec) WithTagPolicy(policy TagPolicy) *OpenAPISpec {
+	vc.tagPolicy = policy
+	return &vc
+}
+
+//WithProgress return a copy of the spec reporting every downloaded swagger spec to w
+func (vc OpenAPISpec) WithProgress(w io.Writer) *OpenAPISpec {
+	vc.progress = w
+	return &vc
+}
+
+//WithWarnings return a copy of the spec reporting the versions it cannot parse to warn instead of guessing them
+func (vc OpenAPISpec) WithWarnings(warn collector.WarningFunc) *OpenAPISpec {
+	vc.warn = warn
+	return &vc
+}
+
+//Name return the source of the spec apis, openapi-v3 when the openapi v3 documents are read
+func (vc OpenAPISpec) Name() string {
+	if vc.v3 || vc.server != nil {
+		return collector.SourceOpenAPIV3
+	}
+	return collector.SourceSwagger
+}
+
+//Priority return the merge priority of the spec
+func (vc OpenAPISpec) Priority() int {
+	return collector.PrioritySwagger
+}
+
+//Collect collect the outdated apis of the specs of opts.K8sVersion onward, sorted by group, version and kind
+func (vc OpenAPISpec) Collect(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+	apis, err := vc.CollectOutdatedAPI(ctx, opts.K8sVersion)
 	if err != nil {
 		return nil, err
 	}
-	var refs []Reference
-	err = json.NewDecoder(r.Body).Decode(&refs)
+	return collector.SortedAPIs(apis), nil
+}
+
+//CollectOutdatedAPI collect removed api version from k8s swagger api, downloads are aborted when ctx is done
+func (vc OpenAPISpec) CollectOutdatedAPI(ctx context.Context, k8sVer string) (map[string]*collector.OutdatedAPI, error) {
+	specs, err := vc.CollectSpecs(ctx, k8sVer)
+	if err != nil {
+		return nil, err
+	}
+	return specs.APIs, nil
+}
+
+//Specs data read from the spec documents of a collect
+type Specs struct {
+	// APIs are the outdated apis by definition name
+	APIs   map[string]*collector.OutdatedAPI
+	Fields []*collector.DeprecatedField
+	// Releases are the k8s releases of the documents read, in release order, empty for unversioned documents
+	Releases []collector.KubeVersion
+	// Tags are the release tags of the documents read, e.g. v1.25.16, in release order
+	Tags []string
+	docs []map[string]interface{}
+}
+
+//ReleaseSpecs data read from the spec documents of one k8s release
+type ReleaseSpecs struct {
+	// Release is zero for unversioned documents
+	Release collector.KubeVersion
+	APIs    map[string]*collector.OutdatedAPI
+	Fields  []*collector.DeprecatedField
+}
+
+//ByRelease return the data of the documents of each release on their own, in release order. The warnings were reported
+//by the collect and are not reported again
+func (s Specs) ByRelease() ([]ReleaseSpecs, error) {
+	releases := make([]ReleaseSpecs, 0, len(s.Releases))
+	for start := 0; start < len(s.docs); {
+		release, end := documentRelease(s.docs[start]), start+1
+		for end < len(s.docs) && documentRelease(s.docs[end]) == release {
+			end++
+		}
+		apis, err := OpenAPISpec{}.versionToDetails(s.docs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, ReleaseSpecs{Release: release, APIs: apis, Fields: deprecatedFields(s.docs[start:end], nil)})
+		start = end
+	}
+	return releases, nil
+}
+
+//CollectSpecs collect the removed api versions, the deprecated fields and the releases from the same spec documents
+func (vc OpenAPISpec) CollectSpecs(ctx context.Context, k8sVer string) (*Specs, error) {
+	docs, err := vc.documents(ctx, k8sVer)
 	if err != nil {
 		return nil, err
 	}
-	v1, err := version.NewVersion(k8sVer)
+	apis, err := vc.versionToDetails(docs)
 	if err != nil {
 		return nil, err
 	}
-	kVer, err := vc.getMatchingVersions(refs, err, v1)
+	return &Specs{APIs: apis, Fields: deprecatedFields(docs, vc.warn), Releases: documentReleases(docs), Tags: documentTags(docs), docs: docs}, nil
+}
+
+//documents read the swagger specs or openapi v3 documents of k8sVer onward, in release order
+func (vc OpenAPISpec) documents(ctx context.Context, k8sVer string) ([]map[string]interface{}, error) {
+	if vc.server != nil {
+		return vc.serverOpenAPIV3Documents(ctx)
+	}
+	if len(vc.localPath) > 0 {
+		return vc.localDocuments(k8sVer)
+	}
+	refs, err := vc.listTags(ctx)
 	if err != nil {
 		return nil, err
 	}
-	vList, err := vc.fetchSwaggerVersions(kVer)
+	kVer, err := selectTags(refs, k8sVer, vc.tagPolicy)
 	if err != nil {
 		return nil, err
 	}
-	return vc.versionToDetails(vList)
+	if vc.v3 {
+		return vc.openAPIV3Documents(ctx, kVer)
+	}
+	return vc.fetchSwaggerVersions(ctx, kVer)
 }
 
-func (vc OpenAPISpec) getMatchingVersions(refs []Reference, err error, v1 *version.Version) ([]string, error) {
-	kVer := make([]string, 0)
-	for _, r := range refs {
-		if strings.Contains(r.Ref, "-rc") ||
-			strings.Contains(r.Ref, "-alpha") ||
-			strings.Contains(r.Ref, "-beta") {
-			continue
-		}
-		v := strings.Replace(r.Ref, "refs/tags/", "", -1)
-		v2, newVerErr := version.NewVersion(strings.Replace(v, "v", "", -1))
-		if newVerErr != nil {
-			return nil, err
-		}
-		if v1.LessThanOrEqual(v2) {
-			kVer = append(kVer, v)
-		}
+//document spec document downloaded by the worker pool, name identifies it in errors and progress reports
+type document struct {
+	name  string
+	fetch func(ctx context.Context) (map[string]interface{}, error)
+}
+
+//fetchSwaggerVersions download the swagger spec of every version, keeping the order of versions
+func (vc OpenAPISpec) fetchSwaggerVersions(ctx context.Context, versions []string) ([]map[string]interface{}, error) {
+	docs := make([]document, 0, len(versions))
+	for _, kv := range versions {
+		kv := kv
+		docs = append(docs, document{name: "swagger " + kv, fetch: func(ctx context.Context) (map[string]interface{}, error) {
+			return vc.fetchSwagger(ctx, kv)
+		}})
 	}
-	return kVer, nil
+	return vc.fetchDocuments(ctx, docs)
 }
 
+//fetchDocuments download every document with a bounded worker pool,
+//the results keep the order of docs and the first failure cancels the remaining downloads
 //gosec -exclude=G303
-func (vc OpenAPISpec) fetchSwaggerVersions(versions []string) ([]map[string]interface{}, error) {
-	swaggerVersionsData := make([]map[string]interface{}, 0)
-	for _, kv := range versions {
-		res, err := http.Get(buildSwaggerURL(kv))
-		if err != nil {
-			return nil, err
+func (vc OpenAPISpec) fetchDocuments(ctx context.Context, docs []document) ([]map[string]interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	workers := vc.concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+	data := make([]map[string]interface{}, len(docs))
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		done     int
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				apiMap, err := docs[i].fetch(ctx)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("%s: %w", docs[i].name, err)
+						cancel()
+					}
+				} else {
+					data[i] = apiMap
+					done++
+					vc.reportProgress(docs[i].name, done, len(docs))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for i := range docs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
 		}
-		var apiMap map[string]interface{}
-		err = json.NewDecoder(res.Body).Decode(&apiMap)
-		if err != nil {
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (vc OpenAPISpec) fetchSwagger(ctx context.Context, kv string) (map[string]interface{}, error) {
+	// released tags never change, their specs are cached forever
+	res, err := vc.fetch(ctx, buildSwaggerURL(kv), "swagger/"+kv+".json", true)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSwagger(res)
+}
+
+func (vc OpenAPISpec) reportProgress(name string, done int, total int) {
+	if vc.progress != nil {
+		fmt.Fprintf(vc.progress, "fetched %s (%d/%d)\n", name, done, total)
+	}
+}
+
+func decodeSwagger(r io.Reader) (map[string]interface{}, error) {
+	var apiMap map[string]interface{}
+	err := json.NewDecoder(r).Decode(&apiMap)
+	if err != nil {
+		return nil, err
+	}
+	return apiMap, nil
+}
+
+//fetch download url, through the cache when one is set
+func (vc OpenAPISpec) fetch(ctx context.Context, url string, key string, immutable bool) (io.Reader, error) {
+	if vc.cache != nil {
+		data, err := vc.cache.Fetch(ctx, url, key, immutable)
+		if err = cache.ReportStale(vc.progress, err); err != nil {
 			return nil, err
 		}
-		swaggerVersionsData = append(swaggerVersionsData, apiMap)
+		return bytes.NewReader(data), nil
+	}
+	data, err := vc.httpClient().Get(ctx, url)
+	if err != nil {
+		return nil, err
 	}
-	return swaggerVersionsData, nil
+	return bytes.NewReader(data), nil
 }
 
 func buildSwaggerURL(version string) string {
@@ -109,11 +328,11 @@ func (vc OpenAPISpec) versionToDetails(swaggerData []map[string]interface{}) (ma
 	}
 	gavMap := make(map[string]*collector.OutdatedAPI)
 	for _, data := range swaggerData {
-		p, ok := data["definitions"]
+		p, source, ok := schemaDefinitions(data)
 		if !ok {
-			return map[string]*collector.OutdatedAPI{}, nil
+			continue
 		}
-		m, err := vc.findOutDatedAPIVersion(p, gavMap)
+		m, err := vc.findOutDatedAPIVersion(p, source, gavMap)
 		if err != nil {
 			return m, err
 		}
@@ -121,8 +340,25 @@ func (vc OpenAPISpec) versionToDetails(swaggerData []map[string]interface{}) (ma
 	return gavMap, nil
 }
 
-func (vc OpenAPISpec) findOutDatedAPIVersion(p interface{}, gavMap map[string]*collector.OutdatedAPI) (map[string]*collector.OutdatedAPI, error) {
-	for key, val := range p.(map[string]interface{}) {
+//schemaDefinitions return the schemas of a swagger 2.0 (definitions) or openapi v3 (components.schemas) document and its source
+func schemaDefinitions(data map[string]interface{}) (interface{}, string, bool) {
+	if p, ok := data["definitions"]; ok {
+		return p, collector.SourceSwagger, true
+	}
+	components, ok := data["components"].(map[string]interface{})
+	if !ok {
+		return nil, "", false
+	}
+	p, ok := components["schemas"]
+	return p, collector.SourceOpenAPIV3, ok
+}
+
+func (vc OpenAPISpec) findOutDatedAPIVersion(p interface{}, source string, gavMap map[string]*collector.OutdatedAPI) (map[string]*collector.OutdatedAPI, error) {
+	schemas, ok := p.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	for key, val := range schemas {
 		mval, ok := val.(map[string]interface{})
 		if !ok {
 			continue
@@ -142,8 +378,11 @@ func (vc OpenAPISpec) findOutDatedAPIVersion(p interface{}, gavMap map[string]*c
 		if !ok {
 			continue
 		}
-		dep, rem := vc.depRemovedVersion(desc)
-		object := collector.OutdatedAPI{Description: desc, Gav: ga[0], Deprecated: dep, Removed: rem}
+		dep, rem := kubeVersions(desc, vc.warn, collector.Warning{Source: source, Gav: ga[0]})
+		object := collector.OutdatedAPI{Description: desc, Gav: ga[0], Deprecated: dep, Removed: rem, Source: source}
+		if replacement, ok := collector.FindReplacementAPI(desc, ga[0].Kind); ok {
+			object.Replacement = replacement
+		}
 		if vc.isOutdatedAPIDataIncomplete(object) {
 			continue
 		}
@@ -153,7 +392,7 @@ func (vc OpenAPISpec) findOutDatedAPIVersion(p interface{}, gavMap map[string]*c
 }
 
 func (vc OpenAPISpec) isOutdatedAPIDataIncomplete(object collector.OutdatedAPI) bool {
-	return (len(object.Deprecated) == 0 && len(object.Removed) == 0) || len(object.Gav.Kind) == 0 || len(object.Gav.Version) == 0 || len(object.Gav.Group) == 0
+	return (object.Deprecated.IsZero() && object.Removed.IsZero()) || len(object.Gav.Kind) == 0 || len(object.Gav.Version) == 0 || len(object.Gav.Group) == 0
 }
 
 func (vc OpenAPISpec) parseSwaggerData(gav interface{}) ([]collector.Gvk, error) {
@@ -183,3 +422,22 @@ func (vc OpenAPISpec) depRemovedVersion(desc string) (string, string) {
 	}
 	return dep, rem
 }
+
+//kubeVersions parse the deprecated and removed versions of a description, a version which does not parse is
+//reported to warn with the details of w and left unknown
+func kubeVersions(desc string, warn collector.WarningFunc, w collector.Warning) (collector.KubeVersion, collector.KubeVersion) {
+	field := w.Field
+	parse := func(name string, value string) collector.KubeVersion {
+		if len(value) == 0 {
+			return collector.KubeVersion{}
+		}
+		v, err := collector.ParseKubeVersion(value)
+		if err != nil {
+			w.Field, w.Value, w.Err = strings.TrimPrefix(field+" "+name, " "), value, err
+			warn.Warn(w)
+		}
+		return v
+	}
+	dep, rem := OpenAPISpec{}.depRemovedVersion(desc)
+	return parse("deprecated", dep), parse("removed", rem)
+}
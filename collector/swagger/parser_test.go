@@ -18,8 +18,8 @@ func TestMapResources(t *testing.T) {
 	}{
 		{name: "k8s api v1.20.1 apis", filePath: "./testdata/fixture/k8s_v1.20.1.api.json", values: []string{
 			"io.k8s.api.rbac.v1alpha1.ClusterRoleBinding", "io.k8s.api.rbac.v1alpha1.RoleBinding"}, ExpectedData: []*collector.OutdatedAPI{
-			{Deprecated: "v1.17", Removed: "v1.22", Gav: collector.Gvk{Group: "rbac.authorization.k8s.io", Version: "v1alpha1", Kind: "ClusterRoleBinding"}},
-			{Deprecated: "v1.17", Removed: "v1.22", Gav: collector.Gvk{Group: "rbac.authorization.k8s.io", Version: "v1alpha1", Kind: "RoleBinding"}}}},
+			{Deprecated: collector.MustParseKubeVersion("v1.17"), Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Group: "rbac.authorization.k8s.io", Version: "v1alpha1", Kind: "ClusterRoleBinding"}},
+			{Deprecated: collector.MustParseKubeVersion("v1.17"), Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Group: "rbac.authorization.k8s.io", Version: "v1alpha1", Kind: "RoleBinding"}}}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -40,3 +40,32 @@ func TestMapResources(t *testing.T) {
 		})
 	}
 }
+
+func TestMalformedVersionWarnings(t *testing.T) {
+	schema := func(kind string, description string) map[string]interface{} {
+		return map[string]interface{}{"description": description,
+			"x-kubernetes-group-version-kind": []interface{}{map[string]interface{}{"group": "batch", "version": "v1beta1", "kind": kind}}}
+	}
+	doc := map[string]interface{}{"definitions": map[string]interface{}{
+		"io.k8s.api.batch.v1beta1.CronJob": schema("CronJob", "CronJob represents a single cron job. Deprecated in v1.21, planned for removal in v1.25."),
+		"io.k8s.api.batch.v1beta1.Job":     schema("Job", "Job is deprecated in favor of batch/v1 Job, planned for removal in the next release."),
+		"io.k8s.api.batch.v1beta1.JobList": schema("JobList", "JobList is deprecated in 1.20; no longer served in v1.22)"),
+	}}
+	warnings := make([]collector.Warning, 0)
+	apis, err := NewOpenAPISpec().WithWarnings(func(w collector.Warning) {
+		warnings = append(warnings, w)
+	}).versionToDetails([]map[string]interface{}{doc})
+	assert.NoError(t, err)
+	assert.Len(t, apis, 2)
+	assert.Equal(t, collector.MustParseKubeVersion("v1.21"), apis["io.k8s.api.batch.v1beta1.CronJob"].Deprecated)
+	assert.Equal(t, collector.MustParseKubeVersion("v1.25"), apis["io.k8s.api.batch.v1beta1.CronJob"].Removed)
+	assert.Equal(t, collector.MustParseKubeVersion("v1.20"), apis["io.k8s.api.batch.v1beta1.JobList"].Deprecated)
+	assert.Equal(t, collector.MustParseKubeVersion("v1.22"), apis["io.k8s.api.batch.v1beta1.JobList"].Removed)
+	// "deprecated in favor of" names no version, the removed version of Job does not parse and is reported instead of
+	// collected with a garbage version
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "Job"}, warnings[0].Gav)
+	assert.Equal(t, collector.SourceSwagger, warnings[0].Source)
+	assert.Equal(t, "removed", warnings[0].Field)
+	assert.Equal(t, "the", warnings[0].Value)
+}
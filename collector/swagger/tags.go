@@ -0,0 +1,163 @@
+package swagger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"io"
+	"k8s-outdated/cache"
+	"k8s-outdated/collector"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//TagPolicy select which release tags of every minor version the swagger specs are read from
+type TagPolicy string
+
+//Supported tag policies, the api lifecycle of all patch releases of a minor version is identical
+const (
+	LatestPatch TagPolicy = "latest-patch"
+	FirstGA     TagPolicy = "first-ga"
+	AllTags     TagPolicy = "all"
+)
+
+const tagsPerPage = 100
+
+var nextLinkRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+//TagPolicies return the supported tag policy names
+func TagPolicies() []string {
+	return []string{string(LatestPatch), string(FirstGA), string(AllTags)}
+}
+
+//ParseTagPolicy parse tag policy name
+func ParseTagPolicy(name string) (TagPolicy, error) {
+	for _, p := range TagPolicies() {
+		if strings.EqualFold(p, name) {
+			return TagPolicy(p), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported tag policy %q, supported policies: %s", name, strings.Join(TagPolicies(), ", "))
+}
+
+//listTags list the v1.x release tags of kubernetes, following the github pagination
+func (vc OpenAPISpec) listTags(ctx context.Context) ([]Reference, error) {
+	refs := make([]Reference, 0)
+	url := fmt.Sprintf("%s?per_page=%d", k8sTagsURL, tagsPerPage)
+	for page := 1; len(url) > 0; page++ {
+		data, link, err := vc.fetchPage(ctx, url, fmt.Sprintf("github/kubernetes-tags/page-%d.json", page))
+		if err != nil {
+			return nil, err
+		}
+		var pageRefs []Reference
+		if err := json.Unmarshal(data, &pageRefs); err != nil {
+			return nil, fmt.Errorf("decode tags page %d: %w", page, err)
+		}
+		refs = append(refs, pageRefs...)
+		url = nextLink(link)
+	}
+	return refs, nil
+}
+
+//fetchPage download a page of a paginated github api and return its body and Link header
+func (vc OpenAPISpec) fetchPage(ctx context.Context, url string, key string) ([]byte, string, error) {
+	if vc.cache != nil {
+		data, entry, err := vc.cache.FetchEntry(ctx, url, key, false)
+		return data, entry.Link, cache.ReportStale(vc.progress, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := vc.httpClient().Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, res.Header.Get("Link"), nil
+}
+
+//nextLink return the url of the next page from a Link header, empty on the last page
+func nextLink(link string) string {
+	if m := nextLinkRe.FindStringSubmatch(link); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+//selectTags return the release tags from k8sVer onward picked by the tag policy, in version order
+func selectTags(refs []Reference, k8sVer string, policy TagPolicy) ([]string, error) {
+	versions := make([]*version.Version, 0, len(refs))
+	for _, r := range refs {
+		v, err := version.NewVersion(strings.TrimPrefix(r.Ref, "refs/tags/"))
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	selected, err := selectVersions(versions, k8sVer, policy)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(selected))
+	for _, v := range selected {
+		tags = append(tags, "v"+v.String())
+	}
+	return tags, nil
+}
+
+//selectVersions drop prereleases and versions lower than k8sVer and pick the versions of every minor release by policy
+func selectVersions(versions []*version.Version, k8sVer string, policy TagPolicy) ([]*version.Version, error) {
+	from, err := version.NewVersion(k8sVer)
+	if err != nil {
+		return nil, err
+	}
+	if len(policy) == 0 {
+		policy = LatestPatch
+	}
+	if _, err := ParseTagPolicy(string(policy)); err != nil {
+		return nil, err
+	}
+	matches := make([]*version.Version, 0, len(versions))
+	for _, v := range versions {
+		if len(v.Prerelease()) == 0 && from.LessThanOrEqual(v) {
+			matches = append(matches, v)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].LessThan(matches[j])
+	})
+	if policy == AllTags {
+		return matches, nil
+	}
+	selected := make([]*version.Version, 0)
+	for _, v := range matches {
+		last := len(selected) - 1
+		if last < 0 || !sameMinor(selected[last], v) {
+			selected = append(selected, v)
+		} else if policy == LatestPatch {
+			selected[last] = v
+		}
+	}
+	return selected, nil
+}
+
+func sameMinor(a *version.Version, b *version.Version) bool {
+	sa, sb := a.Segments(), b.Segments()
+	return sa[0] == sb[0] && sa[1] == sb[1]
+}
+
+//httpClient return the injected client or collector.DefaultClient
+func (vc OpenAPISpec) httpClient() *collector.Client {
+	if vc.client != nil {
+		return vc.client
+	}
+	return collector.DefaultClient()
+}
@@ -0,0 +1,103 @@
+package swagger
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/cache"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+var refs = []Reference{
+	{Ref: "refs/tags/v1.19.16"}, {Ref: "refs/tags/v1.20.0-rc.0"}, {Ref: "refs/tags/v1.20.0"}, {Ref: "refs/tags/v1.20.15"},
+	{Ref: "refs/tags/v1.20.3"}, {Ref: "refs/tags/v1.21.0-alpha.1"}, {Ref: "refs/tags/v1.21.0"}, {Ref: "refs/tags/v1.21.14"},
+	{Ref: "refs/tags/v1.22.0-beta.2"}, {Ref: "refs/tags/not-a-version"},
+}
+
+func TestSelectTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		k8sVer  string
+		policy  TagPolicy
+		want    []string
+		wantErr bool
+	}{
+		{name: "latest patch by default", k8sVer: "v1.20.0", want: []string{"v1.20.15", "v1.21.14"}},
+		{name: "latest patch", k8sVer: "v1.19.0", policy: LatestPatch, want: []string{"v1.19.16", "v1.20.15", "v1.21.14"}},
+		{name: "first ga", k8sVer: "v1.20.0", policy: FirstGA, want: []string{"v1.20.0", "v1.21.0"}},
+		{name: "first ga from a patch release", k8sVer: "v1.20.2", policy: FirstGA, want: []string{"v1.20.3", "v1.21.0"}},
+		{name: "all", k8sVer: "v1.20.1", policy: AllTags, want: []string{"v1.20.3", "v1.20.15", "v1.21.0", "v1.21.14"}},
+		{name: "nothing newer", k8sVer: "v1.30.0", want: []string{}},
+		{name: "unknown policy", k8sVer: "v1.20.0", policy: "newest", wantErr: true},
+		{name: "invalid version", k8sVer: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectTags(refs, tt.k8sVer, tt.policy)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+//pagedTags serve refs two per page with github style Link headers
+func pagedTags(t *testing.T) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		start, end := (page-1)*2, page*2
+		if end > len(refs) {
+			end = len(refs)
+		}
+		if end < len(refs) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/tags?page=%d>; rel="next", <%s/tags?page=5>; rel="last"`, srv.URL, page+1, srv.URL))
+		}
+		body := "["
+		for i, ref := range refs[start:end] {
+			if i > 0 {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"ref": %q}`, ref.Ref)
+		}
+		fmt.Fprint(w, body+"]")
+	}))
+	original := k8sTagsURL
+	k8sTagsURL = srv.URL + "/tags"
+	t.Cleanup(func() {
+		k8sTagsURL = original
+		srv.Close()
+	})
+	return srv
+}
+
+func TestListTags(t *testing.T) {
+	pagedTags(t)
+	got, err := NewOpenAPISpec().WithClient(noRetryClient(t)).listTags(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, refs, got)
+
+	downloads := cache.NewCache(t.TempDir(), false)
+	got, err = NewCachedOpenAPISpec(downloads).WithClient(noRetryClient(t)).listTags(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, refs, got)
+	entries, err := downloads.Entries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 5)
+}
+
+func TestNextLink(t *testing.T) {
+	assert.Equal(t, "https://api.github.com/x?page=2",
+		nextLink(`<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=9>; rel="last"`))
+	assert.Equal(t, "", nextLink(`<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=1>; rel="first"`))
+	assert.Equal(t, "", nextLink(""))
+}
@@ -1,12 +1,73 @@
 package collector
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+)
 
-//FindRemovedDeprecatedVersion find the version of k8s api swagger or markdown by keywords
+//replacementRe match the api pointed to by "in favor of", "use", "migrate to" and "migrate ... to use" phrases,
+//e.g. "Use admissionregistration.k8s.io/v1 MutatingWebhookConfiguration instead" or
+//"Migrate manifests and API clients to use the **batch/v1** API version". Only the verbs ignore case, a kind starts
+//with an upper case letter and a bare version such as v1 is the core group
+var replacementRe = regexp.MustCompile(`\b(?i:in favor of|use|migrate to)(?: the)?\s+\**((?:[a-z0-9.\-]+/)?v[0-9]+(?:(?:alpha|beta)[0-9]+)?)\**` +
+	`(?:\s+([A-Z][A-Za-z]+)\b|[\s,;)]|\.(?:\s|$)|$)`)
+
+//FindRemovedDeprecatedVersion find the version of k8s api swagger or markdown by keywords, empty when verb is not
+//followed by one. "deprecated in favor of" names the replacement instead of a version and is skipped
 func FindRemovedDeprecatedVersion(lower string, verb string) string {
-	dIndex := strings.Index(lower, verb)
-	ndes := lower[dIndex+len(verb):]
-	sndes := strings.Split(strings.TrimPrefix(ndes, " "), " ")
-	rem := strings.TrimSuffix(strings.TrimSuffix(sndes[0], ","), ".")
-	return rem
+	for ndes := lower; ; {
+		dIndex := strings.Index(ndes, verb)
+		if dIndex < 0 {
+			return ""
+		}
+		ndes = strings.TrimPrefix(ndes[dIndex+len(verb):], " ")
+		if strings.HasPrefix(ndes, "favor of") {
+			continue
+		}
+		sndes := strings.Split(ndes, " ")
+		return strings.TrimSuffix(strings.TrimSuffix(sndes[0], ","), ".")
+	}
+}
+
+//FindReplacementAPI find the replacement api of kind in swagger or markdown text,
+//the replacement keeps kind when the text only names the group/version
+func FindReplacementAPI(text string, kind string) (Gvk, bool) {
+	match := replacementRe.FindStringSubmatch(text)
+	if match == nil {
+		return Gvk{}, false
+	}
+	replacementKind := match[2]
+	if len(replacementKind) == 0 || replacementKind == "API" {
+		replacementKind = kind
+	}
+	gvk, err := ParseGvk(match[1], replacementKind)
+	if err != nil {
+		return Gvk{}, false
+	}
+	return gvk, true
+}
+
+var (
+	// deprecatedFieldRe match descriptions marking a field deprecated, e.g. "Deprecated: Use serviceAccountName instead."
+	// or "This field is deprecated", but not passing mentions like "the deprecated field"
+	deprecatedFieldRe = regexp.MustCompile(`(?i)(?:^|[.:]\s+)deprecated\b|\b(?:is|are|has been|was) deprecated\b`)
+	// replacementFieldRe match the field pointed to by "use x instead" and "in favor of x" phrases
+	replacementFieldRe = regexp.MustCompile("(?:\\b[Uu]se|\\bin favor of)(?: the)? `?([a-z][A-Za-z0-9]*(?:\\.[a-z][A-Za-z0-9]*)*)`?(/?)(?: field)?( instead)?")
+)
+
+//IsDeprecatedField check whether a schema property description marks the field deprecated
+func IsDeprecatedField(description string) bool {
+	return deprecatedFieldRe.MatchString(description)
+}
+
+//FindReplacementField find the field a deprecated field description points to, e.g. serviceAccountName
+func FindReplacementField(description string) (string, bool) {
+	for _, match := range replacementFieldRe.FindAllStringSubmatch(description, -1) {
+		// group/version apis are not fields, "use x" needs a trailing instead
+		if len(match[2]) > 0 || (strings.HasPrefix(strings.ToLower(match[0]), "use") && len(match[3]) == 0) {
+			continue
+		}
+		return match[1], true
+	}
+	return "", false
 }
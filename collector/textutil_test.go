@@ -23,6 +23,8 @@ func TestTextUtil(t *testing.T) {
 		{name: "line with willNoLongerBeServed ", want: "v1.22", verb: willNoLongerBeServed, line: "ClusterRole is a cluster level, logical grouping of PolicyRules that can be referenced as a unit by a RoleBinding or ClusterRoleBinding. Deprecated in v1.17 in favor of rbac.authorization.k8s.io/v1 ClusterRole, and will no longer be served in v1.22."},
 		{name: "line with isNoLongerServedAsOf ", want: "v1.22", verb: isNoLongerServedAsOf, line: "The **admissionregistration.k8s.io/v1beta1** API version of MutatingWebhookConfiguration and ValidatingWebhookConfiguration is no longer served as of v1.22."},
 		{name: "line with removedIn ", want: "v1.19", verb: removedIn, line: "MutatingWebhookConfiguration describes the configuration of and admission webhook that accept or reject and may change the object. Deprecated in v1.16, planned for removal in v1.19. Use admissionregistration.k8s.io/v1 MutatingWebhookConfiguration instead."},
+		{name: "deprecated in favor of", want: "", verb: deprecatedIn, line: "Job is deprecated in favor of batch/v1 Job, planned for removal in the next release."},
+		{name: "version after deprecated in favor of", want: "v1.20", verb: deprecatedIn, line: "Deprecated in favor of batch/v1 Job, deprecated in v1.20."},
 		{name: "line with deprecatedIn ", want: "v1.16", verb: deprecatedIn, line: "MutatingWebhookConfiguration describes the configuration of and admission webhook that accept or reject and may change the object. Deprecated in v1.16, planned for removal in v1.19. Use admissionregistration.k8s.io/v1 MutatingWebhookConfiguration instead."},
 	}
 
@@ -33,3 +35,65 @@ func TestTextUtil(t *testing.T) {
 		})
 	}
 }
+
+func TestFindReplacementAPI(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		kind   string
+		want   Gvk
+		wantOk bool
+	}{
+		{name: "use instead", kind: "MutatingWebhookConfiguration", wantOk: true, want: Gvk{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"},
+			line: "MutatingWebhookConfiguration describes the configuration of and admission webhook that accept or reject and may change the object. Deprecated in v1.16, planned for removal in v1.19. Use admissionregistration.k8s.io/v1 MutatingWebhookConfiguration instead."},
+		{name: "in favor of", kind: "ClusterRole", wantOk: true, want: Gvk{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+			line: "ClusterRole is a cluster level, logical grouping of PolicyRules that can be referenced as a unit by a RoleBinding or ClusterRoleBinding. Deprecated in v1.17 in favor of rbac.authorization.k8s.io/v1 ClusterRole, and will no longer be served in v1.22."},
+		{name: "migration bullet", kind: "FlowSchema", wantOk: true, want: Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2", Kind: "FlowSchema"},
+			line: "* Migrate manifests and API clients to use the **flowcontrol.apiserver.k8s.io/v1beta2** API version, available since v1.23."},
+		{name: "migrate to", kind: "Ingress", wantOk: true, want: Gvk{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+			line: "Migrate to the networking.k8s.io/v1 API version."},
+		{name: "no replacement", kind: "CronJob", line: "CronJob represents the configuration of a single cron job."},
+		{name: "lower case word is not a kind", kind: "PodDisruptionBudget", wantOk: true, want: Gvk{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"},
+			line: "Deprecated: use policy/v1 instead."},
+		{name: "lower case word after the version", kind: "CronJob", wantOk: true, want: Gvk{Group: "batch", Version: "v1", Kind: "CronJob"},
+			line: "Use batch/v1 for this"},
+		{name: "core group version", kind: "Endpoints", wantOk: true, want: Gvk{Version: "v1", Kind: "Endpoints"},
+			line: "Deprecated in v1.21, use v1 Endpoints instead."},
+		{name: "verb inside a word", kind: "Deployment", line: "Deprecated because apps/v1 Deployment replaces it."},
+		{name: "release is not a core version", kind: "Ingress", line: "Deprecated, use v1.19 or later."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FindReplacementAPI(tt.line, tt.kind)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDeprecatedField(t *testing.T) {
+	tests := []struct {
+		name            string
+		description     string
+		wantDeprecated  bool
+		wantReplacement string
+	}{
+		{name: "deprecated use instead", description: "DeprecatedServiceAccount is a depreciated alias for ServiceAccountName. Deprecated: Use serviceAccountName instead.",
+			wantDeprecated: true, wantReplacement: "serviceAccountName"},
+		{name: "upper case", description: "GitRepo represents a git repository at a particular revision. DEPRECATED: GitRepo is deprecated.", wantDeprecated: true},
+		{name: "in favor of field", description: "This field is deprecated in favor of the `seccompProfile` field.", wantDeprecated: true, wantReplacement: "seccompProfile"},
+		{name: "in favor of api", description: "Deprecated in v1.17 in favor of rbac.authorization.k8s.io/v1 ClusterRole.", wantDeprecated: true},
+		{name: "use without instead", description: "Deprecated: This field is under-specified. Users are encouraged to use implementation-specific annotations when available.",
+			wantDeprecated: true},
+		{name: "passing mention", description: "If unset, the value of the deprecated field serviceAccount is used."},
+		{name: "not deprecated", description: "ServiceAccountName is the name of the ServiceAccount to use to run this pod."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDeprecated, IsDeprecatedField(tt.description))
+			replacement, ok := FindReplacementField(tt.description)
+			assert.Equal(t, tt.wantReplacement, replacement)
+			assert.Equal(t, len(tt.wantReplacement) > 0, ok)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//kubeVersionRe match a k8s release, e.g. v1.25, 1.25, v1.25.3 or v1.26.0-alpha.1, the patch and pre-release are dropped
+var kubeVersionRe = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.\d+)?(?:[-+][0-9A-Za-z.-]*)?$`)
+
+//KubeVersion k8s minor release an api is introduced, deprecated or removed in, the zero value is an unknown version
+type KubeVersion struct {
+	Major int
+	Minor int
+}
+
+//ParseKubeVersion parse a k8s release such as v1.25 or 1.25.3, the trailing punctuation of text extractions is ignored
+func ParseKubeVersion(s string) (KubeVersion, error) {
+	trimmed := strings.TrimRight(strings.TrimSpace(s), ".,;:)")
+	match := kubeVersionRe.FindStringSubmatch(trimmed)
+	if match == nil {
+		return KubeVersion{}, fmt.Errorf("invalid k8s version %q", s)
+	}
+	major, err := strconv.Atoi(match[1])
+	if err != nil {
+		return KubeVersion{}, fmt.Errorf("invalid k8s version %q: %w", s, err)
+	}
+	minor, err := strconv.Atoi(match[2])
+	if err != nil {
+		return KubeVersion{}, fmt.Errorf("invalid k8s version %q: %w", s, err)
+	}
+	v := KubeVersion{Major: major, Minor: minor}
+	if err := v.Validate(); err != nil {
+		return KubeVersion{}, err
+	}
+	return v, nil
+}
+
+//MustParseKubeVersion parse a k8s release known to be valid, it panics otherwise
+func MustParseKubeVersion(s string) KubeVersion {
+	v, err := ParseKubeVersion(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+//Validate check the version is a k8s 1.x release
+func (v KubeVersion) Validate() error {
+	if v.Major != 1 || v.Minor < 0 {
+		return fmt.Errorf("invalid k8s version %q: only 1.x releases exist", fmt.Sprintf("%d.%d", v.Major, v.Minor))
+	}
+	return nil
+}
+
+//IsZero check whether the version is unknown
+func (v KubeVersion) IsZero() bool {
+	return v == KubeVersion{}
+}
+
+//String return the version as vMAJOR.MINOR, empty when unknown
+func (v KubeVersion) String() string {
+	if v.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("v%d.%d", v.Major, v.Minor)
+}
+
+//Compare return -1, 0 or 1 when v is before, equal to or after o
+func (v KubeVersion) Compare(o KubeVersion) int {
+	switch {
+	case v.Major != o.Major:
+		return compareInt(v.Major, o.Major)
+	default:
+		return compareInt(v.Minor, o.Minor)
+	}
+}
+
+//AtOrBefore check whether v is known and released no later than o, e.g. removed at or before the target
+func (v KubeVersion) AtOrBefore(o KubeVersion) bool {
+	return !v.IsZero() && v.Compare(o) <= 0
+}
+
+//NextMinor return the following minor release
+func (v KubeVersion) NextMinor() KubeVersion {
+	return KubeVersion{Major: v.Major, Minor: v.Minor + 1}
+}
+
+//PreviousMinor return the preceding minor release, v itself for the first minor
+func (v KubeVersion) PreviousMinor() KubeVersion {
+	if v.Minor == 0 {
+		return v
+	}
+	return KubeVersion{Major: v.Major, Minor: v.Minor - 1}
+}
+
+//MarshalText encode the version as vMAJOR.MINOR, empty when unknown
+func (v KubeVersion) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+//UnmarshalText decode a version, empty text is the unknown version
+func (v *KubeVersion) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*v = KubeVersion{}
+		return nil
+	}
+	parsed, err := ParseKubeVersion(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+func compareInt(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+//Warning malformed data a collector skipped instead of reporting it, e.g. a removed version which does not parse
+type Warning struct {
+	Source string
+	Gav    Gvk
+	Field  string
+	Value  string
+	Err    error
+}
+
+//String describe the warning, e.g. swagger: batch/v1beta1 Job: removed "soon" skipped: invalid k8s version "soon"
+func (w Warning) String() string {
+	subject := w.Source
+	if !w.Gav.IsZero() {
+		subject += ": " + w.Gav.String()
+	}
+	return fmt.Sprintf("%s: %s %q skipped: %v", subject, w.Field, w.Value, w.Err)
+}
+
+//WarningFunc receive the warnings of a collector, nil drops them
+type WarningFunc func(Warning)
+
+//Warn report w to fn when it is set
+func (fn WarningFunc) Warn(w Warning) {
+	if fn != nil {
+		fn(w)
+	}
+}
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"testing"
+)
+
+func TestParseKubeVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    KubeVersion
+		wantErr bool
+	}{
+		{name: "release", value: "v1.25", want: KubeVersion{Major: 1, Minor: 25}},
+		{name: "without v", value: "1.9", want: KubeVersion{Major: 1, Minor: 9}},
+		{name: "patch", value: "v1.25.3", want: KubeVersion{Major: 1, Minor: 25}},
+		{name: "pre-release", value: "v1.26.0-alpha.1", want: KubeVersion{Major: 1, Minor: 26}},
+		{name: "trailing punctuation", value: "v1.22.", want: KubeVersion{Major: 1, Minor: 22}},
+		{name: "trailing comma", value: " v1.16, ", want: KubeVersion{Major: 1, Minor: 16}},
+		{name: "word", value: "favor", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+		{name: "major only", value: "v1", wantErr: true},
+		{name: "not a 1.x release", value: "v2.0", wantErr: true},
+		{name: "garbage suffix", value: "v1.25x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKubeVersion(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.True(t, got.IsZero())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKubeVersionCompare(t *testing.T) {
+	v19, v125 := MustParseKubeVersion("v1.9"), MustParseKubeVersion("v1.25")
+	// minors compare as numbers, v1.9 is before v1.25
+	assert.Equal(t, -1, v19.Compare(v125))
+	assert.Equal(t, 1, v125.Compare(v19))
+	assert.Equal(t, 0, v125.Compare(MustParseKubeVersion("1.25.4")))
+	assert.True(t, v19.AtOrBefore(v125))
+	assert.True(t, v125.AtOrBefore(v125))
+	assert.False(t, v125.AtOrBefore(v19))
+	assert.False(t, KubeVersion{}.AtOrBefore(v125))
+
+	versions := []KubeVersion{v125, MustParseKubeVersion("v1.16"), v19}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+	assert.Equal(t, []KubeVersion{v19, MustParseKubeVersion("v1.16"), v125}, versions)
+}
+
+func TestKubeVersionMinorArithmetic(t *testing.T) {
+	assert.Equal(t, "v1.26", MustParseKubeVersion("v1.25").NextMinor().String())
+	assert.Equal(t, "v1.10", MustParseKubeVersion("v1.9").NextMinor().String())
+	assert.Equal(t, "v1.24", MustParseKubeVersion("v1.25").PreviousMinor().String())
+	assert.Equal(t, "v1.0", MustParseKubeVersion("v1.0").PreviousMinor().String())
+}
+
+func TestKubeVersionText(t *testing.T) {
+	type entry struct {
+		Removed KubeVersion `json:"removed" yaml:"removed,omitempty"`
+	}
+	data, err := json.Marshal(entry{Removed: MustParseKubeVersion("v1.25")})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"removed":"v1.25"}`, string(data))
+
+	var e entry
+	assert.NoError(t, yaml.Unmarshal([]byte("removed: 1.22.0\n"), &e))
+	assert.Equal(t, MustParseKubeVersion("v1.22"), e.Removed)
+	assert.Error(t, yaml.Unmarshal([]byte("removed: soon\n"), &e))
+
+	data, err = yaml.Marshal(entry{})
+	assert.NoError(t, err)
+	assert.Equal(t, "{}\n", string(data))
+}
+
+func TestWarningString(t *testing.T) {
+	_, err := ParseKubeVersion("favor")
+	w := Warning{Source: SourceSwagger, Gav: Gvk{Group: "batch", Version: "v1beta1", Kind: "Job"}, Field: "deprecated", Value: "favor", Err: err}
+	assert.Equal(t, `swagger: batch/v1beta1 Job: deprecated "favor" skipped: invalid k8s version "favor"`, w.String())
+}
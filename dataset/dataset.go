@@ -0,0 +1,382 @@
+//Package dataset is the offline dataset of outdated apis: the results of every collector written to a canonical json
+//file by `k8s-outdated dataset generate` and merged when it is read. A dataset is embedded in the binary and newer
+//ones are read at runtime.
+package dataset
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"k8s-outdated/collector"
+	"k8s-outdated/collector/swagger"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+//SchemaVersion of the dataset file written by this version, a file of a newer schema is rejected
+const SchemaVersion = 1
+
+//Name of the dataset collector in the merged sources
+const Name = "dataset"
+
+//embedded dataset file built into the binary, regenerated by `make dataset`
+//
+//go:embed dataset.json
+var embedded []byte
+
+//Dataset outdated apis and deprecated fields of every collector and where they were read from. The apis are kept per
+//source and merged when the dataset is read, so the swagger apis of the releases before the k8s version read from are
+//left out like the collectors leave them out
+type Dataset struct {
+	SchemaVersion int `json:"schemaVersion"`
+	// Generated is the time the collectors ran, zero when unknown
+	Generated time.Time `json:"generated"`
+	// K8sVersions are the k8s releases the swagger specs were read from, in release order
+	K8sVersions []collector.KubeVersion `json:"k8sVersions"`
+	Sources     []Source                `json:"sources"`
+	Fields      []Field                 `json:"fields"`
+}
+
+//Source collector a dataset was generated from and the apis it collected
+type Source struct {
+	// Name of the collector, e.g. markdown
+	Name string `json:"name"`
+	// Location is the repository or local path the collector read, e.g. https://github.com/kubernetes/website
+	Location string `json:"location"`
+	// Ref is the branch or tag read, e.g. main
+	Ref string `json:"ref,omitempty"`
+	// Commit is the commit of Ref the collector read, when the generator was given one
+	Commit string `json:"commit,omitempty"`
+	// Tags are the release tags the swagger specs were read from, e.g. v1.25.16
+	Tags []string `json:"tags,omitempty"`
+	// Priority of the collector in the merge, see collector.PrioritySwagger
+	Priority int   `json:"priority"`
+	APIs     []API `json:"apis,omitempty"`
+}
+
+//API dataset record of a collector.OutdatedAPI
+type API struct {
+	Group       string                `json:"group"`
+	Version     string                `json:"version"`
+	Kind        string                `json:"kind"`
+	Introduced  collector.KubeVersion `json:"introduced"`
+	Deprecated  collector.KubeVersion `json:"deprecated"`
+	Removed     collector.KubeVersion `json:"removed"`
+	Replacement *collector.Gvk        `json:"replacement,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Source      string                `json:"source"`
+	Migration   *Migration            `json:"migration,omitempty"`
+	// LastRelease is the last k8s release whose swagger specs have the api, zero for the other sources
+	LastRelease collector.KubeVersion `json:"lastRelease"`
+}
+
+//Migration dataset record of a collector.Migration
+type Migration struct {
+	Title          string   `json:"title"`
+	Anchor         string   `json:"anchor,omitempty"`
+	URL            string   `json:"url,omitempty"`
+	Steps          []string `json:"steps,omitempty"`
+	NotableChanges []string `json:"notableChanges,omitempty"`
+}
+
+//Field dataset record of a collector.DeprecatedField
+type Field struct {
+	Group       string                `json:"group"`
+	Version     string                `json:"version"`
+	Kind        string                `json:"kind"`
+	Path        string                `json:"path"`
+	Description string                `json:"description,omitempty"`
+	Deprecated  collector.KubeVersion `json:"deprecated"`
+	Removed     collector.KubeVersion `json:"removed"`
+	Replacement string                `json:"replacement,omitempty"`
+	Source      string                `json:"source"`
+	// FirstRelease and LastRelease are the k8s releases whose swagger specs describe the field this way, a field
+	// described differently by other releases has a record for each description
+	FirstRelease collector.KubeVersion `json:"firstRelease"`
+	LastRelease  collector.KubeVersion `json:"lastRelease"`
+}
+
+//New build the dataset of the apis collected by each source and of the swagger specs read release by release, the
+//records are sorted so the same collector results always give the same file. The apis of a source are the collected
+//ones of the same name, the swagger ones carry the last release they were read from
+func New(generated time.Time, sources []Source, collected []collector.Collected, releases []swagger.ReleaseSpecs) *Dataset {
+	d := &Dataset{SchemaVersion: SchemaVersion, Generated: generated.UTC().Truncate(time.Second),
+		K8sVersions: make([]collector.KubeVersion, 0, len(releases)), Sources: make([]Source, 0, len(sources)), Fields: make([]Field, 0)}
+	lastRelease := make(map[collector.Gvk]collector.KubeVersion)
+	runs := make(map[string]int)
+	for _, r := range releases {
+		if !r.Release.IsZero() {
+			d.K8sVersions = append(d.K8sVersions, r.Release)
+		}
+		for _, api := range r.APIs {
+			lastRelease[api.Gav] = r.Release
+		}
+		for _, f := range r.Fields {
+			record := Field{Group: f.Gav.Group, Version: f.Gav.Version, Kind: f.Gav.Kind, Path: f.Path, Description: f.Description,
+				Deprecated: f.Deprecated, Removed: f.Removed, Replacement: f.Replacement, Source: f.Source}
+			key := f.Gav.String() + "#" + f.Path
+			if i, ok := runs[key]; ok && sameField(d.Fields[i], record) {
+				d.Fields[i].LastRelease = r.Release
+				continue
+			}
+			record.FirstRelease, record.LastRelease = r.Release, r.Release
+			runs[key] = len(d.Fields)
+			d.Fields = append(d.Fields, record)
+		}
+	}
+	sort.Slice(d.K8sVersions, func(i, j int) bool {
+		return d.K8sVersions[i].Compare(d.K8sVersions[j]) < 0
+	})
+	for _, source := range sources {
+		source.Tags = append([]string(nil), source.Tags...)
+		source.APIs = nil
+		for _, c := range collected {
+			if c.Name != source.Name {
+				continue
+			}
+			source.Priority = c.Priority
+			sorted := append([]*collector.OutdatedAPI{}, c.APIs...)
+			collector.SortOutdatedAPIs(sorted)
+			for _, api := range sorted {
+				record := newAPI(api)
+				if isSwagger(source.Name) {
+					record.LastRelease = lastRelease[api.Gav]
+				}
+				source.APIs = append(source.APIs, record)
+			}
+		}
+		d.Sources = append(d.Sources, source)
+	}
+	sort.SliceStable(d.Sources, func(i, j int) bool {
+		return d.Sources[i].Name < d.Sources[j].Name
+	})
+	sort.SliceStable(d.Fields, func(i, j int) bool {
+		a, b := d.Fields[i], d.Fields[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.FirstRelease.Compare(b.FirstRelease) < 0
+	})
+	return d
+}
+
+//isSwagger check whether the source is read from the swagger specs of the k8s releases
+func isSwagger(name string) bool {
+	return name == collector.SourceSwagger || name == collector.SourceOpenAPIV3
+}
+
+//sameField check whether two field records describe the field the same way, whatever their releases
+func sameField(a Field, b Field) bool {
+	a.FirstRelease, a.LastRelease = collector.KubeVersion{}, collector.KubeVersion{}
+	b.FirstRelease, b.LastRelease = collector.KubeVersion{}, collector.KubeVersion{}
+	return a == b
+}
+
+func newAPI(api *collector.OutdatedAPI) API {
+	record := API{Group: api.Gav.Group, Version: api.Gav.Version, Kind: api.Gav.Kind, Introduced: api.Introduced,
+		Deprecated: api.Deprecated, Removed: api.Removed, Description: api.Description, Source: api.Source}
+	if !api.Replacement.IsZero() {
+		replacement := api.Replacement
+		record.Replacement = &replacement
+	}
+	if m := api.Migration; m != nil {
+		record.Migration = &Migration{Title: m.Title, Anchor: m.Anchor, URL: m.URL, Steps: m.Steps, NotableChanges: m.NotableChanges}
+	}
+	return record
+}
+
+//Embedded return the dataset built into the binary
+func Embedded() (*Dataset, error) {
+	d, err := Decode(bytes.NewReader(embedded))
+	if err != nil {
+		return nil, fmt.Errorf("embedded dataset: %w", err)
+	}
+	return d, nil
+}
+
+//ReadFile read a dataset file written by Encode
+func ReadFile(path string) (*Dataset, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+//Decode read a dataset, a dataset of a newer schema than SchemaVersion is an error
+func Decode(r io.Reader) (*Dataset, error) {
+	var d Dataset
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+	switch {
+	case d.SchemaVersion == 0:
+		return nil, fmt.Errorf("schemaVersion is missing")
+	case d.SchemaVersion > SchemaVersion:
+		return nil, fmt.Errorf("schemaVersion %d is newer than the supported %d, upgrade k8s-outdated", d.SchemaVersion, SchemaVersion)
+	}
+	for _, source := range d.Sources {
+		for i, api := range source.APIs {
+			if len(api.Version) == 0 || len(api.Kind) == 0 {
+				return nil, fmt.Errorf("source %s api %d: version and kind are required", source.Name, i)
+			}
+		}
+	}
+	return &d, nil
+}
+
+//Encode write the dataset as indented json, the canonical form diffed between generations
+func (d Dataset) Encode(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(d)
+}
+
+//IsEmpty check whether the dataset has no apis, e.g. the placeholder embedded before the first generation
+func (d Dataset) IsEmpty() bool {
+	for _, source := range d.Sources {
+		if len(source.APIs) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+//APICount return the number of api records of every source
+func (d Dataset) APICount() int {
+	count := 0
+	for _, source := range d.Sources {
+		count += len(source.APIs)
+	}
+	return count
+}
+
+//Missing return what a dataset generated from every core collector has and d lacks: the apis of the swagger api,
+//deprecation guide and prerelease lifecycle sources and the k8s versions the swagger specs were read from
+func (d Dataset) Missing() []string {
+	missing := make([]string, 0)
+	for _, names := range [][]string{{collector.SourceSwagger, collector.SourceOpenAPIV3}, {collector.SourceMarkdown}, {collector.SourceLifecycle}} {
+		found := false
+		for _, source := range d.Sources {
+			for _, name := range names {
+				found = found || (source.Name == name && len(source.APIs) > 0)
+			}
+		}
+		if !found {
+			missing = append(missing, names[0])
+		}
+	}
+	if len(d.K8sVersions) == 0 {
+		missing = append(missing, "k8sVersions")
+	}
+	return missing
+}
+
+//Covers check whether the swagger specs of k8sVersion were read, the dataset misses the apis removed before its
+//first release but the deprecation guide lists
+func (d Dataset) Covers(k8sVersion collector.KubeVersion) bool {
+	return len(d.K8sVersions) > 0 && d.K8sVersions[0].AtOrBefore(k8sVersion)
+}
+
+//OutdatedAPIs return the apis of the dataset read from k8sVersion onward merged with collector.MergeByPriority, the
+//result of the collectors run with the same k8s version. The swagger apis last read before k8sVersion are left out,
+//an empty k8sVersion keeps them all
+func (d Dataset) OutdatedAPIs(k8sVersion string) ([]*collector.OutdatedAPI, error) {
+	from, err := fromVersion(k8sVersion)
+	if err != nil {
+		return nil, err
+	}
+	collected := make([]collector.Collected, 0, len(d.Sources))
+	for _, source := range d.Sources {
+		apis := make([]*collector.OutdatedAPI, 0, len(source.APIs))
+		for _, record := range source.APIs {
+			if !isRead(record.LastRelease, from) {
+				continue
+			}
+			api := &collector.OutdatedAPI{Gav: collector.Gvk{Group: record.Group, Version: record.Version, Kind: record.Kind},
+				Introduced: record.Introduced, Deprecated: record.Deprecated, Removed: record.Removed, Description: record.Description,
+				Source: record.Source}
+			if record.Replacement != nil {
+				api.Replacement = *record.Replacement
+			}
+			if m := record.Migration; m != nil {
+				api.Migration = &collector.Migration{Title: m.Title, Anchor: m.Anchor, URL: m.URL, Steps: m.Steps, NotableChanges: m.NotableChanges}
+			}
+			apis = append(apis, api)
+		}
+		collected = append(collected, collector.Collected{Name: source.Name, Priority: source.Priority, APIs: apis})
+	}
+	return collector.MergeByPriority(collected...), nil
+}
+
+//DeprecatedFields return the deprecated fields of the dataset read from k8sVersion onward, a field described
+//differently by the releases has the description of the first release read, empty k8sVersion reads every release
+func (d Dataset) DeprecatedFields(k8sVersion string) ([]*collector.DeprecatedField, error) {
+	from, err := fromVersion(k8sVersion)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]*collector.DeprecatedField, 0, len(d.Fields))
+	seen := make(map[string]bool, len(d.Fields))
+	for _, f := range d.Fields {
+		gvk := collector.Gvk{Group: f.Group, Version: f.Version, Kind: f.Kind}
+		key := gvk.String() + "#" + f.Path
+		if seen[key] || !isRead(f.LastRelease, from) {
+			continue
+		}
+		seen[key] = true
+		fields = append(fields, &collector.DeprecatedField{Gav: gvk, Path: f.Path, Description: f.Description,
+			Deprecated: f.Deprecated, Removed: f.Removed, Replacement: f.Replacement, Source: f.Source})
+	}
+	return fields, nil
+}
+
+//fromVersion parse the k8s version the dataset is read from, zero when it is empty
+func fromVersion(k8sVersion string) (collector.KubeVersion, error) {
+	if len(k8sVersion) == 0 {
+		return collector.KubeVersion{}, nil
+	}
+	return collector.ParseKubeVersion(k8sVersion)
+}
+
+//isRead check whether a record last read from the swagger specs of lastRelease is read from the release from onward,
+//the records of the other sources and of unversioned specs are always read
+func isRead(lastRelease collector.KubeVersion, from collector.KubeVersion) bool {
+	return lastRelease.IsZero() || from.IsZero() || lastRelease.Compare(from) >= 0
+}
+
+//Name return the name of the dataset collector
+func (d Dataset) Name() string {
+	return Name
+}
+
+//Priority return the merge priority of the dataset, the one of the swagger api its apis replace
+func (d Dataset) Priority() int {
+	return collector.PrioritySwagger
+}
+
+//Collect return the apis of the dataset merged like the collectors it was generated from, read from opts.K8sVersion
+//onward
+func (d Dataset) Collect(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+	return d.OutdatedAPIs(opts.K8sVersion)
+}
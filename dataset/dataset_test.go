@@ -0,0 +1,227 @@
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"k8s-outdated/collector/swagger"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cronJob = collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+
+func testAPIs() []*collector.OutdatedAPI {
+	return []*collector.OutdatedAPI{
+		{Gav: collector.Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema"}, Removed: collector.MustParseKubeVersion("v1.26"),
+			Source: collector.SourceMarkdown},
+		{Gav: cronJob, Removed: collector.MustParseKubeVersion("v1.25"), Source: collector.SourceMarkdown,
+			Replacement: collector.Gvk{Group: "batch", Version: "v1", Kind: "CronJob"},
+			Migration: &collector.Migration{Title: "CronJob", Anchor: "cronjob-v125", URL: "https://kubernetes.io/docs/reference/using-api/deprecation-guide/#cronjob-v125",
+				Steps: []string{"Migrate manifests and API clients to use the **batch/v1** API version"}}},
+		{Gav: collector.Gvk{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}, Deprecated: collector.MustParseKubeVersion("v1.14"),
+			Removed: collector.MustParseKubeVersion("v1.22"), Source: collector.SourceSwagger},
+		{Gav: cronJob, Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"),
+			Source: collector.SourceSwagger, Description: "CronJob <v1beta1>"},
+	}
+}
+
+func testSources() []Source {
+	return []Source{
+		{Name: collector.SourceSwagger, Location: "https://github.com/kubernetes/kubernetes", Tags: []string{"v1.20.15", "v1.21.14"}},
+		{Name: collector.SourceMarkdown, Location: "https://github.com/kubernetes/website", Ref: "main", Commit: "3f2a1c9"},
+	}
+}
+
+//testCollected swagger and deprecation guide apis, the swagger ingress is last read from v1.21
+func testCollected() []collector.Collected {
+	return []collector.Collected{
+		{Name: collector.SourceMarkdown, Priority: collector.PriorityMarkdown, APIs: []*collector.OutdatedAPI{testAPIs()[0], testAPIs()[1]}},
+		{Name: collector.SourceSwagger, Priority: collector.PrioritySwagger, APIs: []*collector.OutdatedAPI{testAPIs()[2], testAPIs()[3]}},
+	}
+}
+
+func testReleases() []swagger.ReleaseSpecs {
+	serviceAccount := func(description string) *collector.DeprecatedField {
+		return &collector.DeprecatedField{Gav: collector.Gvk{Version: "v1", Kind: "Pod"}, Path: "spec.serviceAccount",
+			Description: description, Replacement: "serviceAccountName", Source: collector.SourceSwagger}
+	}
+	return []swagger.ReleaseSpecs{
+		{Release: collector.MustParseKubeVersion("v1.20"), APIs: map[string]*collector.OutdatedAPI{"ingress": testAPIs()[2], "cronjob": testAPIs()[3]},
+			Fields: []*collector.DeprecatedField{serviceAccount("DeprecatedServiceAccount is a depreciated alias for ServiceAccountName.")}},
+		{Release: collector.MustParseKubeVersion("v1.21"), APIs: map[string]*collector.OutdatedAPI{"ingress": testAPIs()[2], "cronjob": testAPIs()[3]},
+			Fields: []*collector.DeprecatedField{serviceAccount("DeprecatedServiceAccount is a depreciated alias for ServiceAccountName.")}},
+		{Release: collector.MustParseKubeVersion("v1.22"), APIs: map[string]*collector.OutdatedAPI{"cronjob": testAPIs()[3]},
+			Fields: []*collector.DeprecatedField{serviceAccount("DeprecatedServiceAccount is a deprecated alias for ServiceAccountName.")}},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	generated := time.Date(2024, 5, 2, 10, 30, 15, 500, time.FixedZone("CEST", 2*60*60))
+	d := New(generated, testSources(), testCollected(), testReleases())
+	var b bytes.Buffer
+	assert.NoError(t, d.Encode(&b))
+	assert.Contains(t, b.String(), `"generated": "2024-05-02T08:30:15Z"`)
+	assert.Contains(t, b.String(), `"description": "CronJob <v1beta1>"`)
+
+	decoded, err := Decode(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, []collector.KubeVersion{collector.MustParseKubeVersion("v1.20"), collector.MustParseKubeVersion("v1.21"),
+		collector.MustParseKubeVersion("v1.22")}, decoded.K8sVersions)
+	assert.Equal(t, []string{collector.SourceMarkdown, collector.SourceSwagger}, []string{decoded.Sources[0].Name, decoded.Sources[1].Name})
+	assert.Equal(t, collector.PrioritySwagger, decoded.Sources[1].Priority)
+	assert.Equal(t, []string{"v1.20.15", "v1.21.14"}, decoded.Sources[1].Tags)
+	// only the swagger apis carry the last release they were read from
+	assert.True(t, decoded.Sources[0].APIs[0].LastRelease.IsZero())
+	assert.Equal(t, "v1.22", decoded.Sources[1].APIs[0].LastRelease.String())
+	assert.Equal(t, "v1.21", decoded.Sources[1].APIs[1].LastRelease.String())
+	// a field described differently by a release has a record for each description
+	assert.Equal(t, 2, len(decoded.Fields))
+	assert.Equal(t, []string{"v1.20", "v1.21", "v1.22", "v1.22"}, []string{decoded.Fields[0].FirstRelease.String(), decoded.Fields[0].LastRelease.String(),
+		decoded.Fields[1].FirstRelease.String(), decoded.Fields[1].LastRelease.String()})
+
+	apis, err := decoded.OutdatedAPIs("")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(apis))
+	assert.Equal(t, cronJob, apis[0].Gav)
+	assert.Equal(t, "swagger,markdown", apis[0].Source)
+	assert.Equal(t, "v1.25", apis[0].Removed.String())
+	assert.Equal(t, testAPIs()[1].Migration, apis[0].Migration)
+	fields, err := decoded.DeprecatedFields("")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(fields))
+	assert.Equal(t, "DeprecatedServiceAccount is a depreciated alias for ServiceAccountName.", fields[0].Description)
+}
+
+func TestFromVersion(t *testing.T) {
+	d := New(time.Unix(1714638615, 0), testSources(), testCollected(), testReleases())
+	tests := []struct {
+		name            string
+		k8sVersion      string
+		wantAPIs        []string
+		wantDescription string
+		wantErr         bool
+	}{
+		{name: "every release", wantAPIs: []string{"batch/v1beta1 CronJob", "extensions/v1beta1 Ingress", "flowcontrol.apiserver.k8s.io/v1beta1 FlowSchema"},
+			wantDescription: "depreciated"},
+		{name: "release the ingress was last read from", k8sVersion: "v1.21.0",
+			wantAPIs:        []string{"batch/v1beta1 CronJob", "extensions/v1beta1 Ingress", "flowcontrol.apiserver.k8s.io/v1beta1 FlowSchema"},
+			wantDescription: "depreciated"},
+		// the guide apis are kept whatever the release, like the guide collector keeps them
+		{name: "after the ingress was removed", k8sVersion: "v1.22.3", wantAPIs: []string{"batch/v1beta1 CronJob", "flowcontrol.apiserver.k8s.io/v1beta1 FlowSchema"},
+			wantDescription: "deprecated"},
+		{name: "invalid version", k8sVersion: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apis, err := d.Collect(context.Background(), collector.Options{K8sVersion: tt.k8sVersion})
+			fields, fieldsErr := d.DeprecatedFields(tt.k8sVersion)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Error(t, fieldsErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, fieldsErr)
+			names := make([]string, 0)
+			for _, api := range apis {
+				names = append(names, api.Gav.String())
+			}
+			assert.Equal(t, tt.wantAPIs, names)
+			assert.Equal(t, 1, len(fields))
+			assert.Contains(t, fields[0].Description, tt.wantDescription)
+		})
+	}
+}
+
+func TestEncodeCanonical(t *testing.T) {
+	generated := time.Unix(1714638615, 0)
+	collected, sources := testCollected(), testSources()
+	var first, second bytes.Buffer
+	assert.NoError(t, New(generated, sources, collected, testReleases()).Encode(&first))
+	// the order the collectors return their results in does not change the file
+	collected[0].APIs[0], collected[0].APIs[1] = collected[0].APIs[1], collected[0].APIs[0]
+	collected[0], collected[1] = collected[1], collected[0]
+	sources[0], sources[1] = sources[1], sources[0]
+	assert.NoError(t, New(generated, sources, collected, testReleases()).Encode(&second))
+	assert.Equal(t, first.String(), second.String())
+}
+
+func TestMissing(t *testing.T) {
+	d := New(time.Unix(1714638615, 0), testSources(), testCollected(), testReleases())
+	assert.Equal(t, []string{collector.SourceLifecycle}, d.Missing())
+	d = New(time.Unix(1714638615, 0), testSources()[1:], testCollected(), nil)
+	assert.Equal(t, []string{collector.SourceSwagger, collector.SourceLifecycle, "k8sVersions"}, d.Missing())
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "empty dataset", data: `{"schemaVersion": 1, "sources": []}`},
+		{name: "missing schema version", data: `{"apis": []}`, wantErr: "schemaVersion is missing"},
+		{name: "newer schema version", data: `{"schemaVersion": 2}`, wantErr: "schemaVersion 2 is newer than the supported 1, upgrade k8s-outdated"},
+		{name: "api without kind", data: `{"schemaVersion": 1, "sources": [{"name": "swagger", "apis": [{"group": "batch", "version": "v1beta1"}]}]}`,
+			wantErr: "source swagger api 0: version and kind are required"},
+		{name: "malformed version", data: `{"schemaVersion": 1, "sources": [{"name": "swagger", "apis": [{"version": "v1", "kind": "Pod", "removed": "soon"}]}]}`,
+			wantErr: `invalid k8s version "soon"`},
+		{name: "not json", data: `schemaVersion: 1`, wantErr: "invalid character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.data))
+			if len(tt.wantErr) > 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	d, err := ReadFile("./testdata/fixture/dataset.json")
+	assert.NoError(t, err)
+	assert.False(t, d.IsEmpty())
+	assert.Empty(t, d.Missing())
+	assert.True(t, d.Covers(collector.MustParseKubeVersion("v1.20")))
+	assert.True(t, d.Covers(collector.MustParseKubeVersion("v1.25")))
+	assert.False(t, d.Covers(collector.MustParseKubeVersion("v1.19")))
+
+	apis, err := d.Collect(context.Background(), collector.Options{})
+	assert.NoError(t, err)
+	found := false
+	for _, api := range apis {
+		if api.Gav == cronJob {
+			found = true
+			assert.Equal(t, "v1.25", api.Removed.String())
+			assert.Equal(t, "swagger,markdown,lifecycle", api.Source)
+		}
+	}
+	assert.True(t, found)
+	assert.Equal(t, 13, len(apis))
+
+	_, err = ReadFile("./testdata/fixture/missing.json")
+	assert.Error(t, err)
+}
+
+func TestEmbedded(t *testing.T) {
+	d, err := Embedded()
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaVersion, d.SchemaVersion)
+	assert.False(t, d.IsEmpty())
+	// make dataset regenerates an incomplete embedded dataset
+	assert.Empty(t, d.Missing(), "the embedded dataset is incomplete, regenerate it with make dataset")
+	// the swagger specs are read from release tags, the other sources at a commit
+	for _, source := range d.Sources {
+		if isSwagger(source.Name) {
+			assert.NotEmpty(t, source.Tags, source.Name)
+			continue
+		}
+		assert.NotEmpty(t, source.Commit, source.Name)
+	}
+}
@@ -0,0 +1,186 @@
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	chartFile    = "Chart.yaml"
+	templatesDir = "templates"
+	chartsDir    = "charts"
+)
+
+//Chart helm chart templates
+type Chart struct {
+	Name    string
+	Version string
+	// Path is the chart directory or packaged chart file
+	Path      string
+	Templates []Template
+	// Dependencies are the sub charts found under charts/
+	Dependencies []*Chart
+}
+
+//Template chart template file
+type Template struct {
+	// Name is the template path relative to the chart root, e.g. templates/ingress.yaml
+	Name string
+	Data []byte
+}
+
+type chartMetadata struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
+//IsChart check whether path is a chart directory or a packaged chart
+func IsChart(p string) bool {
+	info, err := os.Stat(p)
+	if err != nil {
+		return false
+	}
+	if info.IsDir() {
+		_, err := os.Stat(filepath.Join(p, chartFile))
+		return err == nil
+	}
+	return strings.HasSuffix(p, ".tgz") || strings.HasSuffix(p, ".tar.gz")
+}
+
+//LoadChart load a chart directory or a packaged .tgz chart
+func LoadChart(p string) (*Chart, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	if info.IsDir() {
+		files, err = readDir(p)
+	} else {
+		files, err = readArchive(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newChart(p, files)
+}
+
+//readDir read all chart files keyed by their slash separated path relative to dir
+func readDir(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(filepath.Clean(p))
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return files, err
+}
+
+//readArchive read all chart files of a packaged chart, dropping the top level chart directory
+func readArchive(p string) (map[string][]byte, error) {
+	f, err := os.Open(filepath.Clean(p))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	defer gz.Close()
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		files[parts[1]] = data
+	}
+}
+
+//newChart build chart and its sub charts from files keyed by path relative to the chart root
+func newChart(p string, files map[string][]byte) (*Chart, error) {
+	meta, ok := files[chartFile]
+	if !ok {
+		return nil, fmt.Errorf("%s: %s is missing", p, chartFile)
+	}
+	var md chartMetadata
+	if err := yaml.Unmarshal(meta, &md); err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", p, chartFile, err)
+	}
+	chart := &Chart{Name: md.Name, Version: md.Version, Path: p}
+	subCharts := make(map[string]map[string][]byte)
+	for name, data := range files {
+		switch {
+		case strings.HasPrefix(name, chartsDir+"/"):
+			rest := strings.TrimPrefix(name, chartsDir+"/")
+			parts := strings.SplitN(rest, "/", 2)
+			if len(parts) != 2 {
+				// packaged sub charts are not expanded
+				continue
+			}
+			if _, ok := subCharts[parts[0]]; !ok {
+				subCharts[parts[0]] = make(map[string][]byte)
+			}
+			subCharts[parts[0]][parts[1]] = data
+		case strings.HasPrefix(name, templatesDir+"/") && isManifestTemplate(name):
+			chart.Templates = append(chart.Templates, Template{Name: name, Data: data})
+		}
+	}
+	sort.Slice(chart.Templates, func(i, j int) bool {
+		return chart.Templates[i].Name < chart.Templates[j].Name
+	})
+	names := make([]string, 0, len(subCharts))
+	for name := range subCharts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sub, err := newChart(path.Join(p, chartsDir, name), subCharts[name])
+		if err != nil {
+			return nil, err
+		}
+		chart.Dependencies = append(chart.Dependencies, sub)
+	}
+	return chart, nil
+}
+
+func isManifestTemplate(name string) bool {
+	ext := path.Ext(name)
+	return ext == ".yaml" || ext == ".yml" || ext == ".json" || ext == ".tpl"
+}
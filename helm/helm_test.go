@@ -0,0 +1,151 @@
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//PackageChart write dir as a packaged chart, the way helm package does
+func packageChart(t *testing.T, dir string) string {
+	out := filepath.Join(t.TempDir(), "mychart-1.2.3.tgz")
+	f, err := os.Create(out)
+	assert.NoError(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Dir(dir), p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(rel), Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return out
+}
+
+//encodeRelease encode a release the way the helm 3 storage drivers do
+func encodeRelease(t *testing.T, rel map[string]interface{}) string {
+	data, err := json.Marshal(rel)
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestLoadChart(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "chart directory", path: "./testdata/fixture/mychart"},
+		{name: "packaged chart", path: packageChart(t, "./testdata/fixture/mychart")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, IsChart(tt.path))
+			chart, err := LoadChart(tt.path)
+			assert.NoError(t, err)
+			assert.Equal(t, "mychart", chart.Name)
+			assert.Equal(t, "1.2.3", chart.Version)
+			names := make([]string, 0)
+			for _, tpl := range chart.Templates {
+				names = append(names, tpl.Name)
+			}
+			assert.Equal(t, []string{"templates/_helpers.tpl", "templates/cronjob.yaml", "templates/ingress.yaml"}, names)
+			assert.Equal(t, 1, len(chart.Dependencies))
+			assert.Equal(t, "cache", chart.Dependencies[0].Name)
+			assert.Equal(t, "templates/pdb.yaml", chart.Dependencies[0].Templates[0].Name)
+		})
+	}
+}
+
+func TestLoadChartErrors(t *testing.T) {
+	assert.False(t, IsChart("./testdata/fixture"))
+	_, err := LoadChart("./testdata/fixture")
+	assert.Error(t, err)
+	_, err = LoadChart("./testdata/fixture/mychart/values.yaml")
+	assert.Error(t, err)
+}
+
+func TestDecodeRelease(t *testing.T) {
+	encoded := encodeRelease(t, map[string]interface{}{"name": "web", "namespace": "prod", "version": 3,
+		"chart":    map[string]interface{}{"metadata": map[string]interface{}{"name": "mychart", "version": "1.2.3"}},
+		"manifest": "---\n# Source: mychart/templates/service.yaml\napiVersion: v1\nkind: Service\n---\n# Source: mychart/templates/ingress.yaml\napiVersion: extensions/v1beta1\nkind: Ingress\n"})
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{name: "helm encoded", payload: encoded},
+		{name: "secret data", payload: base64.StdEncoding.EncodeToString([]byte(encoded))},
+		{name: "secret manifest", payload: "apiVersion: v1\nkind: Secret\ntype: helm.sh/release.v1\ndata:\n  release: " + base64.StdEncoding.EncodeToString([]byte(encoded)) + "\n"},
+		{name: "config map list", payload: "apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: ConfigMap\n  data:\n    release: " + encoded + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releases, err := DecodeReleaseFile([]byte(tt.payload))
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(releases))
+			rel := releases[0]
+			assert.Equal(t, "web", rel.Name)
+			assert.Equal(t, "prod", rel.Namespace)
+			assert.Equal(t, 3, rel.Revision)
+			assert.Equal(t, "mychart-1.2.3", rel.ChartName())
+			manifests := rel.Manifests()
+			assert.Equal(t, 2, len(manifests))
+			assert.Equal(t, "mychart/templates/ingress.yaml", manifests[1].Template)
+			assert.Equal(t, 6, manifests[1].Line)
+			assert.Equal(t, "apiVersion: extensions/v1beta1\nkind: Ingress\n", string(manifests[1].Data))
+		})
+	}
+	_, err := DecodeRelease([]byte("not a release"))
+	assert.Error(t, err)
+}
+
+func TestParseStorageDriver(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    StorageDriver
+		wantErr bool
+	}{
+		{value: "", want: DriverSecret},
+		{value: "secrets", want: DriverSecret},
+		{value: "ConfigMap", want: DriverConfigMap},
+		{value: "configmaps", want: DriverConfigMap},
+		{value: "sql", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseStorageDriver(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package helm
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+)
+
+const (
+	//ReleaseSecretType type of the secrets the helm 3 secret storage driver writes
+	ReleaseSecretType = "helm.sh/release.v1"
+	//ReleaseSelector label selector of the deployed releases stored by helm 3
+	ReleaseSelector = "owner=helm,status=deployed"
+
+	sourcePrefix = "# Source: "
+)
+
+//StorageDriver helm 3 storage driver the releases of a cluster are stored with
+type StorageDriver string
+
+const (
+	//DriverEnv holds the storage driver helm stores the releases with, the secret driver when it is not set
+	DriverEnv = "HELM_DRIVER"
+	//DriverSecret stores the releases as secrets of type ReleaseSecretType
+	DriverSecret StorageDriver = "secret"
+	//DriverConfigMap stores the releases as config maps
+	DriverConfigMap StorageDriver = "configmap"
+)
+
+//ParseStorageDriver parse a HELM_DRIVER value, the drivers storing the releases outside the k8s api, e.g. sql, are
+//not supported
+func ParseStorageDriver(s string) (StorageDriver, error) {
+	switch strings.ToLower(s) {
+	case "", "secret", "secrets":
+		return DriverSecret, nil
+	case "configmap", "configmaps":
+		return DriverConfigMap, nil
+	}
+	return "", fmt.Errorf("helm storage driver %q is not supported, releases are read from secrets or configmaps", s)
+}
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+//Release helm release stored by the helm 3 storage drivers
+type Release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Revision  int    `json:"version"`
+	Manifest  string `json:"manifest"`
+	Chart     struct {
+		Metadata chartMetadata `json:"metadata"`
+	} `json:"chart"`
+}
+
+//Manifest rendered manifest of a single release template
+type Manifest struct {
+	// Template is the template file the manifest was rendered from, e.g. mychart/templates/ingress.yaml
+	Template string
+	Data     []byte
+	// Line is the offset of Data within the release manifest, line n of Data is line Line+n of the release manifest
+	Line int
+}
+
+//ChartName return the chart name and version of the release
+func (r Release) ChartName() string {
+	return r.Chart.Metadata.Name + "-" + r.Chart.Metadata.Version
+}
+
+//DecodeRelease decode a release payload, payload may be the helm encoded release (base64 gzip json),
+//the base64 encoded secret data of it, or the plain release json
+func DecodeRelease(payload []byte) (*Release, error) {
+	data := bytes.TrimSpace(payload)
+	// secret data adds a second base64 layer over the helm encoding
+	for i := 0; i < 3 && !bytes.HasPrefix(data, []byte("{")) && !bytes.HasPrefix(data, gzipMagic); i++ {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid release payload: %w", err)
+		}
+		data = decoded
+	}
+	if bytes.HasPrefix(data, gzipMagic) {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		if data, err = io.ReadAll(gz); err != nil {
+			return nil, err
+		}
+	}
+	var rel Release
+	if err := json.Unmarshal(data, &rel); err != nil {
+		return nil, fmt.Errorf("invalid release payload: %w", err)
+	}
+	return &rel, nil
+}
+
+//storedRelease secret or config map written by the helm 3 storage drivers, or a list of them
+type storedRelease struct {
+	Kind string `yaml:"kind"`
+	Data struct {
+		Release string `yaml:"release"`
+	} `yaml:"data"`
+	Items []storedRelease `yaml:"items"`
+}
+
+//DecodeReleaseFile decode releases from a release secret or config map manifest, a list of them,
+//or a raw release payload
+func DecodeReleaseFile(data []byte) ([]*Release, error) {
+	var stored storedRelease
+	if err := yaml.Unmarshal(data, &stored); err != nil || len(stored.Kind) == 0 {
+		rel, err := DecodeRelease(data)
+		if err != nil {
+			return nil, err
+		}
+		return []*Release{rel}, nil
+	}
+	items := []storedRelease{stored}
+	if strings.HasSuffix(stored.Kind, "List") {
+		items = stored.Items
+	}
+	releases := make([]*Release, 0, len(items))
+	for _, item := range items {
+		if len(item.Data.Release) == 0 {
+			continue
+		}
+		rel, err := DecodeRelease([]byte(item.Data.Release))
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, rel)
+	}
+	return releases, nil
+}
+
+//Manifests split the release manifest by the "# Source:" comments helm writes ahead of every rendered template
+func (r Release) Manifests() []Manifest {
+	manifests := make([]Manifest, 0)
+	var current *Manifest
+	var buf bytes.Buffer
+	// document separators are only kept when more content of the same template follows them
+	separators := 0
+	flush := func() {
+		if current != nil && len(bytes.TrimSpace(buf.Bytes())) > 0 {
+			current.Data = append([]byte{}, buf.Bytes()...)
+			manifests = append(manifests, *current)
+		}
+		buf.Reset()
+		separators = 0
+	}
+	scanner := bufio.NewScanner(strings.NewReader(r.Manifest))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, sourcePrefix):
+			flush()
+			current = &Manifest{Template: strings.TrimSpace(strings.TrimPrefix(text, sourcePrefix)), Line: line}
+		case text == "---" && current == nil:
+		case text == "---":
+			separators++
+		default:
+			if current == nil {
+				current = &Manifest{Line: line - 1}
+			}
+			for ; separators > 0; separators-- {
+				buf.WriteString("---\n")
+			}
+			buf.WriteString(text)
+			buf.WriteByte('\n')
+		}
+	}
+	flush()
+	return manifests
+}
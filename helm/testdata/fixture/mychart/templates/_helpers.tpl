@@ -0,0 +1,3 @@
+{{- define "mychart.fullname" -}}
+{{- .Release.Name | trunc 63 | trimSuffix "-" }}
+{{- end }}
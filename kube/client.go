@@ -0,0 +1,294 @@
+package kube
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	listLimit      = "500"
+	requestTimeout = 30 * time.Second
+)
+
+//Client minimal k8s api server client supporting discovery and list
+type Client struct {
+	cfg  *Config
+	http *http.Client
+}
+
+//VersionInfo api server version
+type VersionInfo struct {
+	Major      string `json:"major"`
+	Minor      string `json:"minor"`
+	GitVersion string `json:"gitVersion"`
+}
+
+//APIGroup discovery information of an api group
+type APIGroup struct {
+	Name             string                     `json:"name"`
+	Versions         []GroupVersionForDiscovery `json:"versions"`
+	PreferredVersion GroupVersionForDiscovery   `json:"preferredVersion"`
+}
+
+//GroupVersionForDiscovery group version served by an api group
+type GroupVersionForDiscovery struct {
+	GroupVersion string `json:"groupVersion"`
+	Version      string `json:"version"`
+}
+
+//APIResourceList resources served by a group version
+type APIResourceList struct {
+	GroupVersion string        `json:"groupVersion"`
+	Resources    []APIResource `json:"resources"`
+}
+
+//APIResource discovery information of a resource
+type APIResource struct {
+	Name       string   `json:"name"`
+	Namespaced bool     `json:"namespaced"`
+	Kind       string   `json:"kind"`
+	Verbs      []string `json:"verbs"`
+}
+
+//StatusError non 2xx api server response
+type StatusError struct {
+	Code int
+	Path string
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status code %d: %s", e.Path, e.Code, strings.TrimSpace(e.Body))
+}
+
+type list struct {
+	Metadata struct {
+		Continue string `json:"continue"`
+	} `json:"metadata"`
+	Items []map[string]interface{} `json:"items"`
+}
+
+//NewClient instansiate new Client from kubeconfig connection details
+func NewClient(cfg *Config) (*Client, error) {
+	if len(cfg.Server) == 0 {
+		return nil, fmt.Errorf("context %q has no server", cfg.Context)
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipTLSVerify} // #nosec G402 -- explicit kubeconfig setting
+	if len(cfg.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(cfg.CAData) {
+			return nil, fmt.Errorf("context %q: invalid certificate authority data", cfg.Context)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(cfg.ClientCertData) > 0 && len(cfg.ClientKeyData) > 0 {
+		cert, err := tls.X509KeyPair(cfg.ClientCertData, cfg.ClientKeyData)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &Client{cfg: cfg, http: &http.Client{Transport: transport, Timeout: requestTimeout}}, nil
+}
+
+//ServerVersion return the api server version
+func (c Client) ServerVersion() (*VersionInfo, error) {
+	var info VersionInfo
+	if err := c.get("/version", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+//ServerGroup return the discovery information of group, false when the group is not served
+func (c Client) ServerGroup(group string) (*APIGroup, bool, error) {
+	var g APIGroup
+	path := "/apis/" + group
+	if len(group) == 0 {
+		var versions struct {
+			Versions []string `json:"versions"`
+		}
+		if err := c.get("/api", nil, &versions); err != nil {
+			return nil, false, err
+		}
+		for _, v := range versions.Versions {
+			g.Versions = append(g.Versions, GroupVersionForDiscovery{GroupVersion: v, Version: v})
+		}
+		if len(g.Versions) > 0 {
+			g.PreferredVersion = g.Versions[0]
+		}
+		return &g, true, nil
+	}
+	err := c.get(path, nil, &g)
+	if IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &g, true, nil
+}
+
+//ServerResources return the resources served by group version, false when the group version is not served
+func (c Client) ServerResources(group string, version string) (*APIResourceList, bool, error) {
+	var resources APIResourceList
+	err := c.get(groupVersionPath(group, version), nil, &resources)
+	if IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &resources, true, nil
+}
+
+//List list all objects of resource in all namespaces following list pagination
+func (c Client) List(group string, version string, resource string) ([]map[string]interface{}, error) {
+	return c.ListSelected(group, version, resource, "")
+}
+
+//ListSelected list the objects of resource in all namespaces matching labelSelector
+func (c Client) ListSelected(group string, version string, resource string, labelSelector string) ([]map[string]interface{}, error) {
+	items := make([]map[string]interface{}, 0)
+	query := url.Values{"limit": []string{listLimit}}
+	if len(labelSelector) > 0 {
+		query.Set("labelSelector", labelSelector)
+	}
+	for {
+		var l list
+		if err := c.get(groupVersionPath(group, version)+"/"+resource, query, &l); err != nil {
+			return nil, err
+		}
+		items = append(items, l.Items...)
+		if len(l.Metadata.Continue) == 0 {
+			return items, nil
+		}
+		query.Set("continue", l.Metadata.Continue)
+	}
+}
+
+//OpenAPIV3Paths return the server relative url of the openapi v3 document of every served group version,
+//keyed by group version path, e.g. apis/batch/v1
+func (c Client) OpenAPIV3Paths() (map[string]string, error) {
+	var discovery struct {
+		Paths map[string]struct {
+			ServerRelativeURL string `json:"serverRelativeURL"`
+		} `json:"paths"`
+	}
+	if err := c.get("/openapi/v3", nil, &discovery); err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string, len(discovery.Paths))
+	for path, p := range discovery.Paths {
+		paths[path] = p.ServerRelativeURL
+	}
+	return paths, nil
+}
+
+//OpenAPIV3Document return the openapi v3 document served at a server relative url returned by OpenAPIV3Paths
+func (c Client) OpenAPIV3Document(serverRelativeURL string) (map[string]interface{}, error) {
+	u, err := url.Parse(serverRelativeURL)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := c.get(u.Path, u.Query(), &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func groupVersionPath(group string, version string) string {
+	if len(group) == 0 {
+		return "/api/" + version
+	}
+	return "/apis/" + group + "/" + version
+}
+
+func (c Client) get(path string, query url.Values, v interface{}) error {
+	u := c.cfg.Server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if err := c.authenticate(req); err != nil {
+		return err
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return &StatusError{Code: res.StatusCode, Path: path, Body: string(body)}
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (c Client) authenticate(req *http.Request) error {
+	switch {
+	case len(c.cfg.Token) > 0:
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	case c.cfg.Exec != nil:
+		token, err := execToken(c.cfg.Exec)
+		if err != nil {
+			return err
+		}
+		c.cfg.Token = token
+		req.Header.Set("Authorization", "Bearer "+token)
+	case len(c.cfg.Username) > 0:
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+	return nil
+}
+
+//execToken run a client-go credential plugin and return its bearer token
+func execToken(e *ExecConfig) (string, error) {
+	cmd := exec.Command(e.Command, e.Args...) // #nosec G204 -- command comes from the user kubeconfig
+	cmd.Env = os.Environ()
+	for _, env := range e.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("credential plugin %s: %w", e.Command, err)
+	}
+	var cred struct {
+		Status struct {
+			Token string `json:"token"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &cred); err != nil {
+		return "", fmt.Errorf("credential plugin %s: %w", e.Command, err)
+	}
+	if len(cred.Status.Token) == 0 {
+		return "", fmt.Errorf("credential plugin %s returned no token", e.Command)
+	}
+	return cred.Status.Token, nil
+}
+
+//IsNotFound check whether err is a not found answer of the api server
+func IsNotFound(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Code == http.StatusNotFound
+}
@@ -0,0 +1,95 @@
+package kube
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/kube/kubetest"
+	"path/filepath"
+	"testing"
+)
+
+func newFakeClient(t *testing.T, server *kubetest.Server) *Client {
+	path := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, server.WriteKubeconfig(path))
+	cfg, err := LoadConfig(path, "")
+	assert.NoError(t, err)
+	client, err := NewClient(cfg)
+	assert.NoError(t, err)
+	return client
+}
+
+func TestClient(t *testing.T) {
+	items := []map[string]interface{}{kubetest.Object("a", "default", ""), kubetest.Object("b", "default", ""), kubetest.Object("c", "other", "")}
+	server := kubetest.NewServer("v1.25.3-eks-1234", "secret", []kubetest.Resource{
+		{Group: "batch", Version: "v1", Kind: "CronJob", Name: "cronjobs", Namespaced: true, Items: items},
+		{Group: "batch", Version: "v1beta1", Kind: "CronJob", Name: "cronjobs", Namespaced: true, Items: items},
+		{Version: "v1", Kind: "Pod", Name: "pods", Namespaced: true},
+	})
+	server.PageSize = 2
+	defer server.Close()
+	client := newFakeClient(t, server)
+
+	info, err := client.ServerVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.25.3-eks-1234", info.GitVersion)
+
+	group, ok, err := client.ServerGroup("batch")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "v1", group.PreferredVersion.Version)
+	assert.Equal(t, 2, len(group.Versions))
+
+	_, ok, err = client.ServerGroup("extensions")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	core, ok, err := client.ServerGroup("")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "v1", core.PreferredVersion.Version)
+
+	resources, ok, err := client.ServerResources("batch", "v1beta1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "cronjobs", resources.Resources[0].Name)
+
+	_, ok, err = client.ServerResources("batch", "v2alpha1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	listed, err := client.List("batch", "v1beta1", "cronjobs")
+	assert.NoError(t, err)
+	assert.Equal(t, items, listed)
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	server := kubetest.NewServer("v1.25.3", "secret", nil)
+	defer server.Close()
+	client := newFakeClient(t, server)
+	client.cfg.Token = "wrong"
+	_, err := client.ServerVersion()
+	se, ok := err.(*StatusError)
+	assert.True(t, ok)
+	assert.Equal(t, 401, se.Code)
+}
+
+func TestClientOpenAPIV3(t *testing.T) {
+	doc := map[string]interface{}{"openapi": "3.0.0", "components": map[string]interface{}{"schemas": map[string]interface{}{}}}
+	server := kubetest.NewServer("v1.25.3", "secret", nil)
+	server.OpenAPIV3 = map[string]map[string]interface{}{"apis/batch/v1beta1": doc, "api/v1": doc}
+	defer server.Close()
+	client := newFakeClient(t, server)
+
+	paths, err := client.OpenAPIV3Paths()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(paths))
+	assert.Contains(t, paths["apis/batch/v1beta1"], "/openapi/v3/apis/batch/v1beta1?hash=")
+
+	served, err := client.OpenAPIV3Document(paths["apis/batch/v1beta1"])
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.0", served["openapi"])
+
+	_, err = client.OpenAPIV3Document("/openapi/v3/apis/extensions/v1beta1")
+	se, ok := err.(*StatusError)
+	assert.True(t, ok)
+	assert.Equal(t, 404, se.Code)
+}
@@ -0,0 +1,207 @@
+package kube
+
+import (
+	"encoding/base64"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	//KubeconfigEnv environment variable holding the kubeconfig paths
+	KubeconfigEnv = "KUBECONFIG"
+	defaultConfig = ".kube/config"
+)
+
+//Config connection details of a single kubeconfig context
+type Config struct {
+	Context               string
+	Server                string
+	CAData                []byte
+	ClientCertData        []byte
+	ClientKeyData         []byte
+	Token                 string
+	Username              string
+	Password              string
+	InsecureSkipTLSVerify bool
+	Exec                  *ExecConfig
+}
+
+//ExecConfig client-go credential plugin
+type ExecConfig struct {
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args"`
+	Env        []EnvVar `yaml:"env"`
+	APIVersion string   `yaml:"apiVersion"`
+}
+
+//EnvVar environment variable passed to the credential plugin
+type EnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type kubeconfig struct {
+	CurrentContext string         `yaml:"current-context"`
+	Clusters       []namedCluster `yaml:"clusters"`
+	Contexts       []namedContext `yaml:"contexts"`
+	Users          []namedUser    `yaml:"users"`
+}
+
+type namedCluster struct {
+	Name    string `yaml:"name"`
+	Cluster struct {
+		Server                   string `yaml:"server"`
+		CertificateAuthority     string `yaml:"certificate-authority"`
+		CertificateAuthorityData string `yaml:"certificate-authority-data"`
+		InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+	} `yaml:"cluster"`
+}
+
+type namedContext struct {
+	Name    string `yaml:"name"`
+	Context struct {
+		Cluster string `yaml:"cluster"`
+		User    string `yaml:"user"`
+	} `yaml:"context"`
+}
+
+type namedUser struct {
+	Name string `yaml:"name"`
+	User struct {
+		ClientCertificate     string      `yaml:"client-certificate"`
+		ClientCertificateData string      `yaml:"client-certificate-data"`
+		ClientKey             string      `yaml:"client-key"`
+		ClientKeyData         string      `yaml:"client-key-data"`
+		Token                 string      `yaml:"token"`
+		TokenFile             string      `yaml:"tokenFile"`
+		Username              string      `yaml:"username"`
+		Password              string      `yaml:"password"`
+		Exec                  *ExecConfig `yaml:"exec"`
+	} `yaml:"user"`
+}
+
+//KubeconfigPath return the kubeconfig path to load, path wins over $KUBECONFIG which wins over ~/.kube/config.
+//Only the first file of a $KUBECONFIG list is used
+func KubeconfigPath(path string) string {
+	if len(path) > 0 {
+		return path
+	}
+	if env := os.Getenv(KubeconfigEnv); len(env) > 0 {
+		return filepath.SplitList(env)[0]
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return defaultConfig
+	}
+	return filepath.Join(home, defaultConfig)
+}
+
+//LoadConfig load the connection details of context from a kubeconfig file, an empty context use the current context
+func LoadConfig(path string, context string) (*Config, error) {
+	path = KubeconfigPath(path)
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(context) == 0 {
+		context = kc.CurrentContext
+	}
+	ctx, err := kc.context(context)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	base := filepath.Dir(path)
+	cfg := &Config{Context: context}
+	if err := kc.applyCluster(cfg, ctx.Context.Cluster, base); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := kc.applyUser(cfg, ctx.Context.User, base); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (kc kubeconfig) context(name string) (*namedContext, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("no current-context is set")
+	}
+	for i := range kc.Contexts {
+		if kc.Contexts[i].Name == name {
+			return &kc.Contexts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("context %q not found", name)
+}
+
+func (kc kubeconfig) applyCluster(cfg *Config, name string, base string) error {
+	for _, c := range kc.Clusters {
+		if c.Name != name {
+			continue
+		}
+		cfg.Server = strings.TrimSuffix(c.Cluster.Server, "/")
+		cfg.InsecureSkipTLSVerify = c.Cluster.InsecureSkipTLSVerify
+		ca, err := dataOrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, base)
+		if err != nil {
+			return err
+		}
+		cfg.CAData = ca
+		return nil
+	}
+	return fmt.Errorf("cluster %q not found", name)
+}
+
+func (kc kubeconfig) applyUser(cfg *Config, name string, base string) error {
+	if len(name) == 0 {
+		return nil
+	}
+	for _, u := range kc.Users {
+		if u.Name != name {
+			continue
+		}
+		var err error
+		if cfg.ClientCertData, err = dataOrFile(u.User.ClientCertificateData, u.User.ClientCertificate, base); err != nil {
+			return err
+		}
+		if cfg.ClientKeyData, err = dataOrFile(u.User.ClientKeyData, u.User.ClientKey, base); err != nil {
+			return err
+		}
+		cfg.Token = u.User.Token
+		if len(cfg.Token) == 0 && len(u.User.TokenFile) > 0 {
+			token, err := os.ReadFile(resolvePath(u.User.TokenFile, base))
+			if err != nil {
+				return err
+			}
+			cfg.Token = strings.TrimSpace(string(token))
+		}
+		cfg.Username = u.User.Username
+		cfg.Password = u.User.Password
+		cfg.Exec = u.User.Exec
+		return nil
+	}
+	return fmt.Errorf("user %q not found", name)
+}
+
+//dataOrFile return base64 decoded inline data or the content of file, relative to the kubeconfig directory
+func dataOrFile(data string, file string, base string) ([]byte, error) {
+	if len(data) > 0 {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if len(file) > 0 {
+		return os.ReadFile(resolvePath(file, base))
+	}
+	return nil, nil
+}
+
+func resolvePath(file string, base string) string {
+	if filepath.IsAbs(file) {
+		return filepath.Clean(file)
+	}
+	return filepath.Join(base, file)
+}
@@ -0,0 +1,38 @@
+package kube
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		context string
+		want    *Config
+		wantErr bool
+	}{
+		{name: "current context", want: &Config{Context: "dev", Server: "https://dev.example.com:6443", CAData: []byte("ca-data"), Token: "dev-token"}},
+		{name: "files relative to kubeconfig", context: "prod", want: &Config{Context: "prod", Server: "https://prod.example.com", CAData: []byte("prod-ca\n"),
+			Token: "prod-token", InsecureSkipTLSVerify: true, Exec: &ExecConfig{APIVersion: "client.authentication.k8s.io/v1beta1", Command: "aws", Args: []string{"eks", "get-token"}}}},
+		{name: "missing context", context: "staging", wantErr: true},
+		{name: "missing cluster", context: "broken", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadConfig("./testdata/fixture/kubeconfig", tt.context)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKubeconfigPath(t *testing.T) {
+	t.Setenv(KubeconfigEnv, "/tmp/a:/tmp/b")
+	assert.Equal(t, "/tmp/explicit", KubeconfigPath("/tmp/explicit"))
+	assert.Equal(t, "/tmp/a", KubeconfigPath(""))
+}
@@ -0,0 +1,210 @@
+//Package kubetest provide a fake k8s api server serving discovery and list endpoints for tests
+package kubetest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//Resource resource served by the fake api server
+type Resource struct {
+	Group      string
+	Version    string
+	Kind       string
+	Name       string
+	Namespaced bool
+	Items      []map[string]interface{}
+}
+
+//Server fake k8s api server
+type Server struct {
+	*httptest.Server
+	GitVersion string
+	Token      string
+	// PageSize is the max number of items returned per list request, 0 returns all items
+	PageSize int
+	// OpenAPIV3 documents served under /openapi/v3, keyed by group version path, e.g. apis/batch/v1
+	OpenAPIV3 map[string]map[string]interface{}
+	resources []Resource
+}
+
+//NewServer start a fake TLS api server, requests must carry token as bearer token when it is set
+func NewServer(gitVersion string, token string, resources []Resource) *Server {
+	s := &Server{GitVersion: gitVersion, Token: token, resources: resources}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+//Object build a list item with an optional last-applied-configuration annotation
+func Object(name string, namespace string, lastApplied string) map[string]interface{} {
+	metadata := map[string]interface{}{"name": name}
+	if len(namespace) > 0 {
+		metadata["namespace"] = namespace
+	}
+	if len(lastApplied) > 0 {
+		metadata["annotations"] = map[string]interface{}{"kubectl.kubernetes.io/last-applied-configuration": lastApplied}
+	}
+	return map[string]interface{}{"metadata": metadata}
+}
+
+//WriteKubeconfig write a kubeconfig file with a "fake" context pointing at the server
+func (s *Server) WriteKubeconfig(path string) error {
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: fake
+clusters:
+- name: fake
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: fake
+  context:
+    cluster: fake
+    user: fake
+users:
+- name: fake
+  user:
+    token: %s
+`, s.URL, base64.StdEncoding.EncodeToString(ca), s.Token)
+	return os.WriteFile(path, []byte(kubeconfig), 0600)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if len(s.Token) > 0 && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		http.Error(w, `{"kind":"Status","code":401}`, http.StatusUnauthorized)
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/version":
+		writeJSON(w, map[string]string{"gitVersion": s.GitVersion})
+	case r.URL.Path == "/openapi/v3":
+		s.serveOpenAPIV3Paths(w)
+	case parts[0] == "openapi" && len(parts) > 2 && parts[1] == "v3":
+		s.serveOpenAPIV3Document(w, strings.Join(parts[2:], "/"))
+	case parts[0] == "api" && len(parts) == 1:
+		writeJSON(w, map[string]interface{}{"versions": s.versions("")})
+	case parts[0] == "api":
+		s.serveGroupVersion(w, r, "", parts[1:])
+	case parts[0] == "apis" && len(parts) == 2:
+		s.serveGroup(w, parts[1])
+	case parts[0] == "apis" && len(parts) > 2:
+		s.serveGroupVersion(w, r, parts[1], parts[2:])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveGroup(w http.ResponseWriter, group string) {
+	versions := s.versions(group)
+	if len(versions) == 0 {
+		http.Error(w, `{"kind":"Status","code":404}`, http.StatusNotFound)
+		return
+	}
+	gvs := make([]map[string]string, 0)
+	for _, v := range versions {
+		gvs = append(gvs, map[string]string{"groupVersion": group + "/" + v, "version": v})
+	}
+	writeJSON(w, map[string]interface{}{"name": group, "versions": gvs, "preferredVersion": gvs[0]})
+}
+
+func (s *Server) serveGroupVersion(w http.ResponseWriter, r *http.Request, group string, parts []string) {
+	version := parts[0]
+	resources := make([]map[string]interface{}, 0)
+	for _, res := range s.resources {
+		if res.Group != group || res.Version != version {
+			continue
+		}
+		if len(parts) == 2 && parts[1] == res.Name {
+			s.serveList(w, r, res)
+			return
+		}
+		resources = append(resources, map[string]interface{}{"name": res.Name, "kind": res.Kind, "namespaced": res.Namespaced, "verbs": []string{"get", "list"}})
+	}
+	if len(parts) != 1 || len(resources) == 0 {
+		http.Error(w, `{"kind":"Status","code":404}`, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"groupVersion": strings.TrimPrefix(group+"/"+version, "/"), "resources": resources})
+}
+
+func (s *Server) serveOpenAPIV3Paths(w http.ResponseWriter) {
+	paths := make(map[string]interface{}, len(s.OpenAPIV3))
+	for path := range s.OpenAPIV3 {
+		paths[path] = map[string]string{"serverRelativeURL": "/openapi/v3/" + path + "?hash=" + strconv.Itoa(len(path))}
+	}
+	writeJSON(w, map[string]interface{}{"paths": paths})
+}
+
+func (s *Server) serveOpenAPIV3Document(w http.ResponseWriter, path string) {
+	doc, ok := s.OpenAPIV3[path]
+	if !ok {
+		http.Error(w, `{"kind":"Status","code":404}`, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, doc)
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request, res Resource) {
+	items := selectItems(res.Items, r.URL.Query().Get("labelSelector"))
+	start, _ := strconv.Atoi(r.URL.Query().Get("continue"))
+	end := len(items)
+	if s.PageSize > 0 && start+s.PageSize < end {
+		end = start + s.PageSize
+	}
+	metadata := map[string]string{}
+	if end < len(items) {
+		metadata["continue"] = strconv.Itoa(end)
+	}
+	writeJSON(w, map[string]interface{}{"metadata": metadata, "items": items[start:end]})
+}
+
+//selectItems filter items by an equality based label selector, e.g. owner=helm,status=deployed
+func selectItems(items []map[string]interface{}, selector string) []map[string]interface{} {
+	if len(selector) == 0 {
+		return items
+	}
+	selected := make([]map[string]interface{}, 0)
+	for _, item := range items {
+		metadata, _ := item["metadata"].(map[string]interface{})
+		labels, _ := metadata["labels"].(map[string]interface{})
+		matches := true
+		for _, requirement := range strings.Split(selector, ",") {
+			kv := strings.SplitN(requirement, "=", 2)
+			if len(kv) != 2 || labels[kv[0]] != kv[1] {
+				matches = false
+			}
+		}
+		if matches {
+			selected = append(selected, item)
+		}
+	}
+	return selected
+}
+
+//versions return the versions served for group in registration order, the first one is the preferred version
+func (s *Server) versions(group string) []string {
+	seen := make(map[string]bool)
+	versions := make([]string, 0)
+	for _, res := range s.resources {
+		if res.Group == group && !seen[res.Version] {
+			seen[res.Version] = true
+			versions = append(versions, res.Version)
+		}
+	}
+	return versions
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,200 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"k8s-outdated/collector"
+	"k8s-outdated/scanner"
+	"regexp"
+	"strings"
+)
+
+//Change migration of one k8s object to its replacement api
+type Change struct {
+	// Document is the zero based index of the yaml document
+	Document int
+	Line     int
+	Kind     string
+	Name     string
+	From     string
+	To       string
+}
+
+//Migrator rewrite k8s objects using apis deprecated or removed at the target version to their replacement apis
+type Migrator struct {
+	apis    map[collector.Gvk]*collector.OutdatedAPI
+	scanner *scanner.Scanner
+}
+
+var separatorRe = regexp.MustCompile(`^---(\s|$)`)
+
+//NewMigrator instansiate new Migrator for the target k8s version
+func NewMigrator(apis []*collector.OutdatedAPI, targetVersion string) (*Migrator, error) {
+	s, err := scanner.NewScanner(apis, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	byGvk := make(map[collector.Gvk]*collector.OutdatedAPI)
+	for _, api := range apis {
+		byGvk[api.Gav] = api
+	}
+	return &Migrator{apis: byGvk, scanner: s}, nil
+}
+
+//Migrate rewrite every document of a yaml or json manifest, documents without outdated apis are kept verbatim
+func (m Migrator) Migrate(data []byte) ([]byte, []Change, error) {
+	lines := strings.SplitAfter(string(data), "\n")
+	var out strings.Builder
+	changes := make([]Change, 0)
+	document, start := 0, 0
+	flush := func(end int) error {
+		text := strings.Join(lines[start:end], "")
+		migrated, docChanges, ok, err := m.migrateDocument(text, start, document)
+		if err != nil {
+			return err
+		}
+		if ok {
+			document++
+		}
+		out.WriteString(migrated)
+		changes = append(changes, docChanges...)
+		return nil
+	}
+	for i, line := range lines {
+		if !separatorRe.MatchString(line) {
+			continue
+		}
+		if err := flush(i); err != nil {
+			return nil, nil, err
+		}
+		out.WriteString(line)
+		start = i + 1
+	}
+	if err := flush(len(lines)); err != nil {
+		return nil, nil, err
+	}
+	return []byte(out.String()), changes, nil
+}
+
+//migrateDocument migrate the objects of one document starting at line offset, false when the document is empty
+func (m Migrator) migrateDocument(text string, offset int, document int) (string, []Change, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+		return "", nil, false, fmt.Errorf("line %d: %w", offset+1, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return text, nil, len(doc.Content) > 0, nil
+	}
+	root := doc.Content[0]
+	objects := []*yaml.Node{root}
+	if items := get(root, "items"); items != nil && strings.HasSuffix(value(root, "kind"), "List") {
+		objects = items.Content
+	}
+	changes := make([]Change, 0)
+	targets := make(map[*yaml.Node]string)
+	transforms := make([]func() error, 0)
+	for _, obj := range objects {
+		apiVersion, kind := get(obj, "apiVersion"), value(obj, "kind")
+		if apiVersion == nil {
+			continue
+		}
+		from, err := collector.ParseGvk(apiVersion.Value, kind)
+		if err != nil {
+			continue
+		}
+		to, steps := m.resolve(from)
+		if len(steps) == 0 {
+			continue
+		}
+		changes = append(changes, Change{
+			Document: document,
+			Line:     offset + obj.Line,
+			Kind:     kind,
+			Name:     value(get(obj, "metadata"), "name"),
+			From:     apiVersion.Value,
+			To:       to.APIVersion(),
+		})
+		targets[obj] = to.APIVersion()
+		for _, step := range steps {
+			if step != nil {
+				obj, step := obj, step
+				transforms = append(transforms, func() error { return step(obj) })
+			}
+		}
+	}
+	if len(changes) == 0 {
+		return text, changes, true, nil
+	}
+	if len(transforms) == 0 {
+		migrated, err := replaceAPIVersions(text, targets)
+		return migrated, changes, true, err
+	}
+	if root.Style&yaml.FlowStyle != 0 {
+		return "", nil, false, fmt.Errorf("line %d: schema migration of json documents is not supported", offset+1)
+	}
+	splice := newSplicer(text, root)
+	for _, transform := range transforms {
+		if err := transform(); err != nil {
+			return "", nil, false, fmt.Errorf("line %d: %w", offset+1, err)
+		}
+	}
+	for obj, to := range targets {
+		get(obj, "apiVersion").Value = to
+	}
+	if migrated, ok := splice.splice(root); ok {
+		return migrated, changes, true, nil
+	}
+	// the document is encoded again when a changed node cannot be located, e.g. a multi line scalar
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return "", nil, false, err
+	}
+	return buf.String(), changes, true, nil
+}
+
+//resolve follow the replacements of gvk while they are outdated at the target version,
+//return the final gvk and the transformation of every step, nil for apiVersion only steps
+func (m Migrator) resolve(gvk collector.Gvk) (collector.Gvk, []Transform) {
+	steps := make([]Transform, 0)
+	visited := map[collector.Gvk]bool{gvk: true}
+	for {
+		api, ok := m.apis[gvk]
+		if !ok {
+			return gvk, steps
+		}
+		if _, outdated := m.scanner.StatusOf(api); !outdated {
+			return gvk, steps
+		}
+		next, transform := api.Replacement, Transform(nil)
+		if migration, ok := builtinMigrations[gvk]; ok {
+			next, transform = migration.To, migration.Transform
+		}
+		if next.IsZero() || visited[next] {
+			return gvk, steps
+		}
+		visited[next] = true
+		steps = append(steps, transform)
+		gvk = next
+	}
+}
+
+//replaceAPIVersions replace the apiVersion values in place keeping the rest of the document verbatim
+func replaceAPIVersions(text string, targets map[*yaml.Node]string) (string, error) {
+	lines := strings.SplitAfter(text, "\n")
+	for obj, to := range targets {
+		apiVersion := get(obj, "apiVersion")
+		line := lines[apiVersion.Line-1]
+		column := apiVersion.Column - 1
+		if apiVersion.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			column++
+		}
+		if column+len(apiVersion.Value) > len(line) || line[column:column+len(apiVersion.Value)] != apiVersion.Value {
+			return "", fmt.Errorf("line %d: cannot locate apiVersion %s", apiVersion.Line, apiVersion.Value)
+		}
+		lines[apiVersion.Line-1] = line[:column] + to + line[column+len(apiVersion.Value):]
+	}
+	return strings.Join(lines, ""), nil
+}
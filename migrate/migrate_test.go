@@ -0,0 +1,133 @@
+package migrate
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+var apis = []*collector.OutdatedAPI{
+	{Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Gav: collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Gav: collector.Gvk{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.14"), Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Group: "extensions", Version: "v1beta1", Kind: "Ingress"},
+		Replacement: collector.Gvk{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.22"), Removed: collector.MustParseKubeVersion("v1.25"), Gav: collector.Gvk{Group: "autoscaling", Version: "v2beta1", Kind: "HorizontalPodAutoscaler"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.16"), Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.23"), Removed: collector.MustParseKubeVersion("v1.26"), Gav: collector.Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema"},
+		Replacement: collector.Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2", Kind: "FlowSchema"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.26"), Removed: collector.MustParseKubeVersion("v1.29"), Gav: collector.Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2", Kind: "FlowSchema"},
+		Replacement: collector.Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3", Kind: "FlowSchema"}},
+}
+
+func TestMigrateGolden(t *testing.T) {
+	tests := []struct {
+		file string
+		want []Change
+	}{
+		{file: "ingress.yaml", want: []Change{
+			{Document: 0, Line: 2, Kind: "Ingress", Name: "web", From: "extensions/v1beta1", To: "networking.k8s.io/v1"}}},
+		{file: "cronjob.yaml", want: []Change{
+			{Document: 0, Line: 1, Kind: "CronJob", Name: "backup", From: "batch/v1beta1", To: "batch/v1"},
+			{Document: 1, Line: 16, Kind: "PodDisruptionBudget", Name: "backup", From: "policy/v1beta1", To: "policy/v1"}}},
+		{file: "hpa.yaml", want: []Change{
+			{Document: 0, Line: 1, Kind: "HorizontalPodAutoscaler", Name: "web", From: "autoscaling/v2beta1", To: "autoscaling/v2"}}},
+		{file: "crd.yaml", want: []Change{
+			{Document: 0, Line: 1, Kind: "CustomResourceDefinition", Name: "backups.example.com", From: "apiextensions.k8s.io/v1beta1", To: "apiextensions.k8s.io/v1"}}},
+		{file: "flowschema.yaml", want: []Change{
+			{Document: 0, Line: 1, Kind: "FlowSchema", Name: "system-leader-election", From: "flowcontrol.apiserver.k8s.io/v1beta1", To: "flowcontrol.apiserver.k8s.io/v1beta3"}}},
+		{file: "layout.yaml", want: []Change{
+			{Document: 0, Line: 2, Kind: "Ingress", Name: "web", From: "extensions/v1beta1", To: "networking.k8s.io/v1"},
+			{Document: 1, Line: 32, Kind: "HorizontalPodAutoscaler", Name: "web", From: "autoscaling/v2beta1", To: "autoscaling/v2"},
+			{Document: 2, Line: 52, Kind: "CustomResourceDefinition", Name: "backups.example.com", From: "apiextensions.k8s.io/v1beta1", To: "apiextensions.k8s.io/v1"}}},
+		{file: "list.json", want: []Change{
+			{Document: 0, Line: 5, Kind: "CronJob", Name: "cleanup", From: "batch/v1beta1", To: "batch/v1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			m, err := NewMigrator(apis, "v1.29.0")
+			assert.NoError(t, err)
+			data, err := os.ReadFile(filepath.Join("testdata", "fixture", tt.file))
+			assert.NoError(t, err)
+			got, changes, err := m.Migrate(data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, changes)
+			ext := filepath.Ext(tt.file)
+			golden := filepath.Join("testdata", "fixture", strings.TrimSuffix(tt.file, ext)+".golden"+ext)
+			if *update {
+				assert.NoError(t, os.WriteFile(golden, got, 0644))
+			}
+			want, err := os.ReadFile(golden)
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestMigrateTargetVersion(t *testing.T) {
+	data, err := os.ReadFile("./testdata/fixture/cronjob.yaml")
+	assert.NoError(t, err)
+	m, err := NewMigrator(apis, "v1.20.0")
+	assert.NoError(t, err)
+	got, changes, err := m.Migrate(data)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, string(data), string(got))
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name       string
+		from       collector.Gvk
+		target     string
+		want       collector.Gvk
+		transforms int
+	}{
+		{name: "builtin migration preferred to data replacement", from: apis[2].Gav, target: "v1.22.0",
+			want: collector.Gvk{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, transforms: 1},
+		{name: "replacement chain", from: apis[5].Gav, target: "v1.29.0", want: apis[6].Replacement},
+		{name: "chain stops at supported replacement", from: apis[5].Gav, target: "v1.23.0", want: apis[5].Replacement},
+		{name: "not outdated yet", from: apis[5].Gav, target: "v1.22.0", want: apis[5].Gav},
+		{name: "unknown api", from: collector.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}, target: "v1.29.0",
+			want: collector.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMigrator(apis, tt.target)
+			assert.NoError(t, err)
+			got, steps := m.resolve(tt.from)
+			assert.Equal(t, tt.want, got)
+			transforms := 0
+			for _, step := range steps {
+				if step != nil {
+					transforms++
+				}
+			}
+			assert.Equal(t, tt.transforms, transforms)
+		})
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "invalid yaml", data: "apiVersion: v1\nkind: [\n"},
+		{name: "schema migration of json", data: `{"apiVersion": "extensions/v1beta1", "kind": "Ingress", "spec": {}}`},
+		{name: "crd without version", data: "apiVersion: apiextensions.k8s.io/v1beta1\nkind: CustomResourceDefinition\nspec:\n  group: example.com\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMigrator(apis, "v1.29.0")
+			assert.NoError(t, err)
+			_, _, err = m.Migrate([]byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package migrate
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+//get return the value node of key in mapping node, nil when missing
+func get(node *yaml.Node, key string) *yaml.Node {
+	i := indexOf(node, key)
+	if i < 0 {
+		return nil
+	}
+	return node.Content[i+1]
+}
+
+//value return the scalar value of key in mapping node, empty when missing
+func value(node *yaml.Node, key string) string {
+	if v := get(node, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+//indexOf return the index of key in the mapping node content, -1 when missing
+func indexOf(node *yaml.Node, key string) int {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+//remove delete key from mapping node and return its value node
+func remove(node *yaml.Node, key string) *yaml.Node {
+	i := indexOf(node, key)
+	if i < 0 {
+		return nil
+	}
+	value := node.Content[i+1]
+	node.Content = append(node.Content[:i], node.Content[i+2:]...)
+	return value
+}
+
+//removePair delete key from mapping node and return its key and value nodes
+func removePair(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	i := indexOf(node, key)
+	if i < 0 {
+		return nil, nil
+	}
+	k := node.Content[i]
+	return k, remove(node, key)
+}
+
+//rename rename key of mapping node keeping its position and comments
+func rename(node *yaml.Node, from string, to string) {
+	if i := indexOf(node, from); i >= 0 {
+		node.Content[i].Value = to
+	}
+}
+
+//set set key to value, an existing key keeps its position, a new key is inserted at index or appended when index is out of range
+func set(node *yaml.Node, key string, value *yaml.Node, index int) {
+	if i := indexOf(node, key); i >= 0 {
+		node.Content[i+1] = value
+		return
+	}
+	pair := []*yaml.Node{scalar(key), value}
+	if index < 0 || index > len(node.Content) {
+		node.Content = append(node.Content, pair...)
+		return
+	}
+	node.Content = append(node.Content[:index], append(pair, node.Content[index:]...)...)
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func boolean(value bool) *yaml.Node {
+	v := "false"
+	if value {
+		v = "true"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: v}
+}
+
+func mapping(pairs ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: pairs}
+}
+
+func sequence(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: items}
+}
+
+//deepCopy copy node and all its children
+func deepCopy(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	c := *node
+	c.Content = make([]*yaml.Node, 0, len(node.Content))
+	for _, child := range node.Content {
+		c.Content = append(c.Content, deepCopy(child))
+	}
+	return &c
+}
+
+//moveComments move the head and line comments of a key to the key replacing it
+func moveComments(from *yaml.Node, to *yaml.Node) {
+	to.HeadComment, to.LineComment, to.FootComment = from.HeadComment, from.LineComment, from.FootComment
+}
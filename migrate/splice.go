@@ -0,0 +1,308 @@
+package migrate
+
+import (
+	"bytes"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+//splicer re-encode the nodes changed by the schema transforms and keep the original lines of the others, so the
+//indentation, blank lines and comment alignment of the untouched parts of a document are kept byte for byte
+type splicer struct {
+	lines   []string
+	before  map[*yaml.Node]snapshot
+	changed map[*yaml.Node]bool
+}
+
+//snapshot node state before the transforms
+type snapshot struct {
+	content []*yaml.Node
+	value   string
+	tag     string
+	style   yaml.Style
+}
+
+//newSplicer record the nodes of root parsed from text, it must be called before the transforms run
+func newSplicer(text string, root *yaml.Node) *splicer {
+	s := &splicer{lines: strings.SplitAfter(text, "\n"), before: make(map[*yaml.Node]snapshot), changed: make(map[*yaml.Node]bool)}
+	s.record(root)
+	return s
+}
+
+func (s *splicer) record(node *yaml.Node) {
+	if _, ok := s.before[node]; ok {
+		return
+	}
+	s.before[node] = snapshot{content: append([]*yaml.Node(nil), node.Content...), value: node.Value, tag: node.Tag, style: node.Style}
+	for _, child := range node.Content {
+		s.record(child)
+	}
+}
+
+//splice return the text of the transformed root, false when a changed node cannot be located in the original text
+func (s *splicer) splice(root *yaml.Node) (string, bool) {
+	first, text, ok := s.collection(root, 0, len(s.lines))
+	if !ok {
+		return "", false
+	}
+	out := strings.Join(s.lines[:first], "") + text
+	if original := strings.Join(s.lines, ""); !strings.HasSuffix(original, "\n") {
+		out = strings.TrimSuffix(out, "\n")
+	}
+	return out, true
+}
+
+//isChanged return if the transforms created or changed node or one of its children
+func (s *splicer) isChanged(node *yaml.Node) bool {
+	if changed, ok := s.changed[node]; ok {
+		return changed
+	}
+	b, ok := s.before[node]
+	changed := !ok || b.value != node.Value || b.tag != node.Tag || b.style != node.Style || !sameNodes(b.content, node.Content)
+	for _, child := range node.Content {
+		if changed {
+			break
+		}
+		changed = s.isChanged(child)
+	}
+	s.changed[node] = changed
+	return changed
+}
+
+func sameNodes(a []*yaml.Node, b []*yaml.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//collection render the block mapping or sequence node spanning the lines up to end, its first entry starts after the
+//lower line. Return the line of the first entry, the lines before it are left to the caller
+func (s *splicer) collection(node *yaml.Node, lower int, end int) (int, string, bool) {
+	b, ok := s.before[node]
+	if !ok || node.Style&yaml.FlowStyle != 0 || (node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode) || len(b.content) == 0 {
+		return 0, "", false
+	}
+	step := 1
+	if node.Kind == yaml.MappingNode {
+		step = 2
+	}
+	// an entry spans from its head comments to the head comments of the next entry, trailing blank lines stay last
+	starts := make([]int, 0, len(b.content)/step)
+	index := make(map[*yaml.Node]int)
+	for i := 0; i < len(b.content); i += step {
+		start, ok := s.entryStart(b.content[i], node.Kind == yaml.SequenceNode, lower)
+		if !ok {
+			return 0, "", false
+		}
+		index[b.content[i]] = len(starts)
+		starts = append(starts, start)
+		lower = b.content[i].Line
+	}
+	tail := s.trimBlank(starts[len(starts)-1], end)
+	starts = append(starts, tail)
+	// the blank lines after an entry follow it, or the new entry taking the place of a removed one
+	gaps := make([]string, len(starts)-1)
+	ends := make([]int, len(starts)-1)
+	for j := range ends {
+		ends[j] = s.trimBlank(starts[j], starts[j+1])
+		gaps[j] = strings.Join(s.lines[ends[j]:starts[j+1]], "")
+	}
+	removed := make(map[int]string)
+	for j := 0; j < len(b.content); j += step {
+		if !contains(node.Content, b.content[j]) {
+			removed[j/step] = gaps[j/step]
+		}
+	}
+	first := b.content[0]
+	indent, dashed := first.Column-1, false
+	if prefix := strings.TrimRight(s.lines[first.Line-1][:first.Column-1], " "); strings.HasSuffix(prefix, "-") {
+		// the entries of a sequence start at their dash, a mapping item of a sequence shares the line of its dash
+		dashed = node.Kind == yaml.MappingNode
+		if node.Kind == yaml.SequenceNode {
+			indent = len(prefix) - 1
+		}
+	}
+	var out strings.Builder
+	for i := 0; i < len(node.Content); i += step {
+		nodes := node.Content[i : i+step]
+		j, original := index[nodes[0]]
+		if dashed && i == 0 && (!original || j != 0) {
+			return 0, "", false
+		}
+		text, ok, gap := "", false, removed[i/step]
+		if original {
+			text, ok = s.entry(starts[j], ends[j], nodes...)
+			gap = gaps[j]
+		}
+		if !ok {
+			if text, ok = encodeEntry(indent, node.Kind, nodes...); !ok {
+				return 0, "", false
+			}
+		}
+		if len(text) > 0 && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		out.WriteString(text + gap)
+	}
+	out.WriteString(strings.Join(s.lines[tail:end], ""))
+	return starts[0], out.String(), true
+}
+
+//trimBlank return the end of the lines [start, end) without their trailing blank lines, keeping the first line
+func (s *splicer) trimBlank(start int, end int) int {
+	for end > start+1 && len(strings.TrimSpace(s.lines[end-1])) == 0 {
+		end--
+	}
+	return end
+}
+
+//contains return if node is one of nodes
+func contains(nodes []*yaml.Node, node *yaml.Node) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+//entryStart return the first line of the entry of node, the line of its key or dash or of the comments right above it
+func (s *splicer) entryStart(node *yaml.Node, item bool, lower int) (int, bool) {
+	start := node.Line - 1
+	if start < lower || start >= len(s.lines) {
+		return 0, false
+	}
+	if item && !strings.HasSuffix(strings.TrimRight(s.lines[start][:node.Column-1], " "), "-") {
+		return 0, false
+	}
+	for start > lower && strings.HasPrefix(strings.TrimSpace(s.lines[start-1]), "#") {
+		start--
+	}
+	return start, true
+}
+
+//entry render the original lines [start, end) of a mapping pair or sequence item with its changed nodes,
+//false when a node is new or cannot be edited in place
+func (s *splicer) entry(start int, end int, nodes ...*yaml.Node) (string, bool) {
+	lines := append([]string(nil), s.lines[start:end]...)
+	var nested *yaml.Node
+	// the value is edited ahead of its key, editing the key first would move the value column
+	for i := len(nodes) - 1; i >= 0; i-- {
+		node := nodes[i]
+		if !s.isChanged(node) {
+			continue
+		}
+		b, ok := s.before[node]
+		switch {
+		case !ok:
+			return "", false
+		case node.Kind == yaml.ScalarNode:
+			if node.Line-1 < start || node.Line-1 >= end {
+				return "", false
+			}
+			line, ok := replaceScalar(lines[node.Line-1-start], node, b.value)
+			if !ok {
+				return "", false
+			}
+			lines[node.Line-1-start] = line
+		case nested == nil && len(b.content) > 0:
+			nested = node
+		default:
+			return "", false
+		}
+	}
+	if nested == nil {
+		return strings.Join(lines, ""), true
+	}
+	lower := start
+	if len(nodes) == 2 {
+		lower = nodes[0].Line
+	}
+	first, text, ok := s.collection(nested, lower, end)
+	if !ok {
+		return "", false
+	}
+	return strings.Join(lines[:first-start], "") + text, true
+}
+
+//encodeEntry encode a new mapping pair or sequence item at the indentation of its collection
+func encodeEntry(indent int, kind yaml.Kind, nodes ...*yaml.Node) (string, bool) {
+	node := mapping(nodes...)
+	if kind == yaml.SequenceNode {
+		node = sequence(nodes...)
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return "", false
+	}
+	lines := strings.SplitAfter(buf.String(), "\n")
+	for i, line := range lines {
+		if len(strings.TrimSpace(line)) > 0 {
+			lines[i] = strings.Repeat(" ", indent) + line
+		}
+	}
+	return strings.Join(lines, ""), true
+}
+
+//replaceScalar replace the single line scalar node holding value on line by the encoding of node, a trailing comment
+//keeps its column when there is room for it
+func replaceScalar(line string, node *yaml.Node, value string) (string, bool) {
+	start := node.Column - 1
+	end, ok := scalarEnd(line, start, node.Style, value)
+	if !ok {
+		return "", false
+	}
+	// the comments around the scalar are kept from the line
+	bare := *node
+	bare.HeadComment, bare.LineComment, bare.FootComment = "", "", ""
+	encoded, err := yaml.Marshal(&bare)
+	if err != nil || strings.Count(strings.TrimSuffix(string(encoded), "\n"), "\n") > 0 {
+		return "", false
+	}
+	replaced := strings.TrimSuffix(string(encoded), "\n")
+	rest := line[end:]
+	if trimmed := strings.TrimLeft(rest, " "); strings.HasPrefix(trimmed, "#") {
+		spaces := len(rest) - len(trimmed) - (len(replaced) - (end - start))
+		if spaces < 1 {
+			spaces = 1
+		}
+		rest = strings.Repeat(" ", spaces) + trimmed
+	}
+	return line[:start] + replaced + rest, true
+}
+
+//scalarEnd return the end of the scalar holding value written at start of line, false when it is not there
+func scalarEnd(line string, start int, style yaml.Style, value string) (int, bool) {
+	if start < 0 || start >= len(line) {
+		return 0, false
+	}
+	switch {
+	case style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0:
+		quote := line[start]
+		for i := start + 1; i < len(line); i++ {
+			switch {
+			case quote == '"' && line[i] == '\\':
+				i++
+			case quote == '\'' && line[i] == '\'' && i+1 < len(line) && line[i+1] == '\'':
+				i++
+			case line[i] == quote:
+				return i + 1, true
+			}
+		}
+		return 0, false
+	case style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		return 0, false
+	}
+	if start+len(value) > len(line) || line[start:start+len(value)] != value {
+		return 0, false
+	}
+	return start + len(value), true
+}
@@ -0,0 +1,237 @@
+package migrate
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"k8s-outdated/collector"
+	"strconv"
+	"strings"
+)
+
+//Transform rewrite the object mapping node from the schema of one api version to the schema of its replacement
+type Transform func(obj *yaml.Node) error
+
+//Migration known migration of an api version to its replacement
+type Migration struct {
+	To collector.Gvk
+	// Transform is nil when the replacement has the same schema and only apiVersion changes
+	Transform Transform
+}
+
+//builtinMigrations schema transformations of the big removals
+var builtinMigrations = map[collector.Gvk]Migration{
+	{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}:         {To: collector.Gvk{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, Transform: ingressV1},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}:  {To: collector.Gvk{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, Transform: ingressV1},
+	{Group: "batch", Version: "v1beta1", Kind: "CronJob"}:              {To: collector.Gvk{Group: "batch", Version: "v1", Kind: "CronJob"}},
+	{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}: {To: collector.Gvk{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}},
+	{Group: "autoscaling", Version: "v2beta1", Kind: "HorizontalPodAutoscaler"}: {
+		To: collector.Gvk{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}, Transform: hpaV2},
+	{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"}: {To: collector.Gvk{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}},
+	{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}: {
+		To: collector.Gvk{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, Transform: crdV1},
+}
+
+//ingressV1 move serviceName/servicePort backends to service.name/service.port, spec.backend to spec.defaultBackend
+//and default pathType of every path to ImplementationSpecific, the v1beta1 behavior
+func ingressV1(obj *yaml.Node) error {
+	spec := get(obj, "spec")
+	if spec == nil {
+		return nil
+	}
+	rename(spec, "backend", "defaultBackend")
+	if err := ingressBackend(get(spec, "defaultBackend")); err != nil {
+		return err
+	}
+	rules := get(spec, "rules")
+	if rules == nil {
+		return nil
+	}
+	for _, rule := range rules.Content {
+		paths := get(get(rule, "http"), "paths")
+		if paths == nil {
+			continue
+		}
+		for _, p := range paths.Content {
+			if get(p, "pathType") == nil {
+				set(p, "pathType", scalar("ImplementationSpecific"), indexOf(p, "path")+2)
+			}
+			if err := ingressBackend(get(p, "backend")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func ingressBackend(backend *yaml.Node) error {
+	if backend == nil || backend.Kind != yaml.MappingNode {
+		return nil
+	}
+	index := indexOf(backend, "serviceName")
+	if index < 0 {
+		return nil
+	}
+	nameKey := backend.Content[index]
+	name := remove(backend, "serviceName")
+	service := mapping(scalar("name"), name)
+	if port := remove(backend, "servicePort"); port != nil {
+		service.Content = append(service.Content, scalar("port"), servicePort(port))
+	}
+	set(backend, "service", service, index)
+	moveComments(nameKey, backend.Content[index])
+	return nil
+}
+
+//servicePort convert an int or string servicePort to a number or name port
+func servicePort(port *yaml.Node) *yaml.Node {
+	if _, err := strconv.Atoi(port.Value); err == nil {
+		port.Tag = "!!int"
+		return mapping(scalar("number"), port)
+	}
+	return mapping(scalar("name"), port)
+}
+
+//hpaV2 convert the v2beta1 metric targets to the v2 metric identifier and target shape
+func hpaV2(obj *yaml.Node) error {
+	metrics := get(get(obj, "spec"), "metrics")
+	if metrics == nil {
+		return nil
+	}
+	for _, m := range metrics.Content {
+		metricType := get(m, "type")
+		if metricType == nil {
+			continue
+		}
+		source := get(m, lowerFirst(metricType.Value))
+		if source == nil || source.Kind != yaml.MappingNode {
+			continue
+		}
+		switch metricType.Value {
+		case "Resource", "ContainerResource":
+			hpaTarget(source, map[string]string{"targetAverageUtilization": "Utilization", "targetAverageValue": "AverageValue"})
+		case "Pods":
+			hpaMetric(source, "selector")
+			hpaTarget(source, map[string]string{"targetAverageValue": "AverageValue"})
+		case "Object":
+			rename(source, "target", "describedObject")
+			hpaMetric(source, "selector")
+			hpaTarget(source, map[string]string{"targetValue": "Value", "averageValue": "AverageValue"})
+		case "External":
+			hpaMetric(source, "metricSelector")
+			hpaTarget(source, map[string]string{"targetValue": "Value", "targetAverageValue": "AverageValue"})
+		default:
+			return fmt.Errorf("unknown HorizontalPodAutoscaler metric type %q", metricType.Value)
+		}
+	}
+	return nil
+}
+
+//hpaMetric move metricName and its selector to metric.name and metric.selector
+func hpaMetric(source *yaml.Node, selectorKey string) {
+	index := indexOf(source, "metricName")
+	if index < 0 {
+		return
+	}
+	metric := mapping(scalar("name"), remove(source, "metricName"))
+	if selector := remove(source, selectorKey); selector != nil {
+		metric.Content = append(metric.Content, scalar("selector"), selector)
+	}
+	set(source, "metric", metric, index)
+}
+
+//hpaTarget move the v2beta1 target fields to target.type and target.<value field>
+func hpaTarget(source *yaml.Node, fields map[string]string) {
+	for i := 0; i+1 < len(source.Content); i += 2 {
+		targetType, ok := fields[source.Content[i].Value]
+		if !ok {
+			continue
+		}
+		valueKey := map[string]string{"Utilization": "averageUtilization", "AverageValue": "averageValue", "Value": "value"}[targetType]
+		value := source.Content[i+1]
+		target := mapping(scalar("type"), scalar(targetType), scalar(valueKey), value)
+		key := scalar("target")
+		moveComments(source.Content[i], key)
+		source.Content[i], source.Content[i+1] = key, target
+		return
+	}
+}
+
+//crdV1 move spec.version, spec.validation, spec.subresources and spec.additionalPrinterColumns to spec.versions[],
+//rename JSONPath to jsonPath and move the conversion webhook settings to spec.conversion.webhook
+func crdV1(obj *yaml.Node) error {
+	spec := get(obj, "spec")
+	if spec == nil {
+		return nil
+	}
+	versions := get(spec, "versions")
+	if versions == nil {
+		index := indexOf(spec, "version")
+		name := get(spec, "version")
+		if name == nil {
+			return fmt.Errorf("CustomResourceDefinition has neither spec.version nor spec.versions")
+		}
+		versions = sequence(mapping(scalar("name"), name, scalar("served"), boolean(true), scalar("storage"), boolean(true)))
+		set(spec, "versions", versions, index)
+	}
+	remove(spec, "version")
+	validationKey, validation := removePair(spec, "validation")
+	subresourcesKey, subresources := removePair(spec, "subresources")
+	columnsKey, columns := removePair(spec, "additionalPrinterColumns")
+	for i, v := range versions.Content {
+		if get(v, "schema") == nil {
+			schema := deepCopy(validation)
+			if schema == nil {
+				schema = mapping(scalar("openAPIV3Schema"), mapping(scalar("type"), scalar("object"),
+					scalar("x-kubernetes-preserve-unknown-fields"), boolean(true)))
+			}
+			set(v, "schema", schema, -1)
+			if i == 0 && validationKey != nil {
+				moveComments(validationKey, v.Content[indexOf(v, "schema")])
+			}
+		}
+		if subresources != nil && get(v, "subresources") == nil {
+			set(v, "subresources", deepCopy(subresources), -1)
+			if i == 0 {
+				moveComments(subresourcesKey, v.Content[indexOf(v, "subresources")])
+			}
+		}
+		if columns != nil && get(v, "additionalPrinterColumns") == nil {
+			set(v, "additionalPrinterColumns", deepCopy(columns), -1)
+			if i == 0 {
+				moveComments(columnsKey, v.Content[indexOf(v, "additionalPrinterColumns")])
+			}
+		}
+		if vColumns := get(v, "additionalPrinterColumns"); vColumns != nil {
+			for _, c := range vColumns.Content {
+				rename(c, "JSONPath", "jsonPath")
+			}
+		}
+	}
+	crdConversion(get(spec, "conversion"))
+	return nil
+}
+
+func crdConversion(conversion *yaml.Node) {
+	strategy := get(conversion, "strategy")
+	if strategy == nil || strategy.Value != "Webhook" || get(conversion, "webhook") != nil {
+		return
+	}
+	webhook := mapping()
+	if clientConfig := remove(conversion, "webhookClientConfig"); clientConfig != nil {
+		webhook.Content = append(webhook.Content, scalar("clientConfig"), clientConfig)
+	}
+	reviewVersions := remove(conversion, "conversionReviewVersions")
+	if reviewVersions == nil {
+		// v1beta1 defaulted conversionReviewVersions, v1 requires it
+		reviewVersions = sequence(scalar("v1beta1"))
+	}
+	webhook.Content = append(webhook.Content, scalar("conversionReviewVersions"), reviewVersions)
+	set(conversion, "webhook", webhook, -1)
+}
+
+func lowerFirst(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"k8s-outdated/scanner"
+	"strconv"
+)
+
+//FindingList versioned document holding the scan findings
+type FindingList struct {
+	SchemaVersion string    `json:"schemaVersion" yaml:"schemaVersion"`
+	TargetVersion string    `json:"targetVersion" yaml:"targetVersion"`
+	Items         []Finding `json:"items" yaml:"items"`
+}
+
+//Finding machine readable scan finding record
+type Finding struct {
+	File       string `json:"file" yaml:"file"`
+	Line       int    `json:"line" yaml:"line"`
+	Document   int    `json:"document" yaml:"document"`
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Name       string `json:"name" yaml:"name"`
+	Namespace  string `json:"namespace" yaml:"namespace"`
+	Status     string `json:"status" yaml:"status"`
+	Deprecated string `json:"deprecated" yaml:"deprecated"`
+	Removed    string `json:"removed" yaml:"removed"`
+	// Replacement is the apiVersion and kind to migrate to, e.g. batch/v1 CronJob, or the field to set instead of a deprecated field
+	Replacement string `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+	// Field is the path of the deprecated field, annotation or label in the object, e.g. spec.template.spec.serviceAccount
+	Field    string `json:"field,omitempty" yaml:"field,omitempty"`
+	Chart    string `json:"chart,omitempty" yaml:"chart,omitempty"`
+	Release  string `json:"release,omitempty" yaml:"release,omitempty"`
+	Revision int    `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+}
+
+//findingRow table row of a scan finding
+type findingRow struct {
+	File        string `header:"file"`
+	Line        int    `header:"line"`
+	Document    int    `header:"doc"`
+	Object      string `header:"object"`
+	API         string `header:"k8s api"`
+	Field       string `header:"field"`
+	Status      string `header:"status"`
+	Deprecated  string `header:"deprecated Version"`
+	Removed     string `header:"removed Version"`
+	Replacement string `header:"replacement"`
+}
+
+var findingCSVHeader = []string{"file", "line", "document", "apiVersion", "kind", "name", "namespace", "status", "deprecated", "removed",
+	"chart", "release", "revision", "template", "replacement", "field"}
+
+//NewFindingList build versioned finding list document from scan findings
+func NewFindingList(targetVersion string, findings []scanner.Finding) FindingList {
+	items := make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		item := Finding{
+			File:       f.File,
+			Line:       f.Line,
+			Document:   f.Document,
+			APIVersion: f.APIVersion,
+			Kind:       f.Kind,
+			Name:       f.Name,
+			Namespace:  f.Namespace,
+			Status:     string(f.Status),
+			Field:      f.Path,
+		}
+		if f.API != nil {
+			item.Deprecated = f.API.Deprecated.String()
+			item.Removed = f.API.Removed.String()
+			item.Replacement = f.API.Replacement.String()
+		}
+		if f.Field != nil {
+			item.Deprecated = f.Field.Deprecated.String()
+			item.Removed = f.Field.Removed.String()
+			item.Replacement = f.Field.Replacement
+		}
+		if f.Metadata != nil {
+			item.Deprecated = f.Metadata.Deprecated.String()
+			item.Removed = f.Metadata.Removed.String()
+			item.Replacement = f.Metadata.Replacement
+		}
+		if f.Helm != nil {
+			item.Chart = f.Helm.Chart
+			item.Release = f.Helm.Release
+			item.Revision = f.Helm.Revision
+			item.Template = f.Helm.Template
+		}
+		items = append(items, item)
+	}
+	return FindingList{SchemaVersion: SchemaVersion, TargetVersion: targetVersion, Items: items}
+}
+
+//WriteFindings write scan findings to w in the requested format
+func WriteFindings(w io.Writer, format Format, targetVersion string, findings []scanner.Finding) error {
+	doc := NewFindingList(targetVersion, findings)
+	switch format {
+	case Table:
+		return writeTable(w, findingRows(doc.Items))
+	case JSON:
+		return writeJSON(w, doc)
+	case YAML:
+		return writeYAML(w, doc)
+	case CSV:
+		records := make([][]string, 0, len(doc.Items))
+		for _, i := range doc.Items {
+			revision := ""
+			if i.Revision > 0 {
+				revision = strconv.Itoa(i.Revision)
+			}
+			records = append(records, []string{i.File, strconv.Itoa(i.Line), strconv.Itoa(i.Document), i.APIVersion, i.Kind, i.Name, i.Namespace, i.Status,
+				i.Deprecated, i.Removed, i.Chart, i.Release, revision, i.Template, i.Replacement, i.Field})
+		}
+		return writeCSV(w, findingCSVHeader, records)
+	case NDJSON:
+		items := make([]interface{}, 0, len(doc.Items))
+		for _, i := range doc.Items {
+			items = append(items, i)
+		}
+		return writeNDJSON(w, items)
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
+
+func findingRows(items []Finding) []findingRow {
+	rows := make([]findingRow, 0, len(items))
+	for _, i := range items {
+		object := i.Name
+		if len(i.Namespace) > 0 {
+			object = i.Namespace + "/" + i.Name
+		}
+		rows = append(rows, findingRow{
+			File:        i.File,
+			Line:        i.Line,
+			Document:    i.Document,
+			Object:      object,
+			API:         i.APIVersion + "/" + i.Kind,
+			Field:       i.Field,
+			Status:      i.Status,
+			Deprecated:  i.Deprecated,
+			Removed:     i.Removed,
+			Replacement: i.Replacement,
+		})
+	}
+	return rows
+}
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"k8s-outdated/scanner"
+	"testing"
+)
+
+var findings = []scanner.Finding{
+	{Object: scanner.Object{APIVersion: "batch/v1beta1", Kind: "CronJob", Name: "hello", Namespace: "batch", File: "cron.yaml", Line: 2, Document: 1},
+		API: apis[0], Status: scanner.StatusRemoved},
+	{Object: scanner.Object{APIVersion: "v1", Kind: "Pod", Name: "builder", File: "pod.yaml", Line: 6},
+		Field: &collector.DeprecatedField{Gav: collector.Gvk{Version: "v1", Kind: "Pod"}, Path: "spec.serviceAccount", Deprecated: collector.MustParseKubeVersion("v1.24"), Replacement: "serviceAccountName"},
+		Path:  "spec.serviceAccount", Status: scanner.StatusDeprecated},
+	{Object: scanner.Object{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "web", File: "ingress.yaml", Line: 5},
+		Metadata: &collector.DeprecatedMetadata{Type: collector.Annotation, Key: "kubernetes.io/ingress.class", Deprecated: collector.MustParseKubeVersion("v1.18"), Replacement: "spec.ingressClassName"},
+		Path:     "metadata.annotations[kubernetes.io/ingress.class]", Status: scanner.StatusDeprecated},
+}
+
+func TestWriteFindings(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{name: "csv", format: CSV, want: "file,line,document,apiVersion,kind,name,namespace,status,deprecated,removed,chart,release,revision,template,replacement,field\n" +
+			"cron.yaml,2,1,batch/v1beta1,CronJob,hello,batch,removed,v1.21,v1.25,,,,,batch/v1 CronJob,\n" +
+			"pod.yaml,6,0,v1,Pod,builder,,deprecated,v1.24,,,,,,serviceAccountName,spec.serviceAccount\n" +
+			"ingress.yaml,5,0,networking.k8s.io/v1,Ingress,web,,deprecated,v1.18,,,,,,spec.ingressClassName,metadata.annotations[kubernetes.io/ingress.class]\n"},
+		{name: "ndjson", format: NDJSON, want: `{"file":"cron.yaml","line":2,"document":1,"apiVersion":"batch/v1beta1","kind":"CronJob","name":"hello","namespace":"batch","status":"removed","deprecated":"v1.21","removed":"v1.25","replacement":"batch/v1 CronJob"}` + "\n" +
+			`{"file":"pod.yaml","line":6,"document":0,"apiVersion":"v1","kind":"Pod","name":"builder","namespace":"","status":"deprecated","deprecated":"v1.24","removed":"","replacement":"serviceAccountName","field":"spec.serviceAccount"}` + "\n" +
+			`{"file":"ingress.yaml","line":5,"document":0,"apiVersion":"networking.k8s.io/v1","kind":"Ingress","name":"web","namespace":"","status":"deprecated","deprecated":"v1.18","removed":"","replacement":"spec.ingressClassName","field":"metadata.annotations[kubernetes.io/ingress.class]"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteFindings(&buf, tt.format, "v1.25.0", findings)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestNewFindingList(t *testing.T) {
+	doc := NewFindingList("v1.25.0", findings)
+	assert.Equal(t, SchemaVersion, doc.SchemaVersion)
+	assert.Equal(t, "v1.25.0", doc.TargetVersion)
+	assert.Equal(t, Finding{File: "cron.yaml", Line: 2, Document: 1, APIVersion: "batch/v1beta1", Kind: "CronJob", Name: "hello", Namespace: "batch",
+		Status: "removed", Deprecated: "v1.21", Removed: "v1.25", Replacement: "batch/v1 CronJob"}, doc.Items[0])
+	assert.Equal(t, collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}, findings[0].API.Gav)
+	assert.Equal(t, Finding{File: "pod.yaml", Line: 6, APIVersion: "v1", Kind: "Pod", Name: "builder", Status: "deprecated", Deprecated: "v1.24",
+		Replacement: "serviceAccountName", Field: "spec.serviceAccount"}, doc.Items[1])
+}
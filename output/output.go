@@ -0,0 +1,168 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"k8s-outdated/collector"
+	"sort"
+	"strings"
+)
+
+//Format of the command output
+type Format string
+
+//Supported output formats
+const (
+	Table  Format = "table"
+	JSON   Format = "json"
+	YAML   Format = "yaml"
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+//SchemaVersion of the machine readable api list document, bumped on any breaking change to APIList or API
+const SchemaVersion = "k8s-outdated/v1"
+
+var apiCSVHeader = []string{"group", "version", "kind", "deprecated", "removed", "description", "source", "replacement", "conflicts", "introduced"}
+
+//APIList versioned document holding the outdated apis
+type APIList struct {
+	SchemaVersion string `json:"schemaVersion" yaml:"schemaVersion"`
+	Items         []API  `json:"items" yaml:"items"`
+}
+
+//API machine readable outdated api record
+type API struct {
+	Group       string     `json:"group" yaml:"group"`
+	Version     string     `json:"version" yaml:"version"`
+	Kind        string     `json:"kind" yaml:"kind"`
+	Deprecated  string     `json:"deprecated" yaml:"deprecated"`
+	Removed     string     `json:"removed" yaml:"removed"`
+	Description string     `json:"description" yaml:"description"`
+	Source      string     `json:"source" yaml:"source"`
+	Replacement *GVK       `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+	Conflicts   []Conflict `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+	// Provenance is the source of each merged field, e.g. removed: markdown
+	Provenance map[string]string `json:"provenance,omitempty" yaml:"provenance,omitempty"`
+	Introduced string            `json:"introduced,omitempty" yaml:"introduced,omitempty"`
+	Migration  *Migration        `json:"migration,omitempty" yaml:"migration,omitempty"`
+}
+
+//Migration machine readable deprecation guide section of an api
+type Migration struct {
+	Title          string   `json:"title" yaml:"title"`
+	URL            string   `json:"url,omitempty" yaml:"url,omitempty"`
+	Steps          []string `json:"steps,omitempty" yaml:"steps,omitempty"`
+	NotableChanges []string `json:"notableChanges,omitempty" yaml:"notableChanges,omitempty"`
+}
+
+//GVK machine readable group/version/kind
+type GVK struct {
+	Group   string `json:"group" yaml:"group"`
+	Version string `json:"version" yaml:"version"`
+	Kind    string `json:"kind" yaml:"kind"`
+}
+
+//Conflict machine readable field the sources disagree on, values are keyed by source
+type Conflict struct {
+	Field  string            `json:"field" yaml:"field"`
+	Values map[string]string `json:"values" yaml:"values"`
+}
+
+//String return the GVK as apiVersion and kind, e.g. batch/v1 CronJob
+func (g *GVK) String() string {
+	if g == nil {
+		return ""
+	}
+	return collector.Gvk{Group: g.Group, Version: g.Version, Kind: g.Kind}.String()
+}
+
+//conflictsColumn flatten conflicts to a single csv column, e.g. replacement: markdown=batch/v1 CronJob; swagger=...
+func conflictsColumn(conflicts []Conflict) string {
+	fields := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		sources := make([]string, 0, len(c.Values))
+		for source := range c.Values {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		values := make([]string, 0, len(sources))
+		for _, source := range sources {
+			values = append(values, source+"="+c.Values[source])
+		}
+		fields = append(fields, c.Field+": "+strings.Join(values, "; "))
+	}
+	return strings.Join(fields, " | ")
+}
+
+//Formats return the supported output format names
+func Formats() []string {
+	return []string{string(Table), string(JSON), string(YAML), string(CSV), string(NDJSON)}
+}
+
+//ParseFormat parse output format name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats() {
+		if strings.EqualFold(f, name) {
+			return Format(f), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported output format %q, supported formats: %s", name, strings.Join(Formats(), ", "))
+}
+
+//NewAPIList build versioned api list document from outdated apis
+func NewAPIList(apis []*collector.OutdatedAPI) APIList {
+	items := make([]API, 0, len(apis))
+	for _, a := range apis {
+		item := API{
+			Group:       a.Gav.Group,
+			Version:     a.Gav.Version,
+			Kind:        a.Gav.Kind,
+			Deprecated:  a.Deprecated.String(),
+			Removed:     a.Removed.String(),
+			Description: a.Description,
+			Source:      a.Source,
+			Introduced:  a.Introduced.String(),
+		}
+		if len(a.Provenance) > 0 {
+			item.Provenance = a.Provenance
+		}
+		if !a.Replacement.IsZero() {
+			item.Replacement = &GVK{Group: a.Replacement.Group, Version: a.Replacement.Version, Kind: a.Replacement.Kind}
+		}
+		if m := a.Migration; m != nil {
+			item.Migration = &Migration{Title: m.Title, URL: m.URL, Steps: m.Steps, NotableChanges: m.NotableChanges}
+		}
+		for _, c := range a.Conflicts {
+			item.Conflicts = append(item.Conflicts, Conflict{Field: c.Field, Values: c.Values})
+		}
+		items = append(items, item)
+	}
+	return APIList{SchemaVersion: SchemaVersion, Items: items}
+}
+
+//WriteAPIs write outdated apis to w in the requested format
+func WriteAPIs(w io.Writer, format Format, apis []*collector.OutdatedAPI) error {
+	switch format {
+	case Table:
+		return writeTable(w, collector.ToK8sAPIs(apis))
+	case JSON:
+		return writeJSON(w, NewAPIList(apis))
+	case YAML:
+		return writeYAML(w, NewAPIList(apis))
+	case CSV:
+		records := make([][]string, 0, len(apis))
+		for _, i := range NewAPIList(apis).Items {
+			records = append(records, []string{i.Group, i.Version, i.Kind, i.Deprecated, i.Removed, i.Description, i.Source,
+				i.Replacement.String(), conflictsColumn(i.Conflicts), i.Introduced})
+		}
+		return writeCSV(w, apiCSVHeader, records)
+	case NDJSON:
+		items := make([]interface{}, 0, len(apis))
+		for _, i := range NewAPIList(apis).Items {
+			items = append(items, i)
+		}
+		return writeNDJSON(w, items)
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
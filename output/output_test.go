@@ -0,0 +1,101 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"k8s-outdated/collector"
+	"strings"
+	"testing"
+)
+
+var apis = []*collector.OutdatedAPI{
+	{Description: "CronJob represents the configuration of a single cron job.", Introduced: collector.MustParseKubeVersion("v1.8"), Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Source: "swagger,markdown", Gav: collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"},
+		Replacement: collector.Gvk{Group: "batch", Version: "v1", Kind: "CronJob"},
+		Conflicts:   []collector.Conflict{{Field: "replacement", Values: map[string]string{"swagger": "batch/v1 CronJob", "markdown": "batch/v2alpha1 CronJob"}}},
+		Provenance:  map[string]string{"deprecated": "swagger", "removed": "markdown", "replacement": "swagger"}},
+	{Description: "The **flowcontrol.apiserver.k8s.io/v1beta1** API version of FlowSchema, \"quoted\"", Removed: collector.MustParseKubeVersion("v1.26"), Source: "markdown", Gav: collector.Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema"},
+		Migration: &collector.Migration{Title: "Flow control resources", Anchor: "flowcontrol-resources-v126", URL: "https://kubernetes.io/docs/reference/using-api/deprecation-guide/#flowcontrol-resources-v126",
+			Steps: []string{"Migrate manifests and API clients to use the **flowcontrol.apiserver.k8s.io/v1beta2** API version, available since v1.23."}}},
+}
+
+func TestWriteAPIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{name: "csv", format: CSV, want: "group,version,kind,deprecated,removed,description,source,replacement,conflicts,introduced\n" +
+			"batch,v1beta1,CronJob,v1.21,v1.25,CronJob represents the configuration of a single cron job.,\"swagger,markdown\",batch/v1 CronJob,replacement: markdown=batch/v2alpha1 CronJob; swagger=batch/v1 CronJob,v1.8\n" +
+			"flowcontrol.apiserver.k8s.io,v1beta1,FlowSchema,,v1.26,\"The **flowcontrol.apiserver.k8s.io/v1beta1** API version of FlowSchema, \"\"quoted\"\"\",markdown,,,\n"},
+		{name: "ndjson", format: NDJSON, want: `{"group":"batch","version":"v1beta1","kind":"CronJob","deprecated":"v1.21","removed":"v1.25","description":"CronJob represents the configuration of a single cron job.","source":"swagger,markdown",` +
+			`"replacement":{"group":"batch","version":"v1","kind":"CronJob"},"conflicts":[{"field":"replacement","values":{"markdown":"batch/v2alpha1 CronJob","swagger":"batch/v1 CronJob"}}],` +
+			`"provenance":{"deprecated":"swagger","removed":"markdown","replacement":"swagger"},"introduced":"v1.8"}` + "\n" +
+			`{"group":"flowcontrol.apiserver.k8s.io","version":"v1beta1","kind":"FlowSchema","deprecated":"","removed":"v1.26","description":"The **flowcontrol.apiserver.k8s.io/v1beta1** API version of FlowSchema, \"quoted\"","source":"markdown",` +
+			`"migration":{"title":"Flow control resources","url":"https://kubernetes.io/docs/reference/using-api/deprecation-guide/#flowcontrol-resources-v126",` +
+			`"steps":["Migrate manifests and API clients to use the **flowcontrol.apiserver.k8s.io/v1beta2** API version, available since v1.23."]}}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteAPIs(&buf, tt.format, apis)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriteAPIsDocuments(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    Format
+		unmarshal func([]byte, interface{}) error
+	}{
+		{name: "json", format: JSON, unmarshal: json.Unmarshal},
+		{name: "yaml", format: YAML, unmarshal: yaml.Unmarshal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteAPIs(&buf, tt.format, apis)
+			assert.NoError(t, err)
+			var doc APIList
+			err = tt.unmarshal(buf.Bytes(), &doc)
+			assert.NoError(t, err)
+			assert.Equal(t, NewAPIList(apis), doc)
+			assert.Equal(t, SchemaVersion, doc.SchemaVersion)
+		})
+	}
+}
+
+func TestWriteAPIsTable(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteAPIs(&buf, Table, apis)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(buf.String(), "batch.v1beta1.CronJob"))
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		want    Format
+		wantErr bool
+	}{
+		{name: "json", format: "json", want: JSON},
+		{name: "upper case", format: "YAML", want: YAML},
+		{name: "unknown", format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.format)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"k8s-outdated/plan"
+)
+
+//Change statuses of an api at an upgrade hop
+const (
+	changeRemoved    = "removed"
+	changeDeprecated = "deprecated"
+)
+
+//Plan versioned document holding the upgrade plan
+type Plan struct {
+	SchemaVersion string `json:"schemaVersion" yaml:"schemaVersion"`
+	From          string `json:"from" yaml:"from"`
+	To            string `json:"to" yaml:"to"`
+	Hops          []Hop  `json:"hops" yaml:"hops"`
+}
+
+//Hop machine readable api changes of an upgrade to the next minor release
+type Hop struct {
+	From       string `json:"from" yaml:"from"`
+	To         string `json:"to" yaml:"to"`
+	Removed    []API  `json:"removed" yaml:"removed"`
+	Deprecated []API  `json:"deprecated" yaml:"deprecated"`
+}
+
+//PlanChange flat record of a single api change at an upgrade hop, used by the csv and ndjson formats
+type PlanChange struct {
+	From   string `json:"from" yaml:"from"`
+	To     string `json:"to" yaml:"to"`
+	Change string `json:"change" yaml:"change"`
+	API    `yaml:",inline"`
+}
+
+//planRow table row of an upgrade plan change
+type planRow struct {
+	Hop         string `header:"hop"`
+	Change      string `header:"change"`
+	API         string `header:"k8s api"`
+	Deprecated  string `header:"deprecated Version"`
+	Removed     string `header:"removed Version"`
+	Replacement string `header:"replacement"`
+}
+
+var planCSVHeader = []string{"from", "to", "change", "group", "version", "kind", "deprecated", "removed", "replacement"}
+
+//NewPlan build versioned upgrade plan document from plan hops
+func NewPlan(from string, to string, hops []plan.Hop) Plan {
+	items := make([]Hop, 0, len(hops))
+	for _, h := range hops {
+		items = append(items, Hop{From: h.From, To: h.To, Removed: NewAPIList(h.Removed).Items, Deprecated: NewAPIList(h.Deprecated).Items})
+	}
+	return Plan{SchemaVersion: SchemaVersion, From: from, To: to, Hops: items}
+}
+
+//WritePlan write upgrade plan to w in the requested format
+func WritePlan(w io.Writer, format Format, from string, to string, hops []plan.Hop) error {
+	doc := NewPlan(from, to, hops)
+	switch format {
+	case Table:
+		rows := make([]planRow, 0)
+		for _, c := range doc.changes() {
+			rows = append(rows, planRow{
+				Hop:         c.From + " -> " + c.To,
+				Change:      c.Change,
+				API:         c.Group + "/" + c.Version + "/" + c.Kind,
+				Deprecated:  c.Deprecated,
+				Removed:     c.Removed,
+				Replacement: c.Replacement.String(),
+			})
+		}
+		return writeTable(w, rows)
+	case JSON:
+		return writeJSON(w, doc)
+	case YAML:
+		return writeYAML(w, doc)
+	case CSV:
+		records := make([][]string, 0)
+		for _, c := range doc.changes() {
+			records = append(records, []string{c.From, c.To, c.Change, c.Group, c.Version, c.Kind, c.Deprecated, c.Removed, c.Replacement.String()})
+		}
+		return writeCSV(w, planCSVHeader, records)
+	case NDJSON:
+		items := make([]interface{}, 0)
+		for _, c := range doc.changes() {
+			items = append(items, c)
+		}
+		return writeNDJSON(w, items)
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
+
+//changes flatten the plan hops to one record per api change
+func (p Plan) changes() []PlanChange {
+	changes := make([]PlanChange, 0)
+	for _, h := range p.Hops {
+		for _, api := range h.Removed {
+			changes = append(changes, PlanChange{From: h.From, To: h.To, Change: changeRemoved, API: api})
+		}
+		for _, api := range h.Deprecated {
+			changes = append(changes, PlanChange{From: h.From, To: h.To, Change: changeDeprecated, API: api})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,45 @@
+package output
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/plan"
+	"testing"
+)
+
+var hops = []plan.Hop{
+	{From: "v1.24", To: "v1.25", Removed: apis[:1], Deprecated: apis[1:]},
+}
+
+func TestWritePlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		want     string
+		contains []string
+	}{
+		{name: "csv", format: CSV, contains: []string{"from,to,change,group,version,kind,deprecated,removed,replacement\n",
+			"v1.24,v1.25,removed,batch,v1beta1,CronJob,v1.21,v1.25,batch/v1 CronJob\n"}},
+		{name: "json", format: JSON, contains: []string{`"from": "v1.24.0"`, `"to": "v1.25"`, `"removed": [`, `"deprecated": [`}},
+		{name: "table", format: Table, contains: []string{"v1.24 -> v1.25", "batch/v1beta1/CronJob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WritePlan(&buf, tt.format, "v1.24.0", "v1.25.0", hops)
+			assert.NoError(t, err)
+			for _, c := range tt.contains {
+				assert.Contains(t, buf.String(), c)
+			}
+		})
+	}
+}
+
+func TestPlanChanges(t *testing.T) {
+	var buf bytes.Buffer
+	err := WritePlan(&buf, NDJSON, "v1.24.0", "v1.25.0", hops)
+	assert.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, len(apis))
+	assert.Contains(t, string(lines[0]), `{"from":"v1.24","to":"v1.25","change":"removed","group":"batch"`)
+}
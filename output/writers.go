@@ -0,0 +1,53 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/lensesio/tableprinter"
+	"gopkg.in/yaml.v3"
+	"io"
+)
+
+func writeTable(w io.Writer, rows interface{}) error {
+	tableprinter.Print(w, rows)
+	return nil
+}
+
+func writeJSON(w io.Writer, doc interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func writeYAML(w io.Writer, doc interface{}) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func writeCSV(w io.Writer, header []string, records [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := cw.Write(r); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeNDJSON(w io.Writer, items []interface{}) error {
+	encoder := json.NewEncoder(w)
+	for _, i := range items {
+		if err := encoder.Encode(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
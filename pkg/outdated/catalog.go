@@ -0,0 +1,200 @@
+//Package outdated is the library entry point of k8s-outdated: a Catalog of the deprecated and removed k8s apis built
+//from any set of collectors, answering whether an api is deprecated or removed at a k8s version and what replaces it.
+//
+//The exported identifiers of this package follow semantic versioning, tracked by Version: a minor version adds
+//identifiers, a major version is required to change or remove one. The other packages of the module are the
+//implementation of the command line and carry no such promise, none of their types is part of this package api.
+package outdated
+
+import (
+	"k8s-outdated/collector"
+	"sort"
+)
+
+//Version of the library api, semantic versioning of the exported identifiers of this package
+const Version = "1.0.0"
+
+//GVK group, version and kind of a k8s api, the core group is empty
+type GVK struct {
+	Group   string
+	Version string
+	Kind    string
+}
+
+//ParseGVK build a GVK from a manifest apiVersion and kind, e.g. batch/v1beta1 and CronJob
+func ParseGVK(apiVersion string, kind string) (GVK, error) {
+	gvk, err := collector.ParseGvk(apiVersion, kind)
+	return fromGvk(gvk), err
+}
+
+//IsZero check whether the GVK is unset
+func (g GVK) IsZero() bool {
+	return g == GVK{}
+}
+
+//APIVersion return the manifest apiVersion of the GVK, e.g. batch/v1beta1 or v1 for the core group
+func (g GVK) APIVersion() string {
+	return g.gvk().APIVersion()
+}
+
+//String return the GVK as apiVersion and kind, e.g. batch/v1beta1 CronJob
+func (g GVK) String() string {
+	return g.gvk().String()
+}
+
+func (g GVK) gvk() collector.Gvk {
+	return collector.Gvk{Group: g.Group, Version: g.Version, Kind: g.Kind}
+}
+
+func fromGvk(g collector.Gvk) GVK {
+	return GVK{Group: g.Group, Version: g.Version, Kind: g.Kind}
+}
+
+//KubeVersion k8s minor release, e.g. v1.25, the zero value is an unknown version
+type KubeVersion struct {
+	Major int
+	Minor int
+}
+
+//ParseKubeVersion parse a k8s release such as v1.25, 1.25 or v1.25.3
+func ParseKubeVersion(s string) (KubeVersion, error) {
+	v, err := collector.ParseKubeVersion(s)
+	return fromKubeVersion(v), err
+}
+
+//IsZero check whether the version is unknown
+func (v KubeVersion) IsZero() bool {
+	return v == KubeVersion{}
+}
+
+//String return the version as vMAJOR.MINOR, empty when unknown
+func (v KubeVersion) String() string {
+	return v.kubeVersion().String()
+}
+
+//Compare return -1, 0 or 1 when v is before, equal to or after o
+func (v KubeVersion) Compare(o KubeVersion) int {
+	return v.kubeVersion().Compare(o.kubeVersion())
+}
+
+//AtOrBefore check whether v is known and not after o
+func (v KubeVersion) AtOrBefore(o KubeVersion) bool {
+	return v.kubeVersion().AtOrBefore(o.kubeVersion())
+}
+
+func (v KubeVersion) kubeVersion() collector.KubeVersion {
+	return collector.KubeVersion{Major: v.Major, Minor: v.Minor}
+}
+
+func fromKubeVersion(v collector.KubeVersion) KubeVersion {
+	return KubeVersion{Major: v.Major, Minor: v.Minor}
+}
+
+//API deprecated or removed k8s api of a Catalog
+type API struct {
+	GVK GVK
+	// Introduced, Deprecated and Removed are the zero KubeVersion when no source reports them
+	Introduced KubeVersion
+	Deprecated KubeVersion
+	Removed    KubeVersion
+	// Replacement is the api to migrate to, zero when no source names one
+	Replacement GVK
+	// CustomResource is set for the versions of a CustomResourceDefinition, outdated at any k8s version
+	CustomResource bool
+	// Unserved is set for custom resource versions their CustomResourceDefinition no longer serves
+	Unserved    bool
+	Description string
+	// Sources are the collectors which reported the api, e.g. swagger and markdown
+	Sources []string
+	// MigrationURL is the deprecation guide section of the api, empty when the guide has none
+	MigrationURL string
+}
+
+//Catalog deprecated and removed k8s apis by GVK, safe for concurrent use
+type Catalog struct {
+	apis  []API
+	byGVK map[GVK]int
+}
+
+//NewCatalog build a Catalog from apis, e.g. the apis of a custom collector, the first api of a GVK wins
+func NewCatalog(apis []API) *Catalog {
+	sorted := append([]API{}, apis...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].GVK, sorted[j].GVK
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Kind < b.Kind
+	})
+	c := &Catalog{apis: make([]API, 0, len(sorted)), byGVK: make(map[GVK]int, len(sorted))}
+	for _, api := range sorted {
+		if _, ok := c.byGVK[api.GVK]; ok {
+			continue
+		}
+		api.Sources = append([]string{}, api.Sources...)
+		c.byGVK[api.GVK] = len(c.apis)
+		c.apis = append(c.apis, api)
+	}
+	return c
+}
+
+//APIs return every api of the catalog sorted by group, version and kind
+func (c Catalog) APIs() []API {
+	return append([]API{}, c.apis...)
+}
+
+//Lookup return the api of gvk, false when no source reports it deprecated or removed
+func (c Catalog) Lookup(gvk GVK) (API, bool) {
+	i, ok := c.byGVK[gvk]
+	if !ok {
+		return API{}, false
+	}
+	return c.apis[i], true
+}
+
+//IsRemoved check whether gvk is no longer served at version
+func (c Catalog) IsRemoved(gvk GVK, version KubeVersion) bool {
+	status, ok := c.status(gvk, version)
+	return ok && status == collector.StatusRemoved
+}
+
+//IsDeprecated check whether gvk is deprecated and still served at version
+func (c Catalog) IsDeprecated(gvk GVK, version KubeVersion) bool {
+	status, ok := c.status(gvk, version)
+	return ok && status == collector.StatusDeprecated
+}
+
+//ReplacementFor return the api to migrate gvk to, false when gvk is not in the catalog or no source names one
+func (c Catalog) ReplacementFor(gvk GVK) (GVK, bool) {
+	api, ok := c.Lookup(gvk)
+	if !ok || api.Replacement.IsZero() {
+		return GVK{}, false
+	}
+	return api.Replacement, true
+}
+
+//RemovedBetween return the apis an upgrade from one version to another removes, removed after from and at or before
+//to, sorted by removed version then by group, version and kind
+func (c Catalog) RemovedBetween(from KubeVersion, to KubeVersion) []API {
+	removed := make([]API, 0)
+	for _, api := range c.apis {
+		if api.Removed.Compare(from) > 0 && api.Removed.AtOrBefore(to) {
+			removed = append(removed, api)
+		}
+	}
+	sort.SliceStable(removed, func(i, j int) bool {
+		return removed[i].Removed.Compare(removed[j].Removed) < 0
+	})
+	return removed
+}
+
+func (c Catalog) status(gvk GVK, version KubeVersion) (collector.Status, bool) {
+	api, ok := c.Lookup(gvk)
+	if !ok {
+		return "", false
+	}
+	return toOutdatedAPI(api).StatusAt(version.kubeVersion())
+}
@@ -0,0 +1,151 @@
+package outdated
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var (
+	cronJob     = GVK{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+	flowSchema  = GVK{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema"}
+	ingress     = GVK{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}
+	certificate = GVK{Group: "cert-manager.io", Version: "v1alpha2", Kind: "Certificate"}
+)
+
+func version(s string) KubeVersion {
+	v, err := ParseKubeVersion(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func testCatalog() *Catalog {
+	return NewCatalog([]API{
+		{GVK: flowSchema, Removed: version("v1.26"), Sources: []string{"markdown"}},
+		{GVK: cronJob, Deprecated: version("v1.21"), Removed: version("v1.25"), Sources: []string{"swagger", "markdown"},
+			Replacement: GVK{Group: "batch", Version: "v1", Kind: "CronJob"}, MigrationURL: "https://kubernetes.io/docs/reference/using-api/deprecation-guide/#cronjob-v125"},
+		{GVK: cronJob, Removed: version("v1.30"), Sources: []string{"swagger"}},
+		{GVK: ingress, Deprecated: version("v1.14"), Removed: version("v1.22"), Sources: []string{"swagger"}},
+		{GVK: certificate, Unserved: true, Sources: []string{"crd"}},
+	})
+}
+
+func TestCatalogLookup(t *testing.T) {
+	c := testCatalog()
+	api, ok := c.Lookup(cronJob)
+	assert.True(t, ok)
+	assert.Equal(t, "v1.25", api.Removed.String())
+	assert.Equal(t, []string{"swagger", "markdown"}, api.Sources)
+	assert.Equal(t, "https://kubernetes.io/docs/reference/using-api/deprecation-guide/#cronjob-v125", api.MigrationURL)
+	_, ok = c.Lookup(GVK{Group: "apps", Version: "v1", Kind: "Deployment"})
+	assert.False(t, ok)
+
+	names := make([]string, 0)
+	for _, api := range c.APIs() {
+		names = append(names, api.GVK.String())
+	}
+	assert.Equal(t, []string{"batch/v1beta1 CronJob", "cert-manager.io/v1alpha2 Certificate", "extensions/v1beta1 Ingress",
+		"flowcontrol.apiserver.k8s.io/v1beta1 FlowSchema"}, names)
+}
+
+func TestCatalogStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		gvk            GVK
+		version        string
+		wantDeprecated bool
+		wantRemoved    bool
+	}{
+		{name: "before deprecation", gvk: cronJob, version: "v1.20"},
+		{name: "deprecated", gvk: cronJob, version: "v1.21.3", wantDeprecated: true},
+		{name: "removed", gvk: cronJob, version: "v1.25", wantRemoved: true},
+		{name: "minors compare as numbers", gvk: ingress, version: "v1.9"},
+		{name: "removed without deprecated version", gvk: flowSchema, version: "v1.27", wantRemoved: true},
+		{name: "unserved custom resource", gvk: certificate, version: "v1.20", wantRemoved: true},
+		{name: "unknown api", gvk: GVK{Version: "v1", Kind: "Pod"}, version: "v1.30"},
+	}
+	c := testCatalog()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := version(tt.version)
+			assert.Equal(t, tt.wantDeprecated, c.IsDeprecated(tt.gvk, v))
+			assert.Equal(t, tt.wantRemoved, c.IsRemoved(tt.gvk, v))
+		})
+	}
+}
+
+func TestCatalogReplacementFor(t *testing.T) {
+	c := testCatalog()
+	replacement, ok := c.ReplacementFor(cronJob)
+	assert.True(t, ok)
+	assert.Equal(t, GVK{Group: "batch", Version: "v1", Kind: "CronJob"}, replacement)
+	_, ok = c.ReplacementFor(ingress)
+	assert.False(t, ok)
+}
+
+func TestCatalogRemovedBetween(t *testing.T) {
+	c := testCatalog()
+	removed := c.RemovedBetween(version("v1.22"), version("v1.26"))
+	assert.Len(t, removed, 2)
+	assert.Equal(t, cronJob, removed[0].GVK)
+	assert.Equal(t, flowSchema, removed[1].GVK)
+	assert.Empty(t, c.RemovedBetween(version("v1.26"), version("v1.29")))
+}
+
+//staticCollector Collector of fixed apis
+type staticCollector struct {
+	name     string
+	priority int
+	apis     []API
+	err      error
+}
+
+func (s staticCollector) Name() string {
+	return s.name
+}
+
+func (s staticCollector) Priority() int {
+	return s.priority
+}
+
+func (s staticCollector) Collect(ctx context.Context, opts Options) ([]API, error) {
+	return s.apis, s.err
+}
+
+func TestCollect(t *testing.T) {
+	collectors := []Collector{
+		DeprecationGuide("../../collector/markdown/testdata/fixture/deprecation-guide.md"),
+		Swagger("../../collector/swagger/testdata/fixture/versions"),
+		CustomResources("../../collector/crd/testdata/fixture/crds"),
+	}
+	c, err := Collect(context.Background(), Options{K8sVersion: "v1.20.0"}, collectors...)
+	assert.NoError(t, err)
+	api, ok := c.Lookup(cronJob)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"swagger", "markdown"}, api.Sources)
+	assert.True(t, c.IsRemoved(cronJob, version("v1.25")))
+	assert.True(t, c.IsDeprecated(GVK{Group: "cert-manager.io", Version: "v1beta1", Kind: "Certificate"}, version("v1.20")))
+
+	// a custom collector of higher priority wins the values it disagrees on
+	custom := staticCollector{name: "custom", priority: PriorityPrereleaseLifecycle + 10, apis: []API{
+		{GVK: cronJob, Removed: version("v1.26"), Replacement: GVK{Group: "batch", Version: "v2", Kind: "CronJob"}},
+		{GVK: GVK{Group: "example.com", Version: "v1", Kind: "Widget"}, Deprecated: version("v1.22")},
+	}}
+	c, err = Collect(context.Background(), Options{K8sVersion: "v1.20.0"}, append(collectors, custom)...)
+	assert.NoError(t, err)
+	api, ok = c.Lookup(cronJob)
+	assert.True(t, ok)
+	assert.Equal(t, "v1.26", api.Removed.String())
+	assert.Equal(t, GVK{Group: "batch", Version: "v2", Kind: "CronJob"}, api.Replacement)
+	assert.Equal(t, []string{"swagger", "markdown", "custom"}, api.Sources)
+	widget, ok := c.Lookup(GVK{Group: "example.com", Version: "v1", Kind: "Widget"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"custom"}, widget.Sources)
+
+	failing := staticCollector{name: "failing", priority: PriorityDeprecationGuide, err: errors.New("unavailable")}
+	_, err = Collect(context.Background(), Options{K8sVersion: "v1.20.0"}, append(collectors, failing)...)
+	assert.EqualError(t, err, "unavailable")
+}
@@ -0,0 +1,153 @@
+package outdated
+
+import (
+	"context"
+	"k8s-outdated/collector"
+	"k8s-outdated/collector/crd"
+	"k8s-outdated/collector/lifecycle"
+	"k8s-outdated/collector/markdown"
+	"k8s-outdated/collector/swagger"
+	"strings"
+)
+
+//Priorities of the built-in collectors, a collector of higher priority wins every value the collectors disagree on
+const (
+	PriorityCustomResources     = collector.PriorityCRD
+	PrioritySwagger             = collector.PrioritySwagger
+	PriorityDeprecationGuide    = collector.PriorityMarkdown
+	PriorityPrereleaseLifecycle = collector.PriorityLifecycle
+)
+
+//Options of a Collect run shared by every collector, a collector ignores the options it has no use for
+type Options struct {
+	// K8sVersion is the k8s version the apis are collected from onward, e.g. v1.20.0
+	K8sVersion string
+}
+
+//Collector source of deprecated and removed apis merged into a Catalog
+type Collector interface {
+	// Name is the source of the collected apis, the Sources of the apis which have none
+	Name() string
+	// Priority rank the collector in the merge, see PrioritySwagger
+	Priority() int
+	// Collect return the apis of the source
+	Collect(ctx context.Context, opts Options) ([]API, error)
+}
+
+//Collect build a Catalog from collectors merged on their GVK: the collector of higher priority wins the values the
+//collectors disagree on, a collector is replaced by a later one of the same name
+func Collect(ctx context.Context, opts Options, collectors ...Collector) (*Catalog, error) {
+	registry := collector.NewRegistry()
+	for _, c := range collectors {
+		registry = registry.WithCollector(toCollector(c))
+	}
+	apis, err := registry.Collect(ctx, collector.Options{K8sVersion: opts.K8sVersion})
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]API, 0, len(apis))
+	for _, api := range apis {
+		converted = append(converted, fromOutdatedAPI(api))
+	}
+	return NewCatalog(converted), nil
+}
+
+//Swagger collector of the apis the swagger specs of the k8s releases mark deprecated, read from the k8s github
+//releases or from the spec files of dir when it is set
+func Swagger(dir string) Collector {
+	if len(dir) == 0 {
+		return builtin{swagger.NewOpenAPISpec()}
+	}
+	return builtin{swagger.NewLocalOpenAPISpec(dir)}
+}
+
+//DeprecationGuide collector of the apis of the k8s deprecation guide, read from the k8s website or from path when it
+//is set
+func DeprecationGuide(path string) Collector {
+	if len(path) == 0 {
+		return builtin{markdown.NewDeprecationGuide()}
+	}
+	return builtin{markdown.NewLocalDeprecationGuide(path)}
+}
+
+//PrereleaseLifecycle collector of the prerelease lifecycle comments of the k8s api types, read from the k8s github
+//repository or from the source tree at path when it is set
+func PrereleaseLifecycle(path string) Collector {
+	if len(path) == 0 {
+		return builtin{lifecycle.NewPrereleaseLifecycle()}
+	}
+	return builtin{lifecycle.NewLocalPrereleaseLifecycle(path)}
+}
+
+//CustomResources collector of the deprecated and unserved versions of the CustomResourceDefinitions of the manifest
+//files and directories of paths
+func CustomResources(paths ...string) Collector {
+	return builtin{crd.NewLocalCustomResources(paths...)}
+}
+
+//builtin Collector of a collector of the module, merged without conversion
+type builtin struct {
+	c collector.Collector
+}
+
+func (b builtin) Name() string {
+	return b.c.Name()
+}
+
+func (b builtin) Priority() int {
+	return b.c.Priority()
+}
+
+func (b builtin) Collect(ctx context.Context, opts Options) ([]API, error) {
+	apis, err := b.c.Collect(ctx, collector.Options{K8sVersion: opts.K8sVersion})
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]API, 0, len(apis))
+	for _, api := range apis {
+		converted = append(converted, fromOutdatedAPI(api))
+	}
+	return converted, nil
+}
+
+//toCollector adapt c to the collectors merged by collector.MergeByPriority
+func toCollector(c Collector) collector.Collector {
+	if b, ok := c.(builtin); ok {
+		return b.c
+	}
+	return collector.NewCollector(c.Name(), c.Priority(), func(ctx context.Context, opts collector.Options) ([]*collector.OutdatedAPI, error) {
+		apis, err := c.Collect(ctx, Options{K8sVersion: opts.K8sVersion})
+		if err != nil {
+			return nil, err
+		}
+		converted := make([]*collector.OutdatedAPI, 0, len(apis))
+		for _, api := range apis {
+			outdated := toOutdatedAPI(api)
+			converted = append(converted, &outdated)
+		}
+		return converted, nil
+	})
+}
+
+func fromOutdatedAPI(a *collector.OutdatedAPI) API {
+	api := API{GVK: fromGvk(a.Gav), Introduced: fromKubeVersion(a.Introduced), Deprecated: fromKubeVersion(a.Deprecated),
+		Removed: fromKubeVersion(a.Removed), Replacement: fromGvk(a.Replacement), CustomResource: a.CustomResource,
+		Unserved: a.Unserved, Description: a.Description, Sources: make([]string, 0)}
+	if len(a.Source) > 0 {
+		api.Sources = strings.Split(a.Source, ",")
+	}
+	if a.Migration != nil {
+		api.MigrationURL = a.Migration.URL
+	}
+	return api
+}
+
+func toOutdatedAPI(api API) collector.OutdatedAPI {
+	a := collector.OutdatedAPI{Gav: api.GVK.gvk(), Introduced: api.Introduced.kubeVersion(), Deprecated: api.Deprecated.kubeVersion(),
+		Removed: api.Removed.kubeVersion(), Replacement: api.Replacement.gvk(), CustomResource: api.CustomResource,
+		Unserved: api.Unserved, Description: api.Description, Source: strings.Join(api.Sources, ",")}
+	if len(api.MigrationURL) > 0 {
+		a.Migration = &collector.Migration{URL: api.MigrationURL}
+	}
+	return a
+}
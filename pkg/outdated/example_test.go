@@ -0,0 +1,40 @@
+package outdated_test
+
+import (
+	"context"
+	"fmt"
+	"k8s-outdated/pkg/outdated"
+)
+
+func ExampleCollect() {
+	guide := outdated.DeprecationGuide("../../collector/markdown/testdata/fixture/deprecation-guide.md")
+	catalog, err := outdated.Collect(context.Background(), outdated.Options{}, guide)
+	if err != nil {
+		panic(err)
+	}
+	gvk, _ := outdated.ParseGVK("batch/v1beta1", "CronJob")
+	target, _ := outdated.ParseKubeVersion("v1.25.3")
+	fmt.Println(catalog.IsRemoved(gvk, target))
+	if replacement, ok := catalog.ReplacementFor(gvk); ok {
+		fmt.Println(replacement)
+	}
+	// Output:
+	// true
+	// batch/v1 CronJob
+}
+
+func ExampleCatalog_RemovedBetween() {
+	catalog := outdated.NewCatalog([]outdated.API{
+		{GVK: outdated.GVK{Group: "batch", Version: "v1beta1", Kind: "CronJob"}, Removed: outdated.KubeVersion{Major: 1, Minor: 25}},
+		{GVK: outdated.GVK{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}, Removed: outdated.KubeVersion{Major: 1, Minor: 22}},
+		{GVK: outdated.GVK{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2", Kind: "FlowSchema"}, Removed: outdated.KubeVersion{Major: 1, Minor: 29}},
+	})
+	from, _ := outdated.ParseKubeVersion("v1.21")
+	to, _ := outdated.ParseKubeVersion("v1.26")
+	for _, api := range catalog.RemovedBetween(from, to) {
+		fmt.Println(api.Removed, api.GVK)
+	}
+	// Output:
+	// v1.22 extensions/v1beta1 Ingress
+	// v1.25 batch/v1beta1 CronJob
+}
@@ -0,0 +1,63 @@
+package plan
+
+import (
+	"fmt"
+	"k8s-outdated/collector"
+)
+
+//Hop api changes of an upgrade from one minor k8s release to the next
+type Hop struct {
+	From string
+	To   string
+	// Removed apis are no longer served from To onward
+	Removed []*collector.OutdatedAPI
+	// Deprecated apis are deprecated from To onward and still served
+	Deprecated []*collector.OutdatedAPI
+}
+
+//Hops return the hops of an upgrade through every minor release between from and to, without api changes
+func Hops(from string, to string) ([]Hop, error) {
+	fromRelease, err := collector.ParseKubeVersion(from)
+	if err != nil {
+		return nil, err
+	}
+	toRelease, err := collector.ParseKubeVersion(to)
+	if err != nil {
+		return nil, err
+	}
+	if toRelease.Compare(fromRelease) <= 0 {
+		return nil, fmt.Errorf("target version %s must be a later minor release than %s", to, from)
+	}
+	hops := make([]Hop, 0, toRelease.Minor-fromRelease.Minor)
+	for release := fromRelease; release.Compare(toRelease) < 0; release = release.NextMinor() {
+		hops = append(hops, Hop{
+			From:       release.String(),
+			To:         release.NextMinor().String(),
+			Removed:    make([]*collector.OutdatedAPI, 0),
+			Deprecated: make([]*collector.OutdatedAPI, 0),
+		})
+	}
+	return hops, nil
+}
+
+//NewPlan step through every minor release between from and to and report the apis removed and newly deprecated at each hop
+func NewPlan(apis []*collector.OutdatedAPI, from string, to string) ([]Hop, error) {
+	hops, err := Hops(from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hops {
+		hop := &hops[i]
+		release := collector.MustParseKubeVersion(hop.To)
+		for _, api := range apis {
+			if api.Removed == release {
+				hop.Removed = append(hop.Removed, api)
+			} else if api.Deprecated == release {
+				hop.Deprecated = append(hop.Deprecated, api)
+			}
+		}
+		collector.SortOutdatedAPIs(hop.Removed)
+		collector.SortOutdatedAPIs(hop.Deprecated)
+	}
+	return hops, nil
+}
@@ -0,0 +1,63 @@
+package plan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"testing"
+)
+
+var apis = []*collector.OutdatedAPI{
+	{Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Gav: collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"},
+		Replacement: collector.Gvk{Group: "batch", Version: "v1", Kind: "CronJob"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Gav: collector.Gvk{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.14"), Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.23"), Removed: collector.MustParseKubeVersion("v1.26"), Gav: collector.Gvk{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"}},
+	{Removed: collector.MustParseKubeVersion("v1.26"), Gav: collector.Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema"}},
+}
+
+func TestNewPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    map[string][2][]string
+		wantErr bool
+	}{
+		{name: "v1.21 to v1.26", from: "v1.21", to: "v1.26.1", want: map[string][2][]string{
+			"v1.21->v1.22": {{"extensions.v1beta1.Ingress"}, {}},
+			"v1.22->v1.23": {{}, {"autoscaling.v2beta2.HorizontalPodAutoscaler"}},
+			"v1.23->v1.24": {{}, {}},
+			"v1.24->v1.25": {{"batch.v1beta1.CronJob", "policy.v1beta1.PodDisruptionBudget"}, {}},
+			"v1.25->v1.26": {{"autoscaling.v2beta2.HorizontalPodAutoscaler", "flowcontrol.apiserver.k8s.io.v1beta1.FlowSchema"}, {}},
+		}},
+		{name: "single hop", from: "v1.20.4", to: "v1.21.0", want: map[string][2][]string{
+			"v1.20->v1.21": {{}, {"batch.v1beta1.CronJob", "policy.v1beta1.PodDisruptionBudget"}},
+		}},
+		{name: "same minor", from: "v1.21.0", to: "v1.21.5", wantErr: true},
+		{name: "downgrade", from: "v1.25", to: "v1.21", wantErr: true},
+		{name: "invalid version", from: "latest", to: "v1.21", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hops, err := NewPlan(apis, tt.from, tt.to)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			got := make(map[string][2][]string)
+			for _, h := range hops {
+				got[h.From+"->"+h.To] = [2][]string{names(h.Removed), names(h.Deprecated)}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func names(apis []*collector.OutdatedAPI) []string {
+	n := make([]string, 0, len(apis))
+	for _, a := range apis {
+		n = append(n, a.Gav.Group+"."+a.Gav.Version+"."+a.Gav.Kind)
+	}
+	return n
+}
@@ -0,0 +1,199 @@
+//Package query filter and sort the merged outdated apis by lifecycle status at a target version, group, kind and source
+package query
+
+import (
+	"fmt"
+	"k8s-outdated/collector"
+	"path"
+	"sort"
+	"strings"
+)
+
+//Status lifecycle status an api is selected by at the target version
+type Status string
+
+//Supported statuses
+const (
+	// Deprecated apis are deprecated and still served at the target version
+	Deprecated Status = "deprecated"
+	// Removed apis are no longer served at the target version
+	Removed Status = "removed"
+	// RemovedSoon apis are deprecated at the target version and removed within the next Within minor releases
+	RemovedSoon Status = "removed-soon"
+)
+
+//SortKey order of the selected apis
+type SortKey string
+
+//Supported sort keys
+const (
+	// ByGroup sort by group, version and kind, the order of the merged apis
+	ByGroup SortKey = "group"
+	// ByRemoved sort by removed version, the apis without one last, then by group, version and kind
+	ByRemoved SortKey = "removed"
+)
+
+//CoreGroup name of the core group, whose apis have an empty group
+const CoreGroup = "core"
+
+//DefaultWithin number of minor releases after the target RemovedSoon looks ahead
+const DefaultWithin = 1
+
+//Query select outdated apis, every filter left empty selects all apis
+type Query struct {
+	// Target is the k8s version the statuses are evaluated at, required by Statuses
+	Target collector.KubeVersion
+	// Statuses select the apis with any of the lifecycle statuses at Target
+	Statuses []Status
+	// Within is the number of minor releases after Target a RemovedSoon api is removed in
+	Within int
+	// Groups select the apis of any of the groups, core is the core group
+	Groups []string
+	// Kinds select the apis whose kind matches any of the globs, e.g. *Ingress*
+	Kinds []string
+	// Sources select the apis any of the sources reported, e.g. markdown
+	Sources []string
+	// SortBy is the order of the result, ByGroup when empty
+	SortBy SortKey
+}
+
+//Statuses return the supported status names
+func Statuses() []string {
+	return []string{string(Deprecated), string(Removed), string(RemovedSoon)}
+}
+
+//ParseStatus parse status name
+func ParseStatus(name string) (Status, error) {
+	for _, s := range Statuses() {
+		if strings.EqualFold(s, name) {
+			return Status(s), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported status %q, supported statuses: %s", name, strings.Join(Statuses(), ", "))
+}
+
+//SortKeys return the supported sort key names
+func SortKeys() []string {
+	return []string{string(ByGroup), string(ByRemoved)}
+}
+
+//ParseSortKey parse sort key name
+func ParseSortKey(name string) (SortKey, error) {
+	for _, k := range SortKeys() {
+		if strings.EqualFold(k, name) {
+			return SortKey(k), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported sort key %q, supported keys: %s", name, strings.Join(SortKeys(), ", "))
+}
+
+//Validate check the query can be applied
+func (q Query) Validate() error {
+	if len(q.Statuses) > 0 && q.Target.IsZero() {
+		return fmt.Errorf("a target version is required to select apis by status")
+	}
+	if q.Within < 0 {
+		return fmt.Errorf("within must not be negative")
+	}
+	for _, kind := range q.Kinds {
+		if _, err := path.Match(kind, ""); err != nil {
+			return fmt.Errorf("invalid kind pattern %q: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+//Apply return the apis the query selects in the query order, apis itself is not modified
+func (q Query) Apply(apis []*collector.OutdatedAPI) ([]*collector.OutdatedAPI, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	selected := make([]*collector.OutdatedAPI, 0, len(apis))
+	for _, api := range apis {
+		if q.Matches(api) {
+			selected = append(selected, api)
+		}
+	}
+	collector.SortOutdatedAPIs(selected)
+	if q.SortBy == ByRemoved {
+		sort.SliceStable(selected, func(i, j int) bool {
+			a, b := selected[i].Removed, selected[j].Removed
+			if a.IsZero() || b.IsZero() {
+				return !a.IsZero() && b.IsZero()
+			}
+			return a.Compare(b) < 0
+		})
+	}
+	return selected, nil
+}
+
+//Matches check whether every filter of the query selects api
+func (q Query) Matches(api *collector.OutdatedAPI) bool {
+	return q.matchesStatus(api) && q.matchesGroup(api.Gav.Group) && q.matchesKind(api.Gav.Kind) && q.matchesSource(api)
+}
+
+func (q Query) matchesStatus(api *collector.OutdatedAPI) bool {
+	if len(q.Statuses) == 0 {
+		return true
+	}
+	status, ok := api.StatusAt(q.Target)
+	if !ok {
+		return false
+	}
+	for _, s := range q.Statuses {
+		switch s {
+		case Deprecated, Removed:
+			if string(s) == string(status) {
+				return true
+			}
+		case RemovedSoon:
+			horizon := q.Target
+			for i := 0; i < q.Within; i++ {
+				horizon = horizon.NextMinor()
+			}
+			if status == collector.StatusDeprecated && api.Removed.AtOrBefore(horizon) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (q Query) matchesGroup(group string) bool {
+	if len(q.Groups) == 0 {
+		return true
+	}
+	for _, g := range q.Groups {
+		if g == group || (g == CoreGroup && len(group) == 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q Query) matchesKind(kind string) bool {
+	if len(q.Kinds) == 0 {
+		return true
+	}
+	for _, pattern := range q.Kinds {
+		if ok, _ := path.Match(pattern, kind); ok {
+			return true
+		}
+	}
+	return false
+}
+
+//matchesSource check whether any of the sources reported api, merged apis list their sources comma separated
+func (q Query) matchesSource(api *collector.OutdatedAPI) bool {
+	if len(q.Sources) == 0 {
+		return true
+	}
+	for _, reported := range strings.Split(api.Source, ",") {
+		for _, s := range q.Sources {
+			if strings.EqualFold(s, reported) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package query
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"testing"
+)
+
+var apis = []*collector.OutdatedAPI{
+	{Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Source: "swagger,markdown",
+		Gav: collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.14"), Removed: collector.MustParseKubeVersion("v1.22"), Source: "swagger,markdown,lifecycle",
+		Gav: collector.Gvk{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.19"), Removed: collector.MustParseKubeVersion("v1.22"), Source: "swagger",
+		Gav: collector.Gvk{Group: "networking.k8s.io", Version: "v1beta1", Kind: "IngressClass"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.23"), Removed: collector.MustParseKubeVersion("v1.26"), Source: "swagger",
+		Gav: collector.Gvk{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.29"), Source: "lifecycle", Gav: collector.Gvk{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3", Kind: "FlowSchema"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.9"), Removed: collector.MustParseKubeVersion("v1.16"), Source: "markdown",
+		Gav: collector.Gvk{Version: "v1beta1", Kind: "ComponentStatus"}},
+	{Source: collector.SourceCRD, Gav: collector.Gvk{Group: "cert-manager.io", Version: "v1beta1", Kind: "Certificate"}, CustomResource: true},
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   Query
+		want    []string
+		wantErr bool
+	}{
+		{name: "no filters", query: Query{}, want: []string{"v1beta1 ComponentStatus", "autoscaling/v2beta2 HorizontalPodAutoscaler",
+			"batch/v1beta1 CronJob", "cert-manager.io/v1beta1 Certificate", "extensions/v1beta1 Ingress",
+			"flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema", "networking.k8s.io/v1beta1 IngressClass"}},
+		{name: "removed at target", query: Query{Target: collector.MustParseKubeVersion("v1.22"), Statuses: []Status{Removed}},
+			want: []string{"v1beta1 ComponentStatus", "extensions/v1beta1 Ingress", "networking.k8s.io/v1beta1 IngressClass"}},
+		{name: "deprecated at target", query: Query{Target: collector.MustParseKubeVersion("v1.23"), Statuses: []Status{Deprecated}},
+			want: []string{"autoscaling/v2beta2 HorizontalPodAutoscaler", "batch/v1beta1 CronJob", "cert-manager.io/v1beta1 Certificate"}},
+		{name: "removed soon", query: Query{Target: collector.MustParseKubeVersion("v1.24"), Statuses: []Status{RemovedSoon}, Within: 1},
+			want: []string{"batch/v1beta1 CronJob"}},
+		{name: "removed soon within two minors", query: Query{Target: collector.MustParseKubeVersion("v1.24"), Statuses: []Status{RemovedSoon}, Within: 2},
+			want: []string{"autoscaling/v2beta2 HorizontalPodAutoscaler", "batch/v1beta1 CronJob"}},
+		{name: "any of the statuses", query: Query{Target: collector.MustParseKubeVersion("v1.25"), Statuses: []Status{Removed, RemovedSoon}, Within: 1, Groups: []string{"batch", "autoscaling"}},
+			want: []string{"autoscaling/v2beta2 HorizontalPodAutoscaler", "batch/v1beta1 CronJob"}},
+		{name: "core group", query: Query{Groups: []string{CoreGroup}}, want: []string{"v1beta1 ComponentStatus"}},
+		{name: "kind glob", query: Query{Kinds: []string{"Ingress*"}}, want: []string{"extensions/v1beta1 Ingress", "networking.k8s.io/v1beta1 IngressClass"}},
+		{name: "merged source", query: Query{Sources: []string{"lifecycle"}},
+			want: []string{"extensions/v1beta1 Ingress", "flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema"}},
+		{name: "sort by removed version", query: Query{Kinds: []string{"*o*"}, SortBy: ByRemoved},
+			want: []string{"v1beta1 ComponentStatus", "batch/v1beta1 CronJob", "autoscaling/v2beta2 HorizontalPodAutoscaler", "flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema"}},
+		{name: "status without target", query: Query{Statuses: []Status{Removed}}, wantErr: true},
+		{name: "invalid kind pattern", query: Query{Kinds: []string{"[Ingress"}}, wantErr: true},
+		{name: "negative within", query: Query{Within: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.Apply(apis)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			names := make([]string, 0, len(got))
+			for _, api := range got {
+				names = append(names, api.Gav.String())
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+	// the apis are not reordered in place
+	assert.Equal(t, "batch/v1beta1 CronJob", apis[0].Gav.String())
+}
+
+func TestParseStatus(t *testing.T) {
+	status, err := ParseStatus("Removed-Soon")
+	assert.NoError(t, err)
+	assert.Equal(t, RemovedSoon, status)
+	_, err = ParseStatus("gone")
+	assert.Error(t, err)
+}
+
+func TestParseSortKey(t *testing.T) {
+	key, err := ParseSortKey("removed")
+	assert.NoError(t, err)
+	assert.Equal(t, ByRemoved, key)
+	_, err = ParseSortKey("kind")
+	assert.Error(t, err)
+}
@@ -0,0 +1,197 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"gopkg.in/yaml.v3"
+	"k8s-outdated/kube"
+	"sort"
+	"strings"
+)
+
+//LastAppliedAnnotation annotation kubectl apply stores the applied manifest in
+const LastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+//ClusterClient api server discovery and list operations used by the cluster scan
+type ClusterClient interface {
+	ServerVersion() (*kube.VersionInfo, error)
+	ServerGroup(group string) (*kube.APIGroup, bool, error)
+	ServerResources(group string, version string) (*kube.APIResourceList, bool, error)
+	List(group string, version string, resource string) ([]map[string]interface{}, error)
+	ListSelected(group string, version string, resource string, labelSelector string) ([]map[string]interface{}, error)
+}
+
+//groupKind api group and kind shared by all versions of a resource
+type groupKind struct {
+	group string
+	kind  string
+}
+
+//ClusterVersion return the api server version without build metadata, e.g. v1.25.3
+func ClusterVersion(c ClusterClient) (string, error) {
+	info, err := c.ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	v, err := version.NewVersion(info.GitVersion)
+	if err != nil {
+		return "", fmt.Errorf("invalid server version %q: %w", info.GitVersion, err)
+	}
+	return "v" + v.Core().String(), nil
+}
+
+//ScanCluster list the objects of every outdated group/kind served by the cluster and report the ones
+//whose last-applied-configuration annotation uses an api, sets a field or carries an annotation or label deprecated or
+//removed at the target version. Objects without the annotation, e.g. created by helm, kubectl create or a controller,
+//are checked as listed, under the version they were listed with
+func (s Scanner) ScanCluster(c ClusterClient) ([]Finding, error) {
+	versions := make(map[groupKind][]string)
+	for gvk := range s.apis {
+		gk := groupKind{group: gvk.Group, kind: gvk.Kind}
+		versions[gk] = append(versions[gk], gvk.Version)
+	}
+	for gvk := range s.fields {
+		gk := groupKind{group: gvk.Group, kind: gvk.Kind}
+		if _, ok := s.apis[gvk]; !ok {
+			versions[gk] = append(versions[gk], gvk.Version)
+		}
+	}
+	kinds := make([]groupKind, 0, len(versions))
+	for gk := range versions {
+		kinds = append(kinds, gk)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].group != kinds[j].group {
+			return kinds[i].group < kinds[j].group
+		}
+		return kinds[i].kind < kinds[j].kind
+	})
+	findings := make([]Finding, 0)
+	// the same object is listed once per served group of a kind, e.g. extensions and networking.k8s.io ingresses
+	seen := make(map[string]bool)
+	for _, gk := range kinds {
+		objects, err := listServedObjects(c, gk, versions[gk])
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range s.Match(objects) {
+			key := strings.Join([]string{f.APIVersion, f.Kind, f.Namespace, f.Name}, "/")
+			if len(f.Path) > 0 {
+				key += "#" + f.Path
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+//listServedObjects list the objects of group/kind through the first served version which has the kind
+func listServedObjects(c ClusterClient, gk groupKind, outdatedVersions []string) ([]Object, error) {
+	group, ok, err := c.ServerGroup(gk.group)
+	if err != nil || !ok {
+		return nil, err
+	}
+	served := make(map[string]bool)
+	for _, v := range group.Versions {
+		served[v.Version] = true
+	}
+	sort.Strings(outdatedVersions)
+	candidates := append([]string{group.PreferredVersion.Version}, outdatedVersions...)
+	for _, v := range candidates {
+		if !served[v] {
+			continue
+		}
+		resources, ok, err := c.ServerResources(gk.group, v)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		resource, ok := listableResource(resources, gk.kind)
+		if !ok {
+			continue
+		}
+		items, err := c.List(gk.group, v, resource.Name)
+		if err != nil {
+			return nil, err
+		}
+		return clusterObjects(items, apiVersion(gk.group, v), gk.kind, objectPath(gk.group, v, resource)), nil
+	}
+	return nil, nil
+}
+
+func listableResource(resources *kube.APIResourceList, kind string) (kube.APIResource, bool) {
+	for _, r := range resources.Resources {
+		if r.Kind != kind || strings.Contains(r.Name, "/") {
+			continue
+		}
+		for _, verb := range r.Verbs {
+			if verb == "list" {
+				return r, true
+			}
+		}
+	}
+	return kube.APIResource{}, false
+}
+
+//clusterObjects return objects with the apiVersion and kind they were last applied with, the objects never applied
+//with kubectl apply have the apiVersion they were listed with
+func clusterObjects(items []map[string]interface{}, listedVersion string, kind string, path func(namespace, name string) string) []Object {
+	objects := make([]Object, 0)
+	for _, item := range items {
+		metadata, _ := item["metadata"].(map[string]interface{})
+		annotations, _ := metadata["annotations"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		namespace, _ := metadata["namespace"].(string)
+		obj := Object{APIVersion: listedVersion, Kind: kind, Name: name, Namespace: namespace, File: path(namespace, name)}
+		manifest, err := json.Marshal(item)
+		if err != nil {
+			continue
+		}
+		if applied, ok := annotations[LastAppliedAnnotation].(string); ok {
+			var lastApplied struct {
+				APIVersion string `json:"apiVersion"`
+				Kind       string `json:"kind"`
+			}
+			if err := json.Unmarshal([]byte(applied), &lastApplied); err == nil {
+				obj.APIVersion, obj.Kind = lastApplied.APIVersion, lastApplied.Kind
+				manifest = []byte(applied)
+			}
+		}
+		// json is yaml, the parsed node lets the scanner look for deprecated fields
+		var node yaml.Node
+		if err := yaml.Unmarshal(manifest, &node); err == nil && len(node.Content) > 0 {
+			obj.Node = node.Content[0]
+		}
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+//apiVersion return the manifest apiVersion of a group version, the version alone for the core group
+func apiVersion(group string, version string) string {
+	if len(group) == 0 {
+		return version
+	}
+	return group + "/" + version
+}
+
+//objectPath build the api server path of an object, used as the finding location
+func objectPath(group string, version string, resource kube.APIResource) func(namespace, name string) string {
+	prefix := "/apis/" + group + "/" + version
+	if len(group) == 0 {
+		prefix = "/api/" + version
+	}
+	return func(namespace, name string) string {
+		if resource.Namespaced && len(namespace) > 0 {
+			return fmt.Sprintf("%s/namespaces/%s/%s/%s", prefix, namespace, resource.Name, name)
+		}
+		return fmt.Sprintf("%s/%s/%s", prefix, resource.Name, name)
+	}
+}
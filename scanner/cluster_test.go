@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"k8s-outdated/kube"
+	"k8s-outdated/kube/kubetest"
+	"path/filepath"
+	"testing"
+)
+
+//fakeCluster start a fake api server serving cron jobs and ingresses, and the extra resources
+func fakeCluster(t *testing.T, extra ...kubetest.Resource) (*kubetest.Server, *kube.Client) {
+	cronJobs := []map[string]interface{}{
+		kubetest.Object("legacy", "batch", `{"apiVersion":"batch/v1beta1","kind":"CronJob","metadata":{"name":"legacy"}}`),
+		kubetest.Object("current", "batch", `{"apiVersion":"batch/v1","kind":"CronJob","metadata":{"name":"current"}}`),
+		kubetest.Object("created", "batch", ""),
+	}
+	ingresses := []map[string]interface{}{
+		kubetest.Object("web", "default", `{"apiVersion":"extensions/v1beta1","kind":"Ingress","metadata":{"name":"web"}}`),
+	}
+	server := kubetest.NewServer("v1.22.4", "", append([]kubetest.Resource{
+		{Group: "batch", Version: "v1", Kind: "CronJob", Name: "cronjobs", Namespaced: true, Items: cronJobs},
+		{Group: "batch", Version: "v1beta1", Kind: "CronJob", Name: "cronjobs", Namespaced: true, Items: cronJobs},
+		{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress", Name: "ingresses", Namespaced: true, Items: ingresses},
+		{Group: "extensions", Version: "v1beta1", Kind: "Ingress", Name: "ingresses", Namespaced: true, Items: ingresses},
+	}, extra...))
+	path := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, server.WriteKubeconfig(path))
+	cfg, err := kube.LoadConfig(path, "")
+	assert.NoError(t, err)
+	client, err := kube.NewClient(cfg)
+	assert.NoError(t, err)
+	return server, client
+}
+
+func TestScanCluster(t *testing.T) {
+	server, client := fakeCluster(t)
+	defer server.Close()
+	target, err := ClusterVersion(client)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.22.4", target)
+	apis := append(apis, &collector.OutdatedAPI{Deprecated: collector.MustParseKubeVersion("v1.19"), Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}})
+	s, err := NewScanner(apis, target)
+	assert.NoError(t, err)
+	findings, err := s.ScanCluster(client)
+	assert.NoError(t, err)
+	got := make([]wantFinding, 0)
+	for _, f := range findings {
+		got = append(got, wantFinding{file: f.File, kind: f.APIVersion + "/" + f.Kind, name: f.Name, namespace: f.Namespace, status: f.Status})
+	}
+	assert.Equal(t, []wantFinding{
+		{file: "/apis/batch/v1/namespaces/batch/cronjobs/legacy", kind: "batch/v1beta1/CronJob", name: "legacy", namespace: "batch", status: StatusDeprecated},
+		{file: "/apis/extensions/v1beta1/namespaces/default/ingresses/web", kind: "extensions/v1beta1/Ingress", name: "web", namespace: "default", status: StatusRemoved},
+	}, got)
+}
+
+func TestScanClusterWithoutLastApplied(t *testing.T) {
+	// a pod disruption budget created by helm or kubectl create, only served by its outdated version
+	budgets := []map[string]interface{}{kubetest.Object("created", "default", "")}
+	server, client := fakeCluster(t, kubetest.Resource{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget",
+		Name: "poddisruptionbudgets", Namespaced: true, Items: budgets})
+	defer server.Close()
+	s, err := NewScanner(apis, "v1.22.4")
+	assert.NoError(t, err)
+	findings, err := s.ScanCluster(client)
+	assert.NoError(t, err)
+	got := make([]wantFinding, 0)
+	for _, f := range findings {
+		got = append(got, wantFinding{file: f.File, kind: f.APIVersion + "/" + f.Kind, name: f.Name, namespace: f.Namespace, status: f.Status})
+	}
+	assert.Contains(t, got, wantFinding{file: "/apis/policy/v1beta1/namespaces/default/poddisruptionbudgets/created",
+		kind: "policy/v1beta1/PodDisruptionBudget", name: "created", namespace: "default", status: StatusDeprecated})
+	// the cron job created without kubectl apply is listed through the served batch/v1, which is not outdated
+	assert.NotContains(t, got, wantFinding{file: "/apis/batch/v1/namespaces/batch/cronjobs/created",
+		kind: "batch/v1/CronJob", name: "created", namespace: "batch", status: StatusDeprecated})
+	assert.Equal(t, 3, len(got))
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"gopkg.in/yaml.v3"
+	"k8s-outdated/collector"
+	"strings"
+)
+
+//WithDeprecatedFields return a copy of the scanner also reporting objects which set fields deprecated or removed at the target version
+func (s Scanner) WithDeprecatedFields(fields []*collector.DeprecatedField) *Scanner {
+	byGvk := make(map[collector.Gvk][]*collector.DeprecatedField)
+	for _, f := range fields {
+		byGvk[f.Gav] = append(byGvk[f.Gav], f)
+	}
+	s.fields = byGvk
+	return &s
+}
+
+//matchFields return a finding for every deprecated field of the object kind set in the object,
+//objects without a parsed node, e.g. rendered helm templates, are skipped
+func (s Scanner) matchFields(obj Object, gvk collector.Gvk) []Finding {
+	findings := make([]Finding, 0)
+	if obj.Node == nil {
+		return findings
+	}
+	for _, field := range s.fields[gvk] {
+		status, ok := s.lifecycleStatus(field.Deprecated, field.Removed)
+		if !ok {
+			continue
+		}
+		key := fieldNode(obj.Node, strings.Split(field.Path, "."))
+		if key == nil {
+			continue
+		}
+		found := obj
+		// cluster objects have no file line, the node is parsed from their last-applied-configuration
+		if found.Line > 0 {
+			found.Line = key.Line
+		}
+		findings = append(findings, Finding{Object: found, Field: field, Path: field.Path, Status: status})
+	}
+	return findings
+}
+
+//lifecycleStatus return the status of a field, annotation or label at the target version, deprecated without any version
+func (s Scanner) lifecycleStatus(deprecated collector.KubeVersion, removed collector.KubeVersion) (Status, bool) {
+	if deprecated.IsZero() && removed.IsZero() {
+		return StatusDeprecated, true
+	}
+	return s.StatusOf(&collector.OutdatedAPI{Deprecated: deprecated, Removed: removed})
+}
+
+//fieldNode return the key node of the first field set along path, name[] steps into every item of a sequence and *
+//into every value of a mapping
+func fieldNode(node *yaml.Node, path []string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode || len(path) == 0 {
+		return nil
+	}
+	name := path[0]
+	if name == "*" {
+		for i := 1; i < len(node.Content); i += 2 {
+			if found := fieldNode(node.Content[i], path[1:]); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	items := strings.HasSuffix(name, "[]")
+	name = strings.TrimSuffix(name, "[]")
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != name {
+			continue
+		}
+		value := node.Content[i+1]
+		if len(path) == 1 {
+			return node.Content[i]
+		}
+		if !items {
+			return fieldNode(value, path[1:])
+		}
+		if value.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for _, item := range value.Content {
+			if found := fieldNode(item, path[1:]); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"k8s-outdated/collector"
+	"k8s-outdated/kube/kubetest"
+	"strings"
+	"testing"
+)
+
+var (
+	pod        = collector.Gvk{Version: "v1", Kind: "Pod"}
+	deployment = collector.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}
+)
+
+var deprecatedFields = []*collector.DeprecatedField{
+	{Gav: pod, Path: "spec.serviceAccount", Replacement: "serviceAccountName"},
+	{Gav: pod, Path: "spec.volumes[].gitRepo", Deprecated: collector.MustParseKubeVersion("v1.11")},
+	{Gav: pod, Path: "spec.hostAliases", Deprecated: collector.MustParseKubeVersion("v1.25"), Removed: collector.MustParseKubeVersion("v1.28")},
+	{Gav: deployment, Path: "spec.template.spec.serviceAccount", Replacement: "serviceAccountName"},
+	{Gav: deployment, Path: "spec.template.spec.hostAliases", Deprecated: collector.MustParseKubeVersion("v1.25"), Removed: collector.MustParseKubeVersion("v1.28")},
+}
+
+type wantFieldFinding struct {
+	line   int
+	kind   string
+	field  string
+	status Status
+}
+
+func TestScanDeprecatedFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   []wantFieldFinding
+	}{
+		{name: "before the hostAliases deprecation", target: "v1.24.0", want: []wantFieldFinding{
+			{line: 6, kind: "Pod", field: "spec.serviceAccount", status: StatusDeprecated},
+			{line: 11, kind: "Pod", field: "spec.volumes[].gitRepo", status: StatusDeprecated},
+		}},
+		{name: "hostAliases removed", target: "v1.28.0", want: []wantFieldFinding{
+			{line: 6, kind: "Pod", field: "spec.serviceAccount", status: StatusDeprecated},
+			{line: 11, kind: "Pod", field: "spec.volumes[].gitRepo", status: StatusDeprecated},
+			{line: 23, kind: "Deployment", field: "spec.template.spec.hostAliases", status: StatusRemoved},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScanner(apis, tt.target)
+			assert.NoError(t, err)
+			findings, err := s.WithDeprecatedFields(deprecatedFields).ScanPaths([]string{"./testdata/fixture/fields"})
+			assert.NoError(t, err)
+			got := make([]wantFieldFinding, 0)
+			for _, f := range findings {
+				assert.Nil(t, f.API)
+				got = append(got, wantFieldFinding{line: f.Line, kind: f.Kind, field: f.Field.Path, status: f.Status})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFieldNode(t *testing.T) {
+	manifest := "spec:\n  containers:\n  - name: a\n  - name: b\n    deprecated: true\n  selector:\n    a:\n      old: 1\n"
+	var doc yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(manifest), &doc))
+	tests := []struct {
+		path     string
+		wantLine int
+	}{
+		{path: "spec.containers[].deprecated", wantLine: 5},
+		{path: "spec.selector.*.old", wantLine: 8},
+		{path: "spec.containers.deprecated"},
+		{path: "spec.missing"},
+		{path: "spec.selector[].old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			node := fieldNode(doc.Content[0], strings.Split(tt.path, "."))
+			if tt.wantLine == 0 {
+				assert.Nil(t, node)
+				return
+			}
+			assert.Equal(t, tt.wantLine, node.Line)
+		})
+	}
+}
+
+func TestScanClusterDeprecatedFields(t *testing.T) {
+	pods := []map[string]interface{}{
+		kubetest.Object("builder", "ci", `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"builder"},"spec":{"serviceAccount":"ci"}}`),
+		kubetest.Object("web", "ci", `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"web"},"spec":{"serviceAccountName":"ci"}}`),
+	}
+	server, client := fakeCluster(t, kubetest.Resource{Version: "v1", Kind: "Pod", Name: "pods", Namespaced: true, Items: pods})
+	defer server.Close()
+	s, err := NewScanner(nil, "v1.22.0")
+	assert.NoError(t, err)
+	findings, err := s.WithDeprecatedFields(deprecatedFields[:1]).ScanCluster(client)
+	assert.NoError(t, err)
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "/api/v1/namespaces/ci/pods/builder", findings[0].File)
+	assert.Equal(t, 0, findings[0].Line)
+	assert.Equal(t, "spec.serviceAccount", findings[0].Field.Path)
+}
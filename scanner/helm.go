@@ -0,0 +1,262 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"k8s-outdated/helm"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const templateAction = "{{"
+
+//HelmSource helm chart or release an object was found in
+type HelmSource struct {
+	// Chart is the chart name and version, e.g. nginx-1.2.3
+	Chart    string
+	Release  string
+	Revision int
+	// Template is the chart template file of the object
+	Template string
+}
+
+//ScanChart scan a chart directory or packaged chart, including its sub charts.
+//Templates are not rendered, every literal apiVersion and kind pair of a template document is checked except the
+//fallbacks of .Capabilities conditions
+func (s Scanner) ScanChart(p string) ([]Finding, error) {
+	chart, err := helm.LoadChart(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Match(ChartObjects(chart)), nil
+}
+
+//ScanReleaseFile scan the releases stored in a helm release secret or config map manifest, or a raw release payload
+func (s Scanner) ScanReleaseFile(p string) ([]Finding, error) {
+	data, err := os.ReadFile(filepath.Clean(p))
+	if err != nil {
+		return nil, err
+	}
+	releases, err := helm.DecodeReleaseFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return s.scanReleases(releases)
+}
+
+//ScanClusterReleases scan the deployed releases stored as secrets or config maps by the helm 3 storage driver
+func (s Scanner) ScanClusterReleases(c ClusterClient, driver helm.StorageDriver) ([]Finding, error) {
+	resource := "secrets"
+	if driver == helm.DriverConfigMap {
+		resource = "configmaps"
+	}
+	stored, err := c.ListSelected("", "v1", resource, helm.ReleaseSelector)
+	if err != nil {
+		return nil, err
+	}
+	releases := make([]*helm.Release, 0)
+	for _, obj := range stored {
+		// config maps have no type, the release label selector alone picks them
+		if secretType, _ := obj["type"].(string); driver == helm.DriverSecret && secretType != helm.ReleaseSecretType {
+			continue
+		}
+		data, _ := obj["data"].(map[string]interface{})
+		payload, _ := data["release"].(string)
+		if len(payload) == 0 {
+			continue
+		}
+		rel, err := helm.DecodeRelease([]byte(payload))
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, rel)
+	}
+	return s.scanReleases(releases)
+}
+
+func (s Scanner) scanReleases(releases []*helm.Release) ([]Finding, error) {
+	findings := make([]Finding, 0)
+	for _, rel := range releases {
+		objects, err := ReleaseObjects(rel)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, s.Match(objects)...)
+	}
+	return findings, nil
+}
+
+//ChartObjects extract objects from the templates of chart and its sub charts
+func ChartObjects(chart *helm.Chart) []Object {
+	objects := make([]Object, 0)
+	source := chart.Name + "-" + chart.Version
+	for _, t := range chart.Templates {
+		for _, obj := range templateObjects(t.Data) {
+			obj.File = path.Join(filepath.ToSlash(chart.Path), t.Name)
+			obj.Helm = &HelmSource{Chart: source, Template: t.Name}
+			objects = append(objects, obj)
+		}
+	}
+	for _, dep := range chart.Dependencies {
+		objects = append(objects, ChartObjects(dep)...)
+	}
+	return objects
+}
+
+//ReleaseObjects decode the objects of a release manifest
+func ReleaseObjects(rel *helm.Release) ([]Object, error) {
+	objects := make([]Object, 0)
+	location := fmt.Sprintf("%s/%s.v%d", rel.Namespace, rel.Name, rel.Revision)
+	for _, m := range rel.Manifests() {
+		manifestObjects, err := DecodeObjects(bytes.NewReader(m.Data), location)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range manifestObjects {
+			obj.Line += m.Line
+			if len(obj.Namespace) == 0 {
+				obj.Namespace = rel.Namespace
+			}
+			obj.File = location + ":" + m.Template
+			obj.Helm = &HelmSource{Chart: rel.ChartName(), Release: rel.Name, Revision: rel.Revision, Template: m.Template}
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+//templateObjects find the literal top level apiVersion and kind of every document in an unrendered template.
+//A document may hold several apiVersions selected by template conditions, each of them is returned except the ones of
+//the else branches of a .Capabilities condition, the fallbacks rendered only when the cluster lacks the guarded api
+func templateObjects(data []byte) []Object {
+	objects := make([]Object, 0)
+	var doc Object
+	var apiVersions []Object
+	var blocks templateBlocks
+	inMetadata, started := false, false
+	flush := func() {
+		if !started {
+			return
+		}
+		for _, v := range apiVersions {
+			if len(doc.Kind) == 0 {
+				continue
+			}
+			objects = append(objects, Object{APIVersion: v.APIVersion, Kind: doc.Kind, Name: doc.Name, Namespace: doc.Namespace, Line: v.Line, Document: doc.Document})
+		}
+		doc = Object{Document: doc.Document + 1}
+		apiVersions = nil
+		inMetadata, started = false, false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "---") {
+			flush()
+			continue
+		}
+		if trimmed := strings.TrimSpace(text); len(trimmed) > 0 && !strings.HasPrefix(trimmed, "#") {
+			started = true
+		}
+		if !strings.HasPrefix(text, " ") && !strings.HasPrefix(text, "\t") {
+			inMetadata = strings.HasPrefix(text, "metadata:")
+		}
+		fallback := blocks.fallback()
+		blocks = blocks.next(text)
+		if fallback && blocks.fallback() {
+			continue
+		}
+		key, value, ok := literalField(text)
+		switch {
+		case !ok:
+		case key == "apiVersion":
+			apiVersions = append(apiVersions, Object{APIVersion: value, Line: line})
+		case key == "kind":
+			doc.Kind = value
+		case inMetadata && key == "  name":
+			doc.Name = value
+		case inMetadata && key == "  namespace":
+			doc.Namespace = value
+		}
+	}
+	flush()
+	return objects
+}
+
+//templateBlock control structure of a template, an if, with, range, define or block action up to its end
+type templateBlock struct {
+	// capabilities is set once a condition of the if or of one of its else ifs reads .Capabilities
+	capabilities bool
+	// fallback is set in the else branches following a .Capabilities condition
+	fallback bool
+}
+
+//templateBlocks the template blocks a template line is nested in, innermost last
+type templateBlocks []templateBlock
+
+//fallback return if a line is in the else branch of a .Capabilities condition
+func (b templateBlocks) fallback() bool {
+	for _, block := range b {
+		if block.fallback {
+			return true
+		}
+	}
+	return false
+}
+
+//next return the blocks after the actions of a template line
+func (b templateBlocks) next(text string) templateBlocks {
+	for {
+		start := strings.Index(text, templateAction)
+		if start < 0 {
+			return b
+		}
+		text = text[start+len(templateAction):]
+		end := strings.Index(text, "}}")
+		if end < 0 {
+			return b
+		}
+		action := strings.TrimSpace(strings.Trim(text[:end], "-"))
+		text = text[end+2:]
+		word := strings.Fields(action)
+		if len(word) == 0 {
+			continue
+		}
+		switch word[0] {
+		case "if":
+			b = append(b, templateBlock{capabilities: strings.Contains(action, ".Capabilities")})
+		case "with", "range", "define", "block":
+			b = append(b, templateBlock{})
+		case "else":
+			if len(b) == 0 {
+				continue
+			}
+			last := &b[len(b)-1]
+			last.fallback = last.fallback || last.capabilities
+			last.capabilities = last.capabilities || strings.Contains(action, ".Capabilities")
+		case "end":
+			if len(b) > 0 {
+				b = b[:len(b)-1]
+			}
+		}
+	}
+}
+
+//literalField split a "key: value" template line, templated values are not literal
+func literalField(text string) (string, string, bool) {
+	if i := strings.Index(text, " #"); i >= 0 {
+		text = text[:i]
+	}
+	parts := strings.SplitN(text, ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+	if len(value) == 0 || strings.Contains(value, templateAction) {
+		return "", "", false
+	}
+	return strings.TrimRight(parts[0], " "), value, true
+}
@@ -0,0 +1,180 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/helm"
+	"k8s-outdated/kube"
+	"k8s-outdated/kube/kubetest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const releaseManifest = `---
+# Source: mychart/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: mychart/templates/cronjob.yaml
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: current
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: cleanup
+  namespace: jobs
+`
+
+type wantHelmFinding struct {
+	file       string
+	line       int
+	document   int
+	apiVersion string
+	name       string
+	namespace  string
+	helm       HelmSource
+}
+
+func helmFindings(findings []Finding) []wantHelmFinding {
+	got := make([]wantHelmFinding, 0)
+	for _, f := range findings {
+		got = append(got, wantHelmFinding{file: f.File, line: f.Line, document: f.Document, apiVersion: f.APIVersion, name: f.Name, namespace: f.Namespace, helm: *f.Helm})
+	}
+	return got
+}
+
+func encodedRelease(t *testing.T) string {
+	data, err := json.Marshal(map[string]interface{}{"name": "web", "namespace": "prod", "version": 4, "manifest": releaseManifest,
+		"chart": map[string]interface{}{"metadata": map[string]interface{}{"name": "mychart", "version": "1.2.3"}}})
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestScanChart(t *testing.T) {
+	s, err := NewScanner(apis, "v1.22.0")
+	assert.NoError(t, err)
+	findings, err := s.ScanPaths([]string{"../helm/testdata/fixture"})
+	assert.NoError(t, err)
+	assert.Equal(t, []wantHelmFinding{
+		{file: "../helm/testdata/fixture/mychart/templates/cronjob.yaml", line: 3, document: 0, apiVersion: "batch/v1beta1", name: "cleanup", namespace: "jobs",
+			helm: HelmSource{Chart: "mychart-1.2.3", Template: "templates/cronjob.yaml"}},
+		{file: "../helm/testdata/fixture/mychart/charts/cache/templates/pdb.yaml", line: 1, document: 0, apiVersion: "policy/v1beta1", name: "cache",
+			helm: HelmSource{Chart: "cache-0.1.0", Template: "templates/pdb.yaml"}},
+	}, helmFindings(findings))
+}
+
+func TestTemplateObjects(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+	}{
+		{name: "capabilities fallbacks", template: `{{- if .Capabilities.APIVersions.Has "autoscaling/v2" }}
+apiVersion: autoscaling/v2
+{{- else if .Capabilities.APIVersions.Has "autoscaling/v2beta2" }}
+apiVersion: autoscaling/v2beta2
+{{- else }}
+apiVersion: autoscaling/v2beta1
+{{- end }}
+kind: HorizontalPodAutoscaler`, want: []string{"autoscaling/v2"}},
+		{name: "capabilities in an else if", template: `{{- if .Values.legacy }}
+apiVersion: policy/v1beta1
+{{- else if semverCompare ">=1.21-0" .Capabilities.KubeVersion.GitVersion }}
+apiVersion: policy/v1
+{{- else }}
+apiVersion: policy/v1beta1
+{{- end }}
+kind: PodDisruptionBudget`, want: []string{"policy/v1beta1", "policy/v1"}},
+		{name: "values conditions", template: `{{- if .Values.v1 }}
+apiVersion: batch/v1
+{{- else }}
+apiVersion: batch/v1beta1
+{{- end }}
+kind: CronJob`, want: []string{"batch/v1", "batch/v1beta1"}},
+		{name: "nested in a capabilities fallback", template: `{{- if .Capabilities.APIVersions.Has "networking.k8s.io/v1/Ingress" }}
+apiVersion: networking.k8s.io/v1
+{{- else }}
+{{- with .Values.ingress }}
+apiVersion: extensions/v1beta1
+{{- end }}
+{{- end }}
+kind: Ingress
+---
+apiVersion: extensions/v1beta1
+kind: Ingress`, want: []string{"networking.k8s.io/v1", "extensions/v1beta1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, obj := range templateObjects([]byte(tt.template)) {
+				got = append(got, obj.APIVersion)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanReleaseFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "release")
+	assert.NoError(t, os.WriteFile(file, []byte(encodedRelease(t)), 0600))
+	s, err := NewScanner(apis, "v1.25.0")
+	assert.NoError(t, err)
+	findings, err := s.ScanReleaseFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, []wantHelmFinding{
+		{file: "prod/web.v4:mychart/templates/cronjob.yaml", line: 14, document: 1, apiVersion: "batch/v1beta1", name: "cleanup", namespace: "jobs",
+			helm: HelmSource{Chart: "mychart-1.2.3", Release: "web", Revision: 4, Template: "mychart/templates/cronjob.yaml"}},
+	}, helmFindings(findings))
+}
+
+func TestScanClusterReleases(t *testing.T) {
+	encoded := encodedRelease(t)
+	stored := func(name string, status string, secret bool) map[string]interface{} {
+		obj := map[string]interface{}{"data": map[string]interface{}{"release": encoded},
+			"metadata": map[string]interface{}{"name": name, "namespace": "prod", "labels": map[string]interface{}{"owner": "helm", "status": status}}}
+		if secret {
+			obj["type"] = "helm.sh/release.v1"
+			obj["data"] = map[string]interface{}{"release": base64.StdEncoding.EncodeToString([]byte(encoded))}
+		}
+		return obj
+	}
+	server := kubetest.NewServer("v1.25.0", "", []kubetest.Resource{
+		{Version: "v1", Kind: "Secret", Name: "secrets", Namespaced: true, Items: []map[string]interface{}{
+			stored("sh.helm.release.v1.web.v3", "superseded", true), stored("sh.helm.release.v1.web.v4", "deployed", true), kubetest.Object("token", "prod", ""),
+		}},
+		{Version: "v1", Kind: "ConfigMap", Name: "configmaps", Namespaced: true, Items: []map[string]interface{}{
+			stored("sh.helm.release.v1.web.v4", "deployed", false), kubetest.Object("settings", "prod", ""),
+		}},
+	})
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, server.WriteKubeconfig(path))
+	cfg, err := kube.LoadConfig(path, "")
+	assert.NoError(t, err)
+	client, err := kube.NewClient(cfg)
+	assert.NoError(t, err)
+	s, err := NewScanner(apis, "v1.25.0")
+	assert.NoError(t, err)
+	for _, driver := range []helm.StorageDriver{helm.DriverSecret, helm.DriverConfigMap} {
+		t.Run(string(driver), func(t *testing.T) {
+			findings, err := s.ScanClusterReleases(client, driver)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(findings))
+			assert.Equal(t, HelmSource{Chart: "mychart-1.2.3", Release: "web", Revision: 4, Template: "mychart/templates/cronjob.yaml"}, *findings[0].Helm)
+		})
+	}
+}
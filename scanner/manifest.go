@@ -0,0 +1,161 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"k8s-outdated/helm"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//StdinPath is the path used to read manifests from standard input
+const StdinPath = "-"
+
+var manifestExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+//Object k8s object found in a manifest
+type Object struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+	File       string
+	Line       int
+	// Document is the zero based index of the yaml document within File
+	Document int
+	// Node is the parsed yaml node of the object
+	Node *yaml.Node
+	// Helm is set for objects found in helm charts and releases
+	Helm *HelmSource
+}
+
+//ManifestFiles resolve files and directories to the list of manifest files and helm charts to scan
+func ManifestFiles(paths []string) ([]string, error) {
+	files := make([]string, 0)
+	for _, p := range paths {
+		if p == StdinPath {
+			files = append(files, p)
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != p && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				if helm.IsChart(path) {
+					files = append(files, path)
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if manifestExtensions[strings.ToLower(filepath.Ext(path))] || helm.IsChart(path) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+//ReadObjects read all k8s objects from a manifest file, StdinPath reads standard input
+func ReadObjects(file string) ([]Object, error) {
+	if file == StdinPath {
+		return DecodeObjects(os.Stdin, file)
+	}
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+	return DecodeObjects(bytes.NewReader(data), file)
+}
+
+//DecodeObjects decode multi document yaml or json manifests, items of List kinds are returned as objects
+func DecodeObjects(r io.Reader, file string) ([]Object, error) {
+	objects := make([]Object, 0)
+	decoder := yaml.NewDecoder(r)
+	for doc := 0; ; doc++ {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: document %d: %w", file, doc, err)
+		}
+		if len(node.Content) == 0 {
+			continue
+		}
+		objects = appendObjects(objects, node.Content[0], file, doc)
+	}
+}
+
+func appendObjects(objects []Object, node *yaml.Node, file string, doc int) []Object {
+	if node.Kind != yaml.MappingNode {
+		return objects
+	}
+	obj := Object{
+		APIVersion: scalarValue(node, "apiVersion"),
+		Kind:       scalarValue(node, "kind"),
+		File:       file,
+		Line:       node.Line,
+		Document:   doc,
+		Node:       node,
+	}
+	if metadata := mappingValue(node, "metadata"); metadata != nil {
+		obj.Name = scalarValue(metadata, "name")
+		obj.Namespace = scalarValue(metadata, "namespace")
+	}
+	if items := mappingValue(node, "items"); items != nil && items.Kind == yaml.SequenceNode && isList(obj.Kind) {
+		for _, item := range items.Content {
+			objects = appendObjects(objects, item, file, doc)
+		}
+		return objects
+	}
+	if len(obj.APIVersion) == 0 || len(obj.Kind) == 0 {
+		return objects
+	}
+	return append(objects, obj)
+}
+
+func isList(kind string) bool {
+	return strings.HasSuffix(kind, "List")
+}
+
+//mappingValue return the value node of key in a yaml mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalarValue(node *yaml.Node, key string) string {
+	v := mappingValue(node, key)
+	if v == nil || v.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return v.Value
+}
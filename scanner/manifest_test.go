@@ -0,0 +1,47 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDecodeObjects(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     []Object
+		wantErr  bool
+	}{
+		{name: "multi document", manifest: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: b\n  namespace: ns\n",
+			want: []Object{{APIVersion: "v1", Kind: "Pod", Name: "a", File: "f", Line: 1}, {APIVersion: "v1", Kind: "Pod", Name: "b", Namespace: "ns", File: "f", Line: 6, Document: 1}}},
+		{name: "typed list", manifest: "apiVersion: apps/v1\nkind: DeploymentList\nitems:\n- apiVersion: apps/v1\n  kind: Deployment\n  metadata:\n    name: a\n",
+			want: []Object{{APIVersion: "apps/v1", Kind: "Deployment", Name: "a", File: "f", Line: 4}}},
+		{name: "json", manifest: `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "a"}}`,
+			want: []Object{{APIVersion: "v1", Kind: "Pod", Name: "a", File: "f", Line: 1}}},
+		{name: "not a k8s object", manifest: "name: values\nreplicas: 1\n", want: []Object{}},
+		{name: "invalid yaml", manifest: "apiVersion: v1\nkind: [Pod\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeObjects(strings.NewReader(tt.manifest), "f")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			for i := range got {
+				assert.NotNil(t, got[i].Node)
+				got[i].Node = nil
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestManifestFiles(t *testing.T) {
+	got, err := ManifestFiles([]string{"./testdata/fixture/manifests", StdinPath})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"testdata/fixture/manifests/multi.yaml", "testdata/fixture/manifests/nested/list.yaml",
+		"testdata/fixture/manifests/nested/webhook.json", StdinPath}, got)
+}
@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"gopkg.in/yaml.v3"
+	"k8s-outdated/collector"
+)
+
+//WithDeprecatedMetadata return a copy of the scanner also reporting objects which carry annotations or labels
+//deprecated or removed at the target version
+func (s Scanner) WithDeprecatedMetadata(metadata []*collector.DeprecatedMetadata) *Scanner {
+	s.metadata = metadata
+	return &s
+}
+
+//matchMetadata return a finding for every deprecated annotation and label of the object and of its pod templates
+func (s Scanner) matchMetadata(obj Object) []Finding {
+	findings := make([]Finding, 0)
+	if obj.Node == nil || len(s.metadata) == 0 {
+		return findings
+	}
+	walkMetadata(obj.Node, "", func(path string, t collector.MetadataType, key *yaml.Node) {
+		for _, m := range s.metadata {
+			if m.Type != t || !m.Matches(obj.Kind, key.Value) {
+				continue
+			}
+			status, ok := s.lifecycleStatus(m.Deprecated, m.Removed)
+			if !ok {
+				continue
+			}
+			found := obj
+			if found.Line > 0 {
+				found.Line = key.Line
+			}
+			findings = append(findings, Finding{Object: found, Metadata: m, Path: path + "[" + key.Value + "]", Status: status})
+			return
+		}
+	})
+	return findings
+}
+
+//walkMetadata call visit with the key node of every annotation and label of the metadata mappings found under node,
+//path is the location of the annotations or labels mapping, e.g. spec.template.metadata.annotations
+func walkMetadata(node *yaml.Node, path string, visit func(path string, t collector.MetadataType, key *yaml.Node)) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			walkMetadata(item, path+"[]", visit)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i].Value, node.Content[i+1]
+			child := name
+			if len(path) > 0 {
+				child = path + "." + name
+			}
+			if name != "metadata" {
+				walkMetadata(value, child, visit)
+				continue
+			}
+			for _, t := range []collector.MetadataType{collector.Annotation, collector.Label} {
+				values := mappingValue(value, string(t)+"s")
+				if values == nil || values.Kind != yaml.MappingNode {
+					continue
+				}
+				for j := 0; j < len(values.Content); j += 2 {
+					visit(child+"."+string(t)+"s", t, values.Content[j])
+				}
+			}
+		}
+	}
+}
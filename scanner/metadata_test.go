@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"strings"
+	"testing"
+)
+
+type wantMetadataFinding struct {
+	line   int
+	kind   string
+	path   string
+	status Status
+}
+
+func TestScanDeprecatedMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   []wantMetadataFinding
+	}{
+		{name: "before the seccomp removal", target: "v1.24.0", want: []wantMetadataFinding{
+			{line: 6, kind: "Ingress", path: "metadata.annotations[kubernetes.io/ingress.class]", status: StatusDeprecated},
+			{line: 24, kind: "Deployment", path: "spec.template.metadata.annotations[seccomp.security.alpha.kubernetes.io/pod]", status: StatusDeprecated},
+			{line: 25, kind: "Deployment", path: "spec.template.metadata.annotations[container.seccomp.security.alpha.kubernetes.io/web]", status: StatusDeprecated},
+		}},
+		{name: "seccomp removed", target: "v1.25.0", want: []wantMetadataFinding{
+			{line: 6, kind: "Ingress", path: "metadata.annotations[kubernetes.io/ingress.class]", status: StatusDeprecated},
+			{line: 24, kind: "Deployment", path: "spec.template.metadata.annotations[seccomp.security.alpha.kubernetes.io/pod]", status: StatusRemoved},
+			{line: 25, kind: "Deployment", path: "spec.template.metadata.annotations[container.seccomp.security.alpha.kubernetes.io/web]", status: StatusRemoved},
+		}},
+		{name: "nothing deprecated yet", target: "v1.12.0", want: []wantMetadataFinding{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScanner(apis, tt.target)
+			assert.NoError(t, err)
+			findings, err := s.WithDeprecatedMetadata(collector.DeprecatedMetadataDataset()).ScanPaths([]string{"./testdata/fixture/metadata"})
+			assert.NoError(t, err)
+			got := make([]wantMetadataFinding, 0)
+			for _, f := range findings {
+				assert.NotNil(t, f.Metadata)
+				got = append(got, wantMetadataFinding{line: f.Line, kind: f.Kind, path: f.Path, status: f.Status})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanDeprecatedLabels(t *testing.T) {
+	objects, err := DecodeObjects(strings.NewReader("apiVersion: v1\nkind: Node\nmetadata:\n  name: a\n  labels:\n    beta.kubernetes.io/arch: amd64\n    kubernetes.io/arch: amd64\n"), "f")
+	assert.NoError(t, err)
+	s, err := NewScanner(nil, "v1.20.0")
+	assert.NoError(t, err)
+	findings := s.WithDeprecatedMetadata(collector.DeprecatedMetadataDataset()).Match(objects)
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "metadata.labels[beta.kubernetes.io/arch]", findings[0].Path)
+	assert.Equal(t, "kubernetes.io/arch", findings[0].Metadata.Replacement)
+	assert.Equal(t, 6, findings[0].Line)
+}
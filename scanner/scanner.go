@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"k8s-outdated/collector"
+	"k8s-outdated/helm"
+)
+
+//Status lifecycle status of an api at the target k8s version
+type Status = collector.Status
+
+//Lifecycle statuses reported by the scanner
+const (
+	StatusDeprecated = collector.StatusDeprecated
+	StatusRemoved    = collector.StatusRemoved
+)
+
+//Finding k8s object using an outdated api
+type Finding struct {
+	Object
+	// API is the outdated api the object uses, nil for findings on a deprecated field
+	API *collector.OutdatedAPI
+	// Field is the deprecated field the object sets, nil for findings on an outdated api
+	Field *collector.DeprecatedField
+	// Metadata is the deprecated annotation or label the object carries
+	Metadata *collector.DeprecatedMetadata
+	// Path locates the deprecated field, annotation or label in the object, e.g. metadata.annotations[kubernetes.io/ingress.class]
+	Path   string
+	Status Status
+}
+
+//Scanner match k8s objects against outdated apis at a target k8s version
+type Scanner struct {
+	apis     map[collector.Gvk]*collector.OutdatedAPI
+	fields   map[collector.Gvk][]*collector.DeprecatedField
+	metadata []*collector.DeprecatedMetadata
+	target   collector.KubeVersion
+}
+
+//NewScanner instansiate new Scanner for the target k8s version
+func NewScanner(apis []*collector.OutdatedAPI, targetVersion string) (*Scanner, error) {
+	target, err := collector.ParseKubeVersion(targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	byGvk := make(map[collector.Gvk]*collector.OutdatedAPI)
+	for _, api := range apis {
+		byGvk[api.Gav] = api
+	}
+	return &Scanner{apis: byGvk, target: target}, nil
+}
+
+//ScanPaths scan manifest files and directories
+func (s Scanner) ScanPaths(paths []string) ([]Finding, error) {
+	files, err := ManifestFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	findings := make([]Finding, 0)
+	for _, file := range files {
+		if file != StdinPath && helm.IsChart(file) {
+			chartFindings, err := s.ScanChart(file)
+			if err != nil {
+				return nil, err
+			}
+			findings = append(findings, chartFindings...)
+			continue
+		}
+		objects, err := ReadObjects(file)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, s.Match(objects)...)
+	}
+	return findings, nil
+}
+
+//Match return findings for objects using apis, setting fields or carrying annotations and labels deprecated or removed at the target version
+func (s Scanner) Match(objects []Object) []Finding {
+	findings := make([]Finding, 0)
+	for _, obj := range objects {
+		gvk, err := collector.ParseGvk(obj.APIVersion, obj.Kind)
+		if err != nil {
+			continue
+		}
+		if api, ok := s.apis[gvk]; ok {
+			if status, ok := s.StatusOf(api); ok {
+				findings = append(findings, Finding{Object: obj, API: api, Status: status})
+			}
+		}
+		findings = append(findings, s.matchFields(obj, gvk)...)
+		findings = append(findings, s.matchMetadata(obj)...)
+	}
+	return findings
+}
+
+//StatusOf return the lifecycle status of api at the target version, false when the api is still fully supported
+func (s Scanner) StatusOf(api *collector.OutdatedAPI) (Status, bool) {
+	return api.StatusAt(s.target)
+}
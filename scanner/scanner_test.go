@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s-outdated/collector"
+	"testing"
+)
+
+var apis = []*collector.OutdatedAPI{
+	{Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Gav: collector.Gvk{Group: "batch", Version: "v1beta1", Kind: "CronJob"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.21"), Removed: collector.MustParseKubeVersion("v1.25"), Gav: collector.Gvk{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.14"), Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}},
+	{Deprecated: collector.MustParseKubeVersion("v1.16"), Removed: collector.MustParseKubeVersion("v1.22"), Gav: collector.Gvk{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "MutatingWebhookConfiguration"}},
+}
+
+type wantFinding struct {
+	file      string
+	line      int
+	document  int
+	kind      string
+	name      string
+	namespace string
+	status    Status
+}
+
+func TestScanPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		paths  []string
+		target string
+		want   []wantFinding
+	}{
+		{name: "directory at v1.22", paths: []string{"./testdata/fixture/manifests"}, target: "v1.22.0", want: []wantFinding{
+			{file: "testdata/fixture/manifests/multi.yaml", line: 2, document: 0, kind: "CronJob", name: "hello", namespace: "batch", status: StatusDeprecated},
+			{file: "testdata/fixture/manifests/multi.yaml", line: 16, document: 3, kind: "PodDisruptionBudget", name: "web-pdb", status: StatusDeprecated},
+			{file: "testdata/fixture/manifests/nested/list.yaml", line: 4, document: 0, kind: "Ingress", name: "web", namespace: "default", status: StatusRemoved},
+			{file: "testdata/fixture/manifests/nested/webhook.json", line: 1, document: 0, kind: "MutatingWebhookConfiguration", name: "sidecar-injector", status: StatusRemoved},
+		}},
+		{name: "single file at v1.25", paths: []string{"./testdata/fixture/manifests/multi.yaml"}, target: "v1.25.3", want: []wantFinding{
+			{file: "./testdata/fixture/manifests/multi.yaml", line: 2, document: 0, kind: "CronJob", name: "hello", namespace: "batch", status: StatusRemoved},
+			{file: "./testdata/fixture/manifests/multi.yaml", line: 16, document: 3, kind: "PodDisruptionBudget", name: "web-pdb", status: StatusRemoved},
+		}},
+		{name: "nothing outdated yet", paths: []string{"./testdata/fixture/manifests/multi.yaml"}, target: "v1.20.0", want: []wantFinding{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScanner(apis, tt.target)
+			assert.NoError(t, err)
+			findings, err := s.ScanPaths(tt.paths)
+			assert.NoError(t, err)
+			got := make([]wantFinding, 0)
+			for _, f := range findings {
+				got = append(got, wantFinding{file: f.File, line: f.Line, document: f.Document, kind: f.Kind, name: f.Name, namespace: f.Namespace, status: f.Status})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanPathsErrors(t *testing.T) {
+	_, err := NewScanner(apis, "latest")
+	assert.Error(t, err)
+	s, err := NewScanner(apis, "v1.25.0")
+	assert.NoError(t, err)
+	_, err = s.ScanPaths([]string{"./testdata/fixture/missing"})
+	assert.Error(t, err)
+}